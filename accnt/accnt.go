@@ -58,7 +58,8 @@ func (p Private) Sign(in []byte) (Signature, error) {
 }
 
 func Recover(in []byte, s Signature) (Public, error) {
-	sig := append(s.R.Bytes(), s.S.Bytes()...)
+	// R and S are padded to 32 bytes, as they can have leading zeros.
+	sig := append(s.R.FillBytes(make([]byte, 32)), s.S.FillBytes(make([]byte, 32))...)
 	sig = append(sig, func(b bool) byte {
 		if b {
 			return 1
@@ -96,7 +97,7 @@ func NewAddress(hx string) (Address, error) {
 
 func (p Public) Address() Address {
 	// Create ECDSA public key (64 bytes) as concatenation of x and y points.
-	pub := append(p.X.Bytes(), p.Y.Bytes()...)
+	pub := append(p.X.FillBytes(make([]byte, 32)), p.Y.FillBytes(make([]byte, 32))...)
 
 	// Keccak-256 hash of pub key (32 bytes)
 	// ehtereum uses a special Keccak256 configuration with dsbyte: 0x01
@@ -1,6 +1,9 @@
 package accnt
 
 import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/crypto"
@@ -18,7 +21,7 @@ func TestAddress(t *testing.T) {
 		if err != nil {
 			t.Fatal(err)
 		}
-		if ad := acct.Address(); ad != test.address {
+		if ad := acct.Address(); ad.String() != test.address {
 			t.Fatalf("Expected: %s, received: %s", test.address, ad)
 		}
 	}
@@ -41,7 +44,49 @@ func TestSignRecover(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if priv.Address() != pub.Address() {
+	if !bytes.Equal(priv.Address(), pub.Address()) {
 		t.Fatalf("Expected: %s, received: %s", priv.Address(), pub.Address())
 	}
 }
+
+func TestLeadingZeros(t *testing.T) {
+	// The public key X of this account, and the r and s of its signatures of
+	// the messages, start with a zero byte.
+	priv, err := NewAccount("06a1b3e3589496977ec2241897c39904215562b3a4b5a558f98e5387e73775f1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := "0x42d098828488ed4420ebd952db261d4ea8a4749e"
+	if a := priv.Address().String(); a != address {
+		t.Fatalf("Expected: %s, received: %s", address, a)
+	}
+
+	var tests = []struct {
+		msg, sig string
+	}{
+		{
+			"1d18e83eb085cf04a0556c1c174bdb058d7e1e563ac4c3952bd9eca6532f70b1",
+			"003bf9f023d24556e3fca53e735e9d6c486ff22d314afdaa6cb81b62aff8c4353065dbb460968fceee2edcc1bd7643fda3dbb3d5e652857b8740cf60d791bfe200",
+		},
+		{
+			"782c005f1bc4ae46bb9bc401407978ffa8784e0a06fc2c39ba5e769b373fcaf1",
+			"bd1573184905d16862fd8eadfbd77b2d0e497870389c3b1c1100673e0a6e517300361510f82e18b7d16a504a718644897e575dfb4366fad03abf972d2a9944c501",
+		},
+	}
+
+	for _, test := range tests {
+		msg, _ := hex.DecodeString(test.msg)
+		sig, _ := hex.DecodeString(test.sig)
+		pub, err := Recover(msg, Signature{
+			R: new(big.Int).SetBytes(sig[:32]),
+			S: new(big.Int).SetBytes(sig[32:64]),
+			V: sig[64] == 1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a := pub.Address().String(); a != address {
+			t.Fatalf("Expected: %s, received: %s", address, a)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package block
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"ethereum/txn"
+	"ethereum/util"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Header is a block header as returned by a node.  Fields which were added by
+// a hard fork are left at their zero value (nil or "") for blocks produced
+// before that fork.
+type Header struct {
+	ParentHash       string
+	Sha3Uncles       string
+	Miner            string
+	StateRoot        string
+	TransactionsRoot string
+	ReceiptsRoot     string
+	LogsBloom        []byte
+	Difficulty       *big.Int
+	Number           uint64
+	GasLimit         uint64
+	GasUsed          uint64
+	Timestamp        uint64
+	ExtraData        []byte
+	MixHash          string
+	Nonce            uint64
+
+	// London
+	BaseFeePerGas *big.Int
+
+	// Shanghai
+	WithdrawalsRoot string
+
+	// Cancun
+	BlobGasUsed           *uint64
+	ExcessBlobGas         *uint64
+	ParentBeaconBlockRoot string
+}
+
+// Block is a block as returned by a node.  Depending on how the block was
+// requested, either Transactions or TransactionHashes is populated.
+type Block struct {
+	Header
+	Hash              string
+	Size              uint64
+	Transactions      []txn.BlockTransaction
+	TransactionHashes []string
+	Uncles            []string
+	Withdrawals       []Withdrawal
+}
+
+// Withdrawal is a validator withdrawal from the beacon chain (Shanghai).
+type Withdrawal struct {
+	Index          uint64
+	ValidatorIndex uint64
+	Address        string
+	Amount         uint64 // in Gwei
+}
+
+// Encode returns the RLP encoding of the header.  Fields introduced by a fork
+// are only included if they are set, which matches how each fork extended
+// the header.
+func (h Header) Encode() []byte {
+	fields := [][]byte{
+		decodeHex(h.ParentHash),
+		decodeHex(h.Sha3Uncles),
+		decodeHex(h.Miner),
+		decodeHex(h.StateRoot),
+		decodeHex(h.TransactionsRoot),
+		decodeHex(h.ReceiptsRoot),
+		h.LogsBloom,
+		bigIntBytes(h.Difficulty),
+		util.IntToArr(h.Number),
+		util.IntToArr(h.GasLimit),
+		util.IntToArr(h.GasUsed),
+		util.IntToArr(h.Timestamp),
+		h.ExtraData,
+		decodeHex(h.MixHash),
+		func(n uint64) []byte {
+			// The nonce is always encoded as 8 bytes.
+			b := make([]byte, 8)
+			binary.BigEndian.PutUint64(b, n)
+			return b
+		}(h.Nonce),
+	}
+
+	if h.BaseFeePerGas == nil {
+		return util.EncodeRLP(fields)
+	}
+	fields = append(fields, bigIntBytes(h.BaseFeePerGas))
+
+	if h.WithdrawalsRoot == "" {
+		return util.EncodeRLP(fields)
+	}
+	fields = append(fields, decodeHex(h.WithdrawalsRoot))
+
+	if h.BlobGasUsed == nil || h.ExcessBlobGas == nil {
+		return util.EncodeRLP(fields)
+	}
+	fields = append(fields,
+		util.IntToArr(*h.BlobGasUsed),
+		util.IntToArr(*h.ExcessBlobGas),
+		decodeHex(h.ParentBeaconBlockRoot),
+	)
+
+	return util.EncodeRLP(fields)
+}
+
+// Hash returns the `0xHEX` formatted keccak256 hash of the RLP encoded header,
+// which is the block hash.
+func (h Header) Hash() string {
+	return "0x" + hex.EncodeToString(crypto.Keccak256(h.Encode()))
+}
+
+// VerifyHash checks that the hash reported for the block matches the hash of
+// its header.
+func (b Block) VerifyHash() error {
+	if h := b.Header.Hash(); h != strings.ToLower(b.Hash) {
+		return fmt.Errorf("block %d: header hashes to %s, expected %s", b.Number, h, b.Hash)
+	}
+	return nil
+}
+
+// VerifyChain checks that the given headers, ordered by ascending block
+// number, form a chain: every header has the number following its
+// predecessor's, and references its predecessor's hash as parent.
+func VerifyChain(headers []Header) error {
+	for i := 1; i < len(headers); i++ {
+		parent, h := headers[i-1], headers[i]
+		if h.Number != parent.Number+1 {
+			return fmt.Errorf("block %d does not follow block %d", h.Number, parent.Number)
+		}
+
+		if ph := parent.Hash(); strings.ToLower(h.ParentHash) != ph {
+			return fmt.Errorf("block %d: parent hash is %s, expected %s", h.Number, h.ParentHash, ph)
+		}
+	}
+
+	return nil
+}
+
+// decodeHex decodes a `0xHEX` formatted string, returning an empty slice for
+// empty or malformed input.
+func decodeHex(h string) []byte {
+	if len(h) < 2 {
+		return []byte{}
+	}
+	b, err := hex.DecodeString(h[2:])
+	if err != nil {
+		return []byte{}
+	}
+	return b
+}
+
+func bigIntBytes(in *big.Int) []byte {
+	if in == nil {
+		return []byte{}
+	}
+	return in.Bytes()
+}
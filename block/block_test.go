@@ -0,0 +1,131 @@
+package block
+
+import (
+	"bytes"
+	"ethereum/util"
+	"math/big"
+	"testing"
+)
+
+func genesis() Header {
+	return Header{
+		ParentHash:       "0x0000000000000000000000000000000000000000000000000000000000000000",
+		Sha3Uncles:       "0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347",
+		Miner:            "0x0000000000000000000000000000000000000000",
+		StateRoot:        "0xd7f8974fb5ac78d9ac099b9ad5018bedc2ce0a72dad1827a1709da30580f0544",
+		TransactionsRoot: "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421",
+		ReceiptsRoot:     "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421",
+		LogsBloom:        make([]byte, 256),
+		Difficulty:       big.NewInt(17179869184),
+		GasLimit:         5000,
+		ExtraData: []byte{17, 187, 232, 219, 78, 52, 123, 78, 140, 147, 124, 28, 131, 112, 228, 181, 237, 51, 173, 179, 219, 105,
+			203, 219, 122, 56, 225, 229, 11, 27, 130, 250},
+		MixHash: "0x0000000000000000000000000000000000000000000000000000000000000000",
+		Nonce:   66,
+	}
+}
+
+func TestHash(t *testing.T) {
+	// Mainnet genesis block.
+	expected := "0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3"
+	if h := genesis().Hash(); h != expected {
+		t.Fatalf("Expected: %s, received: %s", expected, h)
+	}
+}
+
+func TestEncodeForks(t *testing.T) {
+	zero := uint64(0)
+	blobGas := uint64(131072)
+	root := "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421"
+
+	frontier := genesis()
+
+	london := genesis()
+	london.BaseFeePerGas = big.NewInt(1000000000)
+
+	shanghai := london
+	shanghai.WithdrawalsRoot = root
+
+	cancun := shanghai
+	cancun.BlobGasUsed = &blobGas
+	cancun.ExcessBlobGas = &zero
+	cancun.ParentBeaconBlockRoot = root
+
+	var tests = []struct {
+		header Header
+		fields int
+	}{
+		{frontier, 15},
+		{london, 16},
+		{shanghai, 17},
+		{cancun, 20},
+	}
+
+	for _, test := range tests {
+		d, err := util.DecodeRLP(bytes.NewBuffer(test.header.Encode()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fields, ok := d.([]interface{})
+		if !ok {
+			t.Fatalf("Expected rlp list, received: %T", d)
+		}
+
+		if len(fields) != test.fields {
+			t.Fatalf("Expected: %d fields, received: %d", test.fields, len(fields))
+		}
+
+		// The nonce must always be 8 bytes, even with leading zeros.
+		if n := fields[14].([]byte); !bytes.Equal(n, []byte{0, 0, 0, 0, 0, 0, 0, 66}) {
+			t.Fatalf("Expected: 8 byte nonce, received: %v", n)
+		}
+	}
+
+	if frontier.Hash() == london.Hash() || london.Hash() == shanghai.Hash() || shanghai.Hash() == cancun.Hash() {
+		t.Fatal("Expected fork fields to change the hash")
+	}
+}
+
+func TestVerifyHash(t *testing.T) {
+	b := Block{
+		Header: genesis(),
+		Hash:   "0xD4E56740F876AEF8C010B86A40D5F56745A118D0906A34E69AEC8C0DB1CB8FA3",
+	}
+	if err := b.VerifyHash(); err != nil {
+		t.Fatal(err)
+	}
+
+	b.GasLimit++
+	if err := b.VerifyHash(); err == nil {
+		t.Fatal("Expected error for modified header")
+	}
+}
+
+func TestVerifyChain(t *testing.T) {
+	g := genesis()
+
+	child := genesis()
+	child.Number = 1
+	child.ParentHash = g.Hash()
+
+	grandchild := genesis()
+	grandchild.Number = 2
+	grandchild.ParentHash = child.Hash()
+
+	if err := VerifyChain([]Header{g, child, grandchild}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Tampering with a header breaks the link to its child.
+	tampered := child
+	tampered.Timestamp = 1
+	if err := VerifyChain([]Header{g, tampered, grandchild}); err == nil {
+		t.Fatal("Expected error for tampered header")
+	}
+
+	// Gaps are not allowed.
+	if err := VerifyChain([]Header{g, grandchild}); err == nil {
+		t.Fatal("Expected error for missing header")
+	}
+}
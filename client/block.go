@@ -0,0 +1,175 @@
+package client
+
+import (
+	"encoding/json"
+	"ethereum/block"
+	"ethereum/contract"
+	"ethereum/util"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ErrNotFound is returned when the node has no data for the requested item,
+// such as the receipt of a transaction which isn't mined.  It is
+// contract.ErrNotFound, so that DeployAndWait can wait for such receipts.
+var ErrNotFound = contract.ErrNotFound
+
+// BlockNumber returns the number of the most recent block.
+func (c Client) BlockNumber() (uint64, error) {
+	var result hexutil.Uint64
+	err := c.Call(&result, "eth_blockNumber")
+	return uint64(result), err
+}
+
+// GetBlockByNumber returns the block with the given number, or the latest block
+// if number is nil.  If full is set, the block's transactions are returned in
+// Transactions, otherwise only their hashes are returned in TransactionHashes.
+func (c Client) GetBlockByNumber(number *big.Int, full bool) (block.Block, error) {
+	return c.getBlock("eth_getBlockByNumber", blockTag(number), full)
+}
+
+// GetBlockByHash returns the block with the given hash.  See GetBlockByNumber
+// for the meaning of full.
+func (c Client) GetBlockByHash(hash string, full bool) (block.Block, error) {
+	return c.getBlock("eth_getBlockByHash", hash, full)
+}
+
+// GetHeader returns the header of the block with the given number, or of the
+// latest block if number is nil.
+func (c Client) GetHeader(number *big.Int) (block.Header, error) {
+	b, err := c.getBlock("eth_getBlockByNumber", blockTag(number), false)
+	return b.Header, err
+}
+
+func (c Client) getBlock(method string, id string, full bool) (block.Block, error) {
+	var raw *rawBlock
+	if err := c.Call(&raw, method, id, full); err != nil {
+		return block.Block{}, err
+	}
+	if raw == nil {
+		return block.Block{}, ErrNotFound
+	}
+
+	return raw.block()
+}
+
+// blockTag returns the JSON-RPC representation of a block number, with nil
+// meaning the latest block.
+func blockTag(number *big.Int) string {
+	if number == nil {
+		return "latest"
+	}
+	return hexutil.EncodeBig(number)
+}
+
+type rawHeader struct {
+	ParentHash            string `json:"parentHash"`
+	Sha3Uncles            string `json:"sha3Uncles"`
+	Miner                 string `json:"miner"`
+	StateRoot             string `json:"stateRoot"`
+	TransactionsRoot      string `json:"transactionsRoot"`
+	ReceiptsRoot          string `json:"receiptsRoot"`
+	LogsBloom             string `json:"logsBloom"`
+	Difficulty            string `json:"difficulty"`
+	Number                string `json:"number"`
+	GasLimit              string `json:"gasLimit"`
+	GasUsed               string `json:"gasUsed"`
+	Timestamp             string `json:"timestamp"`
+	ExtraData             string `json:"extraData"`
+	MixHash               string `json:"mixHash"`
+	Nonce                 string `json:"nonce"`
+	BaseFeePerGas         string `json:"baseFeePerGas"`
+	WithdrawalsRoot       string `json:"withdrawalsRoot"`
+	BlobGasUsed           string `json:"blobGasUsed"`
+	ExcessBlobGas         string `json:"excessBlobGas"`
+	ParentBeaconBlockRoot string `json:"parentBeaconBlockRoot"`
+}
+
+func (r rawHeader) header() block.Header {
+	return block.Header{
+		ParentHash:            r.ParentHash,
+		Sha3Uncles:            r.Sha3Uncles,
+		Miner:                 r.Miner,
+		StateRoot:             r.StateRoot,
+		TransactionsRoot:      r.TransactionsRoot,
+		ReceiptsRoot:          r.ReceiptsRoot,
+		LogsBloom:             hexToBytes(r.LogsBloom),
+		Difficulty:            util.HexToBigInt(r.Difficulty),
+		Number:                util.HexToUint64(r.Number),
+		GasLimit:              util.HexToUint64(r.GasLimit),
+		GasUsed:               util.HexToUint64(r.GasUsed),
+		Timestamp:             util.HexToUint64(r.Timestamp),
+		ExtraData:             hexToBytes(r.ExtraData),
+		MixHash:               r.MixHash,
+		Nonce:                 util.HexToUint64(r.Nonce),
+		BaseFeePerGas:         hexToBigInt(r.BaseFeePerGas),
+		WithdrawalsRoot:       r.WithdrawalsRoot,
+		BlobGasUsed:           hexToUint64Ptr(r.BlobGasUsed),
+		ExcessBlobGas:         hexToUint64Ptr(r.ExcessBlobGas),
+		ParentBeaconBlockRoot: r.ParentBeaconBlockRoot,
+	}
+}
+
+type rawWithdrawal struct {
+	Index          string `json:"index"`
+	ValidatorIndex string `json:"validatorIndex"`
+	Address        string `json:"address"`
+	Amount         string `json:"amount"`
+}
+
+type rawBlock struct {
+	rawHeader
+	Hash         string            `json:"hash"`
+	Size         string            `json:"size"`
+	Transactions []json.RawMessage `json:"transactions"`
+	Uncles       []string          `json:"uncles"`
+	Withdrawals  []rawWithdrawal   `json:"withdrawals"`
+}
+
+func (r rawBlock) block() (block.Block, error) {
+	b := block.Block{
+		Header: r.header(),
+		Hash:   r.Hash,
+		Size:   util.HexToUint64(r.Size),
+		Uncles: r.Uncles,
+	}
+
+	// Transactions are either all hashes or all objects, depending on whether
+	// full transactions were requested.
+	for _, t := range r.Transactions {
+		if len(t) > 0 && t[0] == '"' {
+			var hash string
+			if err := json.Unmarshal(t, &hash); err != nil {
+				return block.Block{}, err
+			}
+			b.TransactionHashes = append(b.TransactionHashes, hash)
+			continue
+		}
+
+		var rt rawBlockTxn
+		if err := json.Unmarshal(t, &rt); err != nil {
+			return block.Block{}, err
+		}
+		b.Transactions = append(b.Transactions, rt.blockTransaction())
+	}
+
+	for _, w := range r.Withdrawals {
+		b.Withdrawals = append(b.Withdrawals, block.Withdrawal{
+			Index:          util.HexToUint64(w.Index),
+			ValidatorIndex: util.HexToUint64(w.ValidatorIndex),
+			Address:        w.Address,
+			Amount:         util.HexToUint64(w.Amount),
+		})
+	}
+
+	return b, nil
+}
+
+func hexToUint64Ptr(h string) *uint64 {
+	if h == "" {
+		return nil
+	}
+	i := util.HexToUint64(h)
+	return &i
+}
@@ -0,0 +1,273 @@
+package client
+
+import (
+	"ethereum/block"
+	"ethereum/txn"
+	"ethereum/util"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// mainnet genesis block
+var genesisResponse = `{"jsonrpc":"2.0","id":1,"result":{"difficulty":"0x400000000","extraData":` +
+	`"0x11bbe8db4e347b4e8c937c1c8370e4b5ed33adb3db69cbdb7a38e1e50b1b82fa","gasLimit":"0x1388","gasUsed":"0x0","hash":` +
+	`"0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3","logsBloom":"0x` + zeroBloom + `","miner":` +
+	`"0x0000000000000000000000000000000000000000","mixHash":"0x0000000000000000000000000000000000000000000000000000000000000000",` +
+	`"nonce":"0x0000000000000042","number":"0x0","parentHash":"0x0000000000000000000000000000000000000000000000000000000000000000",` +
+	`"receiptsRoot":"0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421","sha3Uncles":` +
+	`"0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347","size":"0x21c","stateRoot":` +
+	`"0xd7f8974fb5ac78d9ac099b9ad5018bedc2ce0a72dad1827a1709da30580f0544","timestamp":"0x0","transactions":[],` +
+	`"transactionsRoot":"0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421","uncles":[]}}`
+
+var zeroBloom = strings.Repeat("00", 256)
+
+var genesisHeader = block.Header{
+	ParentHash:       "0x0000000000000000000000000000000000000000000000000000000000000000",
+	Sha3Uncles:       "0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347",
+	Miner:            "0x0000000000000000000000000000000000000000",
+	StateRoot:        "0xd7f8974fb5ac78d9ac099b9ad5018bedc2ce0a72dad1827a1709da30580f0544",
+	TransactionsRoot: "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421",
+	ReceiptsRoot:     "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421",
+	LogsBloom:        make([]byte, 256),
+	Difficulty:       big.NewInt(17179869184),
+	Number:           0,
+	GasLimit:         5000,
+	GasUsed:          0,
+	Timestamp:        0,
+	ExtraData: []byte{17, 187, 232, 219, 78, 52, 123, 78, 140, 147, 124, 28, 131, 112, 228, 181, 237, 51, 173, 179, 219, 105,
+		203, 219, 122, 56, 225, 229, 11, 27, 130, 250},
+	MixHash: "0x0000000000000000000000000000000000000000000000000000000000000000",
+	Nonce:   66,
+}
+
+func newTestServer(t *testing.T, rpcRequest, rpcResponse string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if d := string(data); d != rpcRequest {
+			t.Fatalf("Expected: %s, received: %s", rpcRequest, d)
+		}
+
+		if _, err := w.Write([]byte(rpcResponse)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+}
+
+func TestBlockNumber(t *testing.T) {
+	ts := newTestServer(t, `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}`, `{"jsonrpc":"2.0","id":1,"result":"0x12ca"}`)
+	defer ts.Close()
+
+	c, err := Dial(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := c.BlockNumber()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 4810 {
+		t.Fatalf("Expected: %d, received: %d", 4810, n)
+	}
+}
+
+func TestGetBlockByNumber(t *testing.T) {
+	var tests = []struct {
+		number      *big.Int
+		full        bool
+		rpcRequest  string
+		rpcResponse string
+		expected    block.Block
+	}{
+		{
+			number:      big.NewInt(0),
+			rpcRequest:  `{"jsonrpc":"2.0","id":1,"method":"eth_getBlockByNumber","params":["0x0",false]}`,
+			rpcResponse: genesisResponse,
+			expected: block.Block{
+				Header: genesisHeader,
+				Hash:   "0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3",
+				Size:   540,
+				Uncles: []string{},
+			},
+		},
+		{
+			number:     nil,
+			rpcRequest: `{"jsonrpc":"2.0","id":1,"method":"eth_getBlockByNumber","params":["latest",false]}`,
+			rpcResponse: `{"jsonrpc":"2.0","id":1,"result":{"baseFeePerGas":"0x7","blobGasUsed":"0x20000","difficulty":"0x0",` +
+				`"excessBlobGas":"0x0","extraData":"0x","gasLimit":"0x1c9c380","gasUsed":"0x5208","hash":` +
+				`"0x7b9a6a0b5f5c9b5cfa3e2b4ea7a7ad33b2e0a1c5e73b4de0e6ac5d0c56e1f34c","logsBloom":"0x` + zeroBloom + `",` +
+				`"miner":"0x4838b106fce9647bdf1e7877bf73ce8b0bad5f97","mixHash":` +
+				`"0x6f1b1a7e3fde3b0a2c5d6b0d3e0c1d9b9a5f2c4e1d7b3a0c8e6f4d2b1a9c7e5f","nonce":"0x0000000000000000",` +
+				`"number":"0x12a05f2","parentBeaconBlockRoot":"0x0101010101010101010101010101010101010101010101010101010101010101",` +
+				`"parentHash":"0x2a5c1f3a4b6d8e0f1a3c5e7f9b1d3f5a7c9e1b3d5f7a9c1e3b5d7f9a1c3e5b7d","receiptsRoot":` +
+				`"0x056b23fbba480696b65fe5a59b8f2148a1299103c4f57df839233af2cf4ca2d2","sha3Uncles":` +
+				`"0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347","size":"0x2bd","stateRoot":` +
+				`"0x3f1b6bb3e6a0c3d2b5e7f9a1c3e5b7d9f1a3c5e7b9d1f3a5c7e9b1d3f5a7c9e1","timestamp":"0x65f1b057","transactions":` +
+				`["0xd866f3672a3cef05f66dec56d30562bbffcc42aa11b54450e6973d52c89d1719"],"transactionsRoot":` +
+				`"0x3a8b1ff6a2a0e8d1c0d0b7c1e3b0a6a0c5e1f9d3b7a1c5e9d3b7f1a5c9e3d7b1","uncles":[],"withdrawals":[{"index":"0x2a",` +
+				`"validatorIndex":"0x3039","address":"0x2c65492bb820552334ba59b4fbb626f35a95e566","amount":"0x3b9aca00"}],` +
+				`"withdrawalsRoot":"0x9d3b7f1a5c9e3d7b1f5a9c3e7d1b5f9a3c7e1d5b9f3a7c1e5d9b3f7a1c5e9d3b"}}`,
+			expected: block.Block{
+				Header: block.Header{
+					ParentHash:            "0x2a5c1f3a4b6d8e0f1a3c5e7f9b1d3f5a7c9e1b3d5f7a9c1e3b5d7f9a1c3e5b7d",
+					Sha3Uncles:            "0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347",
+					Miner:                 "0x4838b106fce9647bdf1e7877bf73ce8b0bad5f97",
+					StateRoot:             "0x3f1b6bb3e6a0c3d2b5e7f9a1c3e5b7d9f1a3c5e7b9d1f3a5c7e9b1d3f5a7c9e1",
+					TransactionsRoot:      "0x3a8b1ff6a2a0e8d1c0d0b7c1e3b0a6a0c5e1f9d3b7a1c5e9d3b7f1a5c9e3d7b1",
+					ReceiptsRoot:          "0x056b23fbba480696b65fe5a59b8f2148a1299103c4f57df839233af2cf4ca2d2",
+					LogsBloom:             make([]byte, 256),
+					Difficulty:            util.HexToBigInt("0x0"),
+					Number:                19531250,
+					GasLimit:              30000000,
+					GasUsed:               21000,
+					Timestamp:             1710338135,
+					ExtraData:             []byte{},
+					MixHash:               "0x6f1b1a7e3fde3b0a2c5d6b0d3e0c1d9b9a5f2c4e1d7b3a0c8e6f4d2b1a9c7e5f",
+					Nonce:                 0,
+					BaseFeePerGas:         big.NewInt(7),
+					WithdrawalsRoot:       "0x9d3b7f1a5c9e3d7b1f5a9c3e7d1b5f9a3c7e1d5b9f3a7c1e5d9b3f7a1c5e9d3b",
+					BlobGasUsed:           func(i uint64) *uint64 { return &i }(131072),
+					ExcessBlobGas:         func(i uint64) *uint64 { return &i }(0),
+					ParentBeaconBlockRoot: "0x0101010101010101010101010101010101010101010101010101010101010101",
+				},
+				Hash:              "0x7b9a6a0b5f5c9b5cfa3e2b4ea7a7ad33b2e0a1c5e73b4de0e6ac5d0c56e1f34c",
+				Size:              701,
+				TransactionHashes: []string{"0xd866f3672a3cef05f66dec56d30562bbffcc42aa11b54450e6973d52c89d1719"},
+				Uncles:            []string{},
+				Withdrawals: []block.Withdrawal{
+					{
+						Index:          42,
+						ValidatorIndex: 12345,
+						Address:        "0x2c65492bb820552334ba59b4fbb626f35a95e566",
+						Amount:         1000000000,
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		ts := newTestServer(t, test.rpcRequest, test.rpcResponse)
+		defer ts.Close()
+
+		c, err := Dial(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := c.GetBlockByNumber(test.number, test.full)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(b, test.expected) {
+			t.Fatalf("Expected: %+v, received: %+v", test.expected, b)
+		}
+	}
+}
+
+func TestGetBlockByHashFull(t *testing.T) {
+	ts := newTestServer(t,
+		`{"jsonrpc":"2.0","id":1,"method":"eth_getBlockByHash","params":`+
+			`["0x12601e9203cd8b29eb2317d6f645b14b2acafc2564eb24276e75cb4ec6667a4d",true]}`,
+		`{"jsonrpc":"2.0","id":1,"result":{"difficulty":"0x2","extraData":"0x","gasLimit":"0x47e7c4","gasUsed":"0x5208",`+
+			`"hash":"0x12601e9203cd8b29eb2317d6f645b14b2acafc2564eb24276e75cb4ec6667a4d","logsBloom":"0x`+zeroBloom+`",`+
+			`"miner":"0x0000000000000000000000000000000000000000","mixHash":`+
+			`"0x0000000000000000000000000000000000000000000000000000000000000000","nonce":"0x0000000000000000","number":"0x12ca",`+
+			`"parentHash":"0x0000000000000000000000000000000000000000000000000000000000000000","receiptsRoot":`+
+			`"0x056b23fbba480696b65fe5a59b8f2148a1299103c4f57df839233af2cf4ca2d2","sha3Uncles":`+
+			`"0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347","size":"0x28f","stateRoot":`+
+			`"0x0000000000000000000000000000000000000000000000000000000000000000","timestamp":"0x5a0c5ba2","transactions":`+
+			`[{"blockHash":"0x12601e9203cd8b29eb2317d6f645b14b2acafc2564eb24276e75cb4ec6667a4d","blockNumber":"0x12ca","from":`+
+			`"0x9d39856f91822ff0bdc2e234bb0d40124a201677","gas":"0x5208","gasPrice":"0x4a817c800","hash":`+
+			`"0xd866f3672a3cef05f66dec56d30562bbffcc42aa11b54450e6973d52c89d1719","input":"0x","nonce":"0x1","to":`+
+			`"0x2c65492bb820552334ba59b4fbb626f35a95e566","transactionIndex":"0x0","value":"0x15af1d78b58c40000","v":"0x1c","r":`+
+			`"0x2083a43ac72ca892e22e805003926850a0da13d9aeb0ef1c4405de35a67d8447","s":`+
+			`"0x6502a37bd5cd629128dd889ee8916acd8d2193f1301c00d9064cbada640a9b58"}],"transactionsRoot":`+
+			`"0x0000000000000000000000000000000000000000000000000000000000000000","uncles":[]}}`)
+	defer ts.Close()
+
+	c, err := Dial(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := c.GetBlockByHash("0x12601e9203cd8b29eb2317d6f645b14b2acafc2564eb24276e75cb4ec6667a4d", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(b.TransactionHashes) != 0 {
+		t.Fatalf("Expected no transaction hashes, received: %+v", b.TransactionHashes)
+	}
+
+	if len(b.Transactions) != 1 {
+		t.Fatalf("Expected: 1 transaction, received: %d", len(b.Transactions))
+	}
+
+	expected := txn.BlockTransaction{
+		BlockHash:        "0x12601e9203cd8b29eb2317d6f645b14b2acafc2564eb24276e75cb4ec6667a4d",
+		BlockNumber:      4810,
+		From:             "0x9d39856f91822ff0bdc2e234bb0d40124a201677",
+		Gas:              big.NewInt(21000),
+		GasPrice:         big.NewInt(2e10),
+		Hash:             "0xd866f3672a3cef05f66dec56d30562bbffcc42aa11b54450e6973d52c89d1719",
+		Input:            []byte{},
+		Nonce:            1,
+		To:               "0x2c65492bb820552334ba59b4fbb626f35a95e566",
+		TransactionIndex: 0,
+		Value:            util.EthToWei(25),
+		V:                28,
+		R: new(big.Int).SetBytes([]byte{32, 131, 164, 58, 199, 44, 168, 146, 226, 46, 128, 80, 3, 146, 104, 80, 160, 218, 19,
+			217, 174, 176, 239, 28, 68, 5, 222, 53, 166, 125, 132, 71}),
+		S: new(big.Int).SetBytes([]byte{101, 2, 163, 123, 213, 205, 98, 145, 40, 221, 136, 158, 232, 145, 106, 205, 141, 33,
+			147, 241, 48, 28, 0, 217, 6, 76, 186, 218, 100, 10, 155, 88}),
+	}
+	if !reflect.DeepEqual(b.Transactions[0], expected) {
+		t.Fatalf("Expected: %+v, received: %+v", expected, b.Transactions[0])
+	}
+}
+
+func TestGetHeader(t *testing.T) {
+	ts := newTestServer(t, `{"jsonrpc":"2.0","id":1,"method":"eth_getBlockByNumber","params":["0x0",false]}`, genesisResponse)
+	defer ts.Close()
+
+	c, err := Dial(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := c.GetHeader(big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(h, genesisHeader) {
+		t.Fatalf("Expected: %+v, received: %+v", genesisHeader, h)
+	}
+}
+
+func TestGetBlockNotFound(t *testing.T) {
+	ts := newTestServer(t, `{"jsonrpc":"2.0","id":1,"method":"eth_getBlockByNumber","params":["0xffffff",false]}`,
+		`{"jsonrpc":"2.0","id":1,"result":null}`)
+	defer ts.Close()
+
+	c, err := Dial(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.GetBlockByNumber(big.NewInt(0xffffff), false); err != ErrNotFound {
+		t.Fatalf("Expected: %v, received: %v", ErrNotFound, err)
+	}
+}
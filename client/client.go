@@ -14,31 +14,101 @@ import (
 
 type Client struct {
 	*rpc.Client
+
+	url string
 }
 
+// Dial connects to a node over HTTP, or over WebSocket if url is a ws:// or
+// wss:// URL.  Subscriptions require a WebSocket connection.
 func Dial(url string) (Client, error) {
 	c, err := rpc.Dial(url)
 	if err != nil {
 		return Client{}, err
 	}
-	return Client{c}, nil
+	return Client{Client: c, url: url}, nil
 }
 
+// CallContract calls a function of a contract with eth_call at the latest
+// block, decoding the result into output.  If the call reverts, the error is
+// a *contract.RevertError.
 func (c Client) CallContract(cont contract.Contract, funcname string, inputs []interface{}, output interface{}) error {
-	cm := struct {
-		Data util.Data
-		To   string
-	}{
-		Data: util.Data(cont.Abi[funcname].Id()),
-		To:   cont.Address,
+	return c.CallContractOpts(contract.CallOpts{}, cont, funcname, inputs, output)
+}
+
+// CallContractOpts is like CallContract, with the sender, value, gas and
+// block of the call given by opts.
+func (c Client) CallContractOpts(opts contract.CallOpts, cont contract.Contract, funcname string, inputs []interface{},
+	output interface{}) error {
+	f, err := cont.Abi.Function(funcname, inputs...)
+	if err != nil {
+		return err
+	}
+	if err := opts.CheckCall(f); err != nil {
+		return err
 	}
 
-	var result hexutil.Bytes
-	if err := c.Call(&result, "eth_call", cm, "latest"); err != nil {
+	data, err := f.Encode(inputs...)
+	if err != nil {
 		return err
 	}
 
-	return cont.UnmarshalResponse(funcname, result, output)
+	result, err := c.call(opts, cont.Address, data)
+	if err != nil {
+		return callError(err, cont.Abi)
+	}
+
+	return f.Decode(result, output)
+}
+
+// CallData calls a contract with eth_call and raw call data, returning the
+// raw result.  If the call reverts, the error is a *contract.RevertError,
+// with custom errors left undecoded.
+func (c Client) CallData(opts contract.CallOpts, to string, data []byte) ([]byte, error) {
+	// Any value may be sent without a function to check it against.
+	if err := opts.CheckCall(contract.Function{Payable: true}); err != nil {
+		return nil, err
+	}
+
+	result, err := c.call(opts, to, data)
+	if err != nil {
+		return nil, callError(err, contract.ABI{})
+	}
+	return result, nil
+}
+
+func (c Client) call(opts contract.CallOpts, to string, data []byte) ([]byte, error) {
+	var result hexutil.Bytes
+	err := c.Call(&result, "eth_call", callArgs(opts, to, data)...)
+	return result, err
+}
+
+// callArgs returns the params of eth_call.
+func callArgs(opts contract.CallOpts, to string, data []byte) []interface{} {
+	cm := struct {
+		From                 accnt.Address `json:"from,omitempty"`
+		Data                 util.Data     `json:"data"`
+		To                   string        `json:"to,omitempty"`
+		Value                *hexutil.Big  `json:"value,omitempty"`
+		Gas                  *hexutil.Big  `json:"gas,omitempty"`
+		GasPrice             *hexutil.Big  `json:"gasPrice,omitempty"`
+		MaxFeePerGas         *hexutil.Big  `json:"maxFeePerGas,omitempty"`
+		MaxPriorityFeePerGas *hexutil.Big  `json:"maxPriorityFeePerGas,omitempty"`
+	}{
+		From:                 opts.From,
+		Data:                 util.Data(data),
+		To:                   to,
+		Value:                (*hexutil.Big)(opts.Value),
+		Gas:                  (*hexutil.Big)(opts.GasLimit),
+		GasPrice:             (*hexutil.Big)(opts.GasPrice),
+		MaxFeePerGas:         (*hexutil.Big)(opts.MaxFeePerGas),
+		MaxPriorityFeePerGas: (*hexutil.Big)(opts.MaxPriorityFeePerGas),
+	}
+
+	block := opts.BlockTag
+	if block == "" {
+		block = blockTag(opts.BlockNumber)
+	}
+	return []interface{}{cm, block}
 }
 
 // Always uses "latest" block.
@@ -54,83 +124,64 @@ func (c Client) GetTransactionCount(addr accnt.Address) (uint64, error) {
 	return uint64(result), err
 }
 
+// GetTransactionReceipt returns the receipt of a transaction, or ErrNotFound
+// if the transaction isn't mined.
 func (c Client) GetTransactionReceipt(hash string) (txn.TransactionReceipt, error) {
-	var rawTxnReceipt = struct {
+	var rawTxnReceipt *struct {
 		BlockHash         string   `json:"blockHash"`
 		BlockNumber       string   `json:"blockNumber"`
 		ContractAddress   string   `json:"contractAddress"`
 		CumulativeGasUsed string   `json:"cumulativeGasUsed"`
 		From              string   `json:"from"`
 		GasUsed           string   `json:"gasUsed"`
-		Logs              []string `json:"logs"`
+		Logs              []rawLog `json:"logs"`
 		LogsBloom         string   `json:"logsBloom"`
 		Root              string   `json:"root"`
+		Status            string   `json:"status"`
 		To                string   `json:"to"`
 		TransactionHash   string   `json:"transactionHash"`
 		TransactionIndex  string   `json:"transactionIndex"`
-	}{}
+		Type              string   `json:"type"`
+	}
 	err := c.Call(&rawTxnReceipt, "eth_getTransactionReceipt", hash)
 	if err != nil {
 		return txn.TransactionReceipt{}, err
 	}
+	if rawTxnReceipt == nil {
+		return txn.TransactionReceipt{}, ErrNotFound
+	}
+
+	logs := make([]txn.Log, len(rawTxnReceipt.Logs))
+	for i, l := range rawTxnReceipt.Logs {
+		logs[i] = l.log()
+	}
 
 	return txn.TransactionReceipt{
 		BlockHash:         rawTxnReceipt.BlockHash,
-		BlockNumber:       util.HexToUint64(rawTxnReceipt.BlockNumber),
+		BlockNumber:       hexToUint64(rawTxnReceipt.BlockNumber),
 		ContractAddress:   rawTxnReceipt.ContractAddress,
-		CumulativeGasUsed: util.HexToBigInt(rawTxnReceipt.CumulativeGasUsed),
+		CumulativeGasUsed: hexToBigInt(rawTxnReceipt.CumulativeGasUsed),
 		From:              rawTxnReceipt.From,
-		GasUsed:           util.HexToBigInt(rawTxnReceipt.GasUsed),
-		Logs:              rawTxnReceipt.Logs,
+		GasUsed:           hexToBigInt(rawTxnReceipt.GasUsed),
+		Logs:              logs,
 		LogsBloom:         rawTxnReceipt.LogsBloom,
 		Root:              rawTxnReceipt.Root,
+		Status:            hexToUint64(rawTxnReceipt.Status),
 		To:                rawTxnReceipt.To,
 		TransactionHash:   rawTxnReceipt.TransactionHash,
-		TransactionIndex:  util.HexToUint64(rawTxnReceipt.TransactionIndex),
+		TransactionIndex:  hexToUint64(rawTxnReceipt.TransactionIndex),
+		Type:              hexToUint64(rawTxnReceipt.Type),
 	}, nil
 }
 
 func (c Client) GetTransaction(hash string) (txn.BlockTransaction, error) {
-	var rawBlockTxn = struct {
-		BlockHash        string `json:"blockHash"`
-		BlockNumber      string `json:"blockNumber"`
-		From             string `json:"from"`
-		Gas              string `json:"gas"`
-		GasPrice         string `json:"gasPrice"`
-		Hash             string `json:"hash"`
-		Input            string `json:"input"`
-		Nonce            string `json:"nonce"`
-		To               string `json:"to"`
-		TransactionIndex string `json:"transactionIndex"`
-		Value            string `json:"value"`
-		V                string `json:"v"`
-		R                string `json:"r"`
-		S                string `json:"s"`
-	}{}
-	err := c.Call(&rawBlockTxn, "eth_getTransactionByHash", hash)
+	var raw rawBlockTxn
+	err := c.Call(&raw, "eth_getTransactionByHash", hash)
 	if err != nil {
 		return txn.BlockTransaction{}, err
 	}
 
-	return txn.BlockTransaction{
-		BlockHash:   rawBlockTxn.BlockHash,
-		BlockNumber: util.HexToUint64(rawBlockTxn.BlockNumber),
-		From:        rawBlockTxn.From,
-		Gas:         util.HexToBigInt(rawBlockTxn.Gas),
-		GasPrice:    util.HexToBigInt(rawBlockTxn.GasPrice),
-		Hash:        rawBlockTxn.Hash,
-		Input: func(h string) []byte {
-			b, _ := hex.DecodeString(h[2:])
-			return b
-		}(rawBlockTxn.Input),
-		Nonce:            util.HexToUint64(rawBlockTxn.Nonce),
-		To:               rawBlockTxn.To,
-		TransactionIndex: util.HexToUint64(rawBlockTxn.TransactionIndex),
-		Value:            util.HexToBigInt(rawBlockTxn.Value),
-		V:                int(util.HexToUint64(rawBlockTxn.V)),
-		R:                util.HexToBigInt(rawBlockTxn.R),
-		S:                util.HexToBigInt(rawBlockTxn.S),
-	}, nil
+	return raw.blockTransaction(), nil
 }
 
 // Input a signed transaction, return transaction hash.
@@ -139,3 +190,155 @@ func (c Client) SendTransaction(t txn.Transaction) (string, error) {
 	err := c.Call(&result, "eth_sendRawTransaction", "0x"+hex.EncodeToString(t.Encode()))
 	return result, err
 }
+
+// GasPrice returns the node's suggested gas price.
+func (c Client) GasPrice() (*big.Int, error) {
+	var result hexutil.Big
+	err := c.Call(&result, "eth_gasPrice")
+	return (*big.Int)(&result), err
+}
+
+// EstimateGas returns the gas which sending t from an account would use.  t
+// need not be signed, and its gas limit is ignored.
+func (c Client) EstimateGas(from accnt.Address, t txn.Transaction) (uint64, error) {
+	arg := struct {
+		From     accnt.Address `json:"from"`
+		To       string        `json:"to,omitempty"`
+		GasPrice *hexutil.Big  `json:"gasPrice,omitempty"`
+		Value    *hexutil.Big  `json:"value,omitempty"`
+		Data     util.Data     `json:"data,omitempty"`
+	}{
+		From:     from,
+		To:       t.To,
+		GasPrice: (*hexutil.Big)(t.GasPrice),
+		Value:    (*hexutil.Big)(t.Value),
+		Data:     util.Data(t.Data),
+	}
+
+	var result hexutil.Uint64
+	err := c.Call(&result, "eth_estimateGas", arg)
+	return uint64(result), err
+}
+
+// GetCode returns the code of the contract at addr, which is empty if there
+// is none.  Always uses "latest" block.
+func (c Client) GetCode(addr string) ([]byte, error) {
+	var result hexutil.Bytes
+	err := c.Call(&result, "eth_getCode", addr, "latest")
+	return result, err
+}
+
+type rawBlockTxn struct {
+	BlockHash        string `json:"blockHash"`
+	BlockNumber      string `json:"blockNumber"`
+	From             string `json:"from"`
+	Gas              string `json:"gas"`
+	GasPrice         string `json:"gasPrice"`
+	Hash             string `json:"hash"`
+	Input            string `json:"input"`
+	Nonce            string `json:"nonce"`
+	To               string `json:"to"`
+	TransactionIndex string `json:"transactionIndex"`
+	Value            string `json:"value"`
+	V                string `json:"v"`
+	R                string `json:"r"`
+	S                string `json:"s"`
+
+	Type       string `json:"type"`
+	ChainID    string `json:"chainId"`
+	AccessList []struct {
+		Address     string   `json:"address"`
+		StorageKeys []string `json:"storageKeys"`
+	} `json:"accessList"`
+	MaxPriorityFeePerGas string   `json:"maxPriorityFeePerGas"`
+	MaxFeePerGas         string   `json:"maxFeePerGas"`
+	MaxFeePerBlobGas     string   `json:"maxFeePerBlobGas"`
+	BlobVersionedHashes  []string `json:"blobVersionedHashes"`
+}
+
+func (r rawBlockTxn) blockTransaction() txn.BlockTransaction {
+	bt := txn.BlockTransaction{
+		BlockHash:        r.BlockHash,
+		BlockNumber:      hexToUint64(r.BlockNumber),
+		From:             r.From,
+		Gas:              util.HexToBigInt(r.Gas),
+		GasPrice:         util.HexToBigInt(r.GasPrice),
+		Hash:             r.Hash,
+		Input:            hexToBytes(r.Input),
+		Nonce:            util.HexToUint64(r.Nonce),
+		To:               r.To,
+		TransactionIndex: hexToUint64(r.TransactionIndex),
+		Value:            util.HexToBigInt(r.Value),
+		V:                int(util.HexToUint64(r.V)),
+		R:                util.HexToBigInt(r.R),
+		S:                util.HexToBigInt(r.S),
+
+		Type:                 hexToUint64(r.Type),
+		ChainID:              hexToBigInt(r.ChainID),
+		MaxPriorityFeePerGas: hexToBigInt(r.MaxPriorityFeePerGas),
+		MaxFeePerGas:         hexToBigInt(r.MaxFeePerGas),
+		MaxFeePerBlobGas:     hexToBigInt(r.MaxFeePerBlobGas),
+		BlobVersionedHashes:  r.BlobVersionedHashes,
+	}
+
+	for _, t := range r.AccessList {
+		bt.AccessList = append(bt.AccessList, txn.AccessTuple{
+			Address:     t.Address,
+			StorageKeys: t.StorageKeys,
+		})
+	}
+
+	return bt
+}
+
+type rawLog struct {
+	Address          string   `json:"address"`
+	Topics           []string `json:"topics"`
+	Data             string   `json:"data"`
+	BlockNumber      string   `json:"blockNumber"`
+	BlockHash        string   `json:"blockHash"`
+	TransactionHash  string   `json:"transactionHash"`
+	TransactionIndex string   `json:"transactionIndex"`
+	LogIndex         string   `json:"logIndex"`
+	Removed          bool     `json:"removed"`
+}
+
+func (r rawLog) log() txn.Log {
+	return txn.Log{
+		Address:          r.Address,
+		Topics:           r.Topics,
+		Data:             hexToBytes(r.Data),
+		BlockNumber:      hexToUint64(r.BlockNumber),
+		BlockHash:        r.BlockHash,
+		TransactionHash:  r.TransactionHash,
+		TransactionIndex: hexToUint64(r.TransactionIndex),
+		LogIndex:         hexToUint64(r.LogIndex),
+		Removed:          r.Removed,
+	}
+}
+
+// hexToUint64 is like util.HexToUint64, but returns 0 for fields which the
+// node omitted.
+func hexToUint64(h string) uint64 {
+	if h == "" {
+		return 0
+	}
+	return util.HexToUint64(h)
+}
+
+// hexToBigInt is like util.HexToBigInt, but returns nil for fields which the
+// node omitted.
+func hexToBigInt(h string) *big.Int {
+	if h == "" {
+		return nil
+	}
+	return util.HexToBigInt(h)
+}
+
+func hexToBytes(h string) []byte {
+	if len(h) < 2 {
+		return []byte{}
+	}
+	b, _ := hex.DecodeString(h[2:])
+	return b
+}
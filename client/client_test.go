@@ -1,15 +1,19 @@
 package client
 
 import (
+	"encoding/json"
+	"ethereum/accnt"
 	"ethereum/contract"
 	"ethereum/txn"
 	"ethereum/util"
+	"fmt"
 	"io/ioutil"
 	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestCallContract(t *testing.T) {
@@ -22,21 +26,23 @@ func TestCallContract(t *testing.T) {
 	}{
 		{
 			cont: contract.Contract{
-				Abi: map[string]contract.Function{
-					"displayMessage": contract.Function{
-						Type: "function",
-						Name: "displayMessage",
-						Outputs: []contract.Param{
-							{Name: "", Type: "string"},
+				Abi: contract.ABI{
+					Functions: map[string]contract.Function{
+						"displayMessage()": contract.Function{
+							Type: "function",
+							Name: "displayMessage",
+							Outputs: []contract.Param{
+								{Name: "", Type: "string"},
+							},
+							Constant: true,
+							Payable:  false,
 						},
-						Constant: true,
-						Payable:  false,
 					},
 				},
 				Address: "0xa10a3b175f0f2641cf41912b887f77d8ef34fae8",
 			},
 			funcName: "displayMessage",
-			rpcRequest: `{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{"Data":"0x2d59dc12","To":` +
+			rpcRequest: `{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{"data":"0x2d59dc12","to":` +
 				`"0xa10a3b175f0f2641cf41912b887f77d8ef34fae8"},"latest"]}`,
 			rpcResponse: `{"jsonrpc":"2.0","id":1,"result":"0x00000000000000000000000000000000000000000000000000` +
 				`00000000000020000000000000000000000000000000000000000000000000000000000000002e48656c6c6f2066726f6d2` +
@@ -109,7 +115,7 @@ func TestGetTransactionReceipt(t *testing.T) {
 				CumulativeGasUsed: big.NewInt(151579),
 				From:              "0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a",
 				GasUsed:           big.NewInt(151579),
-				Logs:              []string{},
+				Logs:              []txn.Log{},
 				LogsBloom: "0x00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
 					"000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
 					"000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000" +
@@ -122,6 +128,53 @@ func TestGetTransactionReceipt(t *testing.T) {
 				TransactionIndex: 0,
 			},
 		},
+		{
+			hash: "0x9f5c8a1fd0d8d6d8aa2b6f2c4e6a8c0e2f4a6c8e0a2c4e6f8a0c2e4a6c8e0f2a",
+			rpcRequest: `{"jsonrpc":"2.0","id":1,"method":"eth_getTransactionReceipt","params":` +
+				`["0x9f5c8a1fd0d8d6d8aa2b6f2c4e6a8c0e2f4a6c8e0a2c4e6f8a0c2e4a6c8e0f2a"]}`,
+			rpcResponse: `{"jsonrpc":"2.0","id":1,"result":{"blockHash":` +
+				`"0x32dc6ca00fd4b11831bda72c37e91109961604fa7f6d85c85781f22b2d7b49db","blockNumber":"0x4",` +
+				`"contractAddress":null,"cumulativeGasUsed":"0xc738","from":"0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a",` +
+				`"gasUsed":"0xc738","logs":[{"address":"0x73b647cba2fe75ba05b8e12ef8f8d6327d6367bf","topics":` +
+				`["0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",` +
+				`"0x00000000000000000000000019e7e376e7c213b7e7e7e46cc70a5dd086daff2a",` +
+				`"0x0000000000000000000000001563915e194d8cfba1943570603f7606a3115508"],"data":` +
+				`"0x000000000000000000000000000000000000000000000000000000000000000a","blockNumber":"0x4",` +
+				`"transactionHash":"0x9f5c8a1fd0d8d6d8aa2b6f2c4e6a8c0e2f4a6c8e0a2c4e6f8a0c2e4a6c8e0f2a","transactionIndex":"0x0",` +
+				`"blockHash":"0x32dc6ca00fd4b11831bda72c37e91109961604fa7f6d85c85781f22b2d7b49db","logIndex":"0x0","removed":false}],` +
+				`"logsBloom":"0x00","status":"0x1","to":"0x73b647cba2fe75ba05b8e12ef8f8d6327d6367bf","transactionHash":` +
+				`"0x9f5c8a1fd0d8d6d8aa2b6f2c4e6a8c0e2f4a6c8e0a2c4e6f8a0c2e4a6c8e0f2a","transactionIndex":"0x0","type":"0x2"}}`,
+			expected: txn.TransactionReceipt{
+				BlockHash:         "0x32dc6ca00fd4b11831bda72c37e91109961604fa7f6d85c85781f22b2d7b49db",
+				BlockNumber:       4,
+				CumulativeGasUsed: big.NewInt(51000),
+				From:              "0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a",
+				GasUsed:           big.NewInt(51000),
+				Logs: []txn.Log{
+					{
+						Address: "0x73b647cba2fe75ba05b8e12ef8f8d6327d6367bf",
+						Topics: []string{
+							"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+							"0x00000000000000000000000019e7e376e7c213b7e7e7e46cc70a5dd086daff2a",
+							"0x0000000000000000000000001563915e194d8cfba1943570603f7606a3115508",
+						},
+						Data: []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+							0, 10},
+						BlockNumber:      4,
+						BlockHash:        "0x32dc6ca00fd4b11831bda72c37e91109961604fa7f6d85c85781f22b2d7b49db",
+						TransactionHash:  "0x9f5c8a1fd0d8d6d8aa2b6f2c4e6a8c0e2f4a6c8e0a2c4e6f8a0c2e4a6c8e0f2a",
+						TransactionIndex: 0,
+						LogIndex:         0,
+					},
+				},
+				LogsBloom:        "0x00",
+				Status:           1,
+				To:               "0x73b647cba2fe75ba05b8e12ef8f8d6327d6367bf",
+				TransactionHash:  "0x9f5c8a1fd0d8d6d8aa2b6f2c4e6a8c0e2f4a6c8e0a2c4e6f8a0c2e4a6c8e0f2a",
+				TransactionIndex: 0,
+				Type:             2,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -157,6 +210,87 @@ func TestGetTransactionReceipt(t *testing.T) {
 	}
 }
 
+func TestGetTransactionReceiptNotFound(t *testing.T) {
+	// A pending transaction has no receipt.
+	ts := newTestServer(t, `{"jsonrpc":"2.0","id":1,"method":"eth_getTransactionReceipt","params":`+
+		`["0x9f5c8a1fd0d8d6d8aa2b6f2c4e6a8c0e2f4a6c8e0a2c4e6f8a0c2e4a6c8e0f2a"]}`,
+		`{"jsonrpc":"2.0","id":1,"result":null}`)
+	defer ts.Close()
+
+	c, err := Dial(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.GetTransactionReceipt("0x9f5c8a1fd0d8d6d8aa2b6f2c4e6a8c0e2f4a6c8e0a2c4e6f8a0c2e4a6c8e0f2a")
+	if err != ErrNotFound {
+		t.Fatalf("Expected: %v, received: %v", ErrNotFound, err)
+	}
+}
+
+func TestDeployAndWaitPending(t *testing.T) {
+	// The node answers null for the receipt until the transaction is mined,
+	// after two polls.
+	polls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     int    `json:"id"`
+			Method string `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+
+		result := ""
+		switch req.Method {
+		case "eth_getTransactionCount":
+			result = `"0x7"`
+		case "eth_sendRawTransaction":
+			result = `"0xb0e27987021a059af5f01f17330d2a3c886ddb2e16b62db421ca937c061ebd40"`
+		case "eth_getTransactionReceipt":
+			result = "null"
+			if polls++; polls > 2 {
+				result = `{"blockHash":"0x8e38b4dbf6b11fcc3b9dee84fb7986e29ca0a02cecd8977c161ff7333329681e",` +
+					`"blockNumber":"0x12ca","contractAddress":"0x1563915e194d8cfba1943570603f7606a3115508",` +
+					`"cumulativeGasUsed":"0x15f90","gasUsed":"0x15f90","logs":[],"status":"0x1",` +
+					`"transactionHash":"0xb0e27987021a059af5f01f17330d2a3c886ddb2e16b62db421ca937c061ebd40"}`
+			}
+		case "eth_getCode":
+			result = `"0x6080"`
+		default:
+			t.Fatalf("Unexpected request: %s", req.Method)
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, result)
+	}))
+	defer ts.Close()
+
+	c, err := Dial(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	priv, err := accnt.NewAccount("cb4aab9577130f5c4622f355e5c6c3cad2661518ac968c34e4f14a9fde071bfd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := contract.DeployOpts{
+		GasPrice:     big.NewInt(2e9),
+		GasLimit:     big.NewInt(90000),
+		PollInterval: time.Millisecond,
+	}
+	deployed, receipt, err := contract.DeployAndWait(c, txn.KeySigner{Private: priv},
+		contract.Contract{Bin: []byte{0x60, 0x80}}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if polls != 3 || receipt.Status != 1 {
+		t.Errorf("Expected: 3 polls and status 1, received: %d polls and status %d", polls, receipt.Status)
+	}
+	if deployed.Address != "0x1563915e194d8cfba1943570603f7606a3115508" {
+		t.Errorf("Expected: %s, received: %s", "0x1563915e194d8cfba1943570603f7606a3115508", deployed.Address)
+	}
+}
+
 func TestGetTransaction(t *testing.T) {
 	var tests = []struct {
 		hash        string
@@ -329,7 +463,11 @@ func TestGetTransactionCount(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		count, err := c.GetTransactionCount(test.address)
+		addr, err := accnt.NewAddress(test.address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		count, err := c.GetTransactionCount(addr)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -388,3 +526,96 @@ func TestGetBalance(t *testing.T) {
 		}
 	}
 }
+
+func TestGasAndCode(t *testing.T) {
+	ts := newSequenceTestServer(t,
+		[2]string{`{"jsonrpc":"2.0","id":1,"method":"eth_gasPrice"}`,
+			`{"jsonrpc":"2.0","id":1,"result":"0x4a817c800"}`},
+		[2]string{`{"jsonrpc":"2.0","id":2,"method":"eth_estimateGas","params":[{"from":` +
+			`"0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a","value":"0x64","data":"0x6080"}]}`,
+			`{"jsonrpc":"2.0","id":2,"result":"0x15f90"}`},
+		[2]string{`{"jsonrpc":"2.0","id":3,"method":"eth_getCode","params":["0x1563915e194d8cfba1943570603f7606a3115508",` +
+			`"latest"]}`,
+			`{"jsonrpc":"2.0","id":3,"result":"0x6080"}`},
+	)
+	defer ts.Close()
+
+	c, err := Dial(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var _ contract.Backend = c
+
+	price, err := c.GasPrice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if price.Int64() != 2e10 {
+		t.Fatalf("Expected: 20000000000, received: %v", price)
+	}
+
+	from, _ := accnt.NewAddress("0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a")
+	gas, err := c.EstimateGas(from, txn.Transaction{Value: big.NewInt(100), Data: []byte{0x60, 0x80}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gas != 90000 {
+		t.Fatalf("Expected: 90000, received: %d", gas)
+	}
+
+	code, err := c.GetCode("0x1563915e194d8cfba1943570603f7606a3115508")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(code, []byte{0x60, 0x80}) {
+		t.Fatalf("Expected: 6080, received: %x", code)
+	}
+}
+
+func TestCallContractOpts(t *testing.T) {
+	cont, err := contract.New(`[{"inputs":[],"name":"deposit","outputs":[{"name":"","type":"uint256"}],`+
+		`"stateMutability":"payable","type":"function"}]`, "0xa10a3b175f0f2641cf41912b887f77d8ef34fae8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := newSequenceTestServer(t,
+		[2]string{`{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{"from":"0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a",` +
+			`"data":"0xd0e30db0","to":"0xa10a3b175f0f2641cf41912b887f77d8ef34fae8","value":"0x3e8","gas":"0xc350"},"0x10"]}`,
+			`{"jsonrpc":"2.0","id":1,"result":"0x00000000000000000000000000000000000000000000000000000000000003e8"}`},
+		[2]string{`{"jsonrpc":"2.0","id":2,"method":"eth_call","params":[{"data":"0xd0e30db0","to":` +
+			`"0xa10a3b175f0f2641cf41912b887f77d8ef34fae8"},"pending"]}`,
+			`{"jsonrpc":"2.0","id":2,"result":"0x0000000000000000000000000000000000000000000000000000000000000000"}`},
+	)
+	defer ts.Close()
+
+	c, err := Dial(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from, _ := accnt.NewAddress("0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a")
+	opts := contract.CallOpts{
+		From:        from,
+		Value:       big.NewInt(1000),
+		GasLimit:    big.NewInt(50000),
+		BlockNumber: big.NewInt(16),
+	}
+	var out *big.Int
+	if err := c.CallContractOpts(opts, cont, "deposit", nil, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Int64() != 1000 {
+		t.Fatalf("Expected: 1000, received: %v", out)
+	}
+
+	if err := c.CallContractOpts(contract.CallOpts{BlockTag: "pending"}, cont, "deposit", nil, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid opts are rejected without a request.
+	opts.BlockTag = "latest"
+	if err := c.CallContractOpts(opts, cont, "deposit", nil, &out); err == nil {
+		t.Fatal("Expected error for both block number and tag")
+	}
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"errors"
+	"ethereum/txn"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// FilterQuery selects logs.  Logs match if they were emitted by one of
+// Addresses, or by any contract if Addresses is empty, and match Topics.
+type FilterQuery struct {
+	// BlockHash restricts the query to a single block.  If it is set,
+	// FromBlock and ToBlock must be nil.
+	BlockHash string
+
+	// FromBlock defaults to the genesis block, and ToBlock to the latest
+	// block.
+	FromBlock *big.Int
+	ToBlock   *big.Int
+
+	Addresses []string
+
+	// Topics[i] is the set of values of which topic i must match one.  An
+	// empty set matches any value, e.g. {{transfer}, nil, {to}} matches
+	// transfers to to from any address.  contract.Event.Topics builds topics
+	// for a contract event.
+	Topics [][]string
+}
+
+type filterArg struct {
+	BlockHash string     `json:"blockHash,omitempty"`
+	FromBlock string     `json:"fromBlock,omitempty"`
+	ToBlock   string     `json:"toBlock,omitempty"`
+	Address   []string   `json:"address,omitempty"`
+	Topics    [][]string `json:"topics,omitempty"`
+}
+
+// Error messages with which nodes reject queries which match too many logs
+// or span too many blocks.
+var rangeTooLargeErrors = []string{
+	"query returned more than",
+	"response size exceeded",
+	"response size should not",
+	"block range",
+	"range too large",
+	"too many blocks",
+	"limit exceeded",
+	"exceeds limit",
+}
+
+// FilterLogs returns the logs matching q.  If the node rejects the query
+// because its range is too large, the range is split and the halves queried
+// separately.
+func (c Client) FilterLogs(q FilterQuery) ([]txn.Log, error) {
+	if q.BlockHash != "" {
+		if q.FromBlock != nil || q.ToBlock != nil {
+			return nil, errors.New("filter query cannot have both a block hash and a block range")
+		}
+		return c.getLogs(filterArg{BlockHash: q.BlockHash, Address: q.Addresses, Topics: q.Topics})
+	}
+
+	from := q.FromBlock
+	if from == nil {
+		from = big.NewInt(0)
+	}
+
+	logs, err := c.getLogs(q.filterArg(from, q.ToBlock))
+	if err == nil || !isRangeTooLarge(err) {
+		return logs, err
+	}
+
+	// Splitting needs a concrete end of the range.
+	to := q.ToBlock
+	if to == nil {
+		latest, err := c.BlockNumber()
+		if err != nil {
+			return nil, err
+		}
+		to = new(big.Int).SetUint64(latest)
+	}
+	return c.filterRange(q, from, to, err)
+}
+
+// filterRange queries logs in the halves of [from, to], after the node
+// rejected the whole range with err.
+func (c Client) filterRange(q FilterQuery, from, to *big.Int, err error) ([]txn.Log, error) {
+	if from.Cmp(to) >= 0 {
+		// A single block can't be split any further.
+		return nil, err
+	}
+
+	mid := new(big.Int).Add(from, to)
+	mid.Rsh(mid, 1)
+
+	var logs []txn.Log
+	for _, r := range [][2]*big.Int{{from, mid}, {new(big.Int).Add(mid, big.NewInt(1)), to}} {
+		l, err := c.getLogs(q.filterArg(r[0], r[1]))
+		if err != nil && isRangeTooLarge(err) {
+			l, err = c.filterRange(q, r[0], r[1], err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, l...)
+	}
+	return logs, nil
+}
+
+func (q FilterQuery) filterArg(from, to *big.Int) filterArg {
+	return filterArg{
+		FromBlock: hexutil.EncodeBig(from),
+		ToBlock:   blockTag(to),
+		Address:   q.Addresses,
+		Topics:    q.Topics,
+	}
+}
+
+func (c Client) getLogs(arg filterArg) ([]txn.Log, error) {
+	var raw []rawLog
+	if err := c.Call(&raw, "eth_getLogs", arg); err != nil {
+		return nil, err
+	}
+
+	logs := make([]txn.Log, len(raw))
+	for i, l := range raw {
+		logs[i] = l.log()
+	}
+	return logs, nil
+}
+
+// isRangeTooLarge reports whether err is a node rejecting a log query as too
+// large.
+func isRangeTooLarge(err error) bool {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == -32005 {
+		// Limit exceeded.
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, s := range rangeTooLargeErrors {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
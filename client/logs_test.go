@@ -0,0 +1,182 @@
+package client
+
+import (
+	"encoding/json"
+	"ethereum/txn"
+	"ethereum/util"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+const transferLog = `{"address":"0xa10a3b175f0f2641cf41912b887f77d8ef34fae8","topics":["0xddf252ad1be2c89b69c2b068fc378daa9` +
+	`52ba7f163c4a11628f55a4df523b3ef","0x00000000000000000000000019e7e376e7c213b7e7e7e46cc70a5dd086daff2a","0x0000` +
+	`0000000000000000000073b647cba2fe75ba05b8e12ef8f8d6327d6367bf"],"data":"0x00000000000000000000000000000000000000` +
+	`000000000000000000000003e8","blockNumber":"0x12ca","blockHash":"0x2c4b4c9b5c0ab0ef6ec8ba1e7e14f8b3c1e6fae62ef9e` +
+	`1dfe6d6f3b0b5f4e0d6","transactionHash":"0xd866f3672a3cef05f66dec56d30562bbffcc42aa11b54450e6973d52c89d1719","tr` +
+	`ansactionIndex":"0x1","logIndex":"0x3","removed":false}`
+
+func TestFilterLogs(t *testing.T) {
+	expected := []txn.Log{{
+		Address: "0xa10a3b175f0f2641cf41912b887f77d8ef34fae8",
+		Topics: []string{
+			"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+			"0x00000000000000000000000019e7e376e7c213b7e7e7e46cc70a5dd086daff2a",
+			"0x00000000000000000000000073b647cba2fe75ba05b8e12ef8f8d6327d6367bf",
+		},
+		Data:             util.HexToBigInt("0x3e8").FillBytes(make([]byte, 32)),
+		BlockNumber:      4810,
+		BlockHash:        "0x2c4b4c9b5c0ab0ef6ec8ba1e7e14f8b3c1e6fae62ef9e1dfe6d6f3b0b5f4e0d6",
+		TransactionHash:  "0xd866f3672a3cef05f66dec56d30562bbffcc42aa11b54450e6973d52c89d1719",
+		TransactionIndex: 1,
+		LogIndex:         3,
+	}}
+
+	var tests = []struct {
+		query      FilterQuery
+		rpcRequest string
+	}{
+		{
+			query: FilterQuery{
+				FromBlock: big.NewInt(4800),
+				ToBlock:   big.NewInt(4810),
+				Addresses: []string{"0xa10a3b175f0f2641cf41912b887f77d8ef34fae8"},
+				Topics: [][]string{
+					{"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"},
+					nil,
+					{"0x00000000000000000000000073b647cba2fe75ba05b8e12ef8f8d6327d6367bf"},
+				},
+			},
+			rpcRequest: `{"jsonrpc":"2.0","id":1,"method":"eth_getLogs","params":[{"fromBlock":"0x12c0","toBlock":"0x12ca",` +
+				`"address":["0xa10a3b175f0f2641cf41912b887f77d8ef34fae8"],"topics":[["0xddf252ad1be2c89b69c2b068fc378daa952` +
+				`ba7f163c4a11628f55a4df523b3ef"],null,["0x00000000000000000000000073b647cba2fe75ba05b8e12ef8f8d6327d6367bf"]]}]}`,
+		},
+		{
+			query:      FilterQuery{},
+			rpcRequest: `{"jsonrpc":"2.0","id":1,"method":"eth_getLogs","params":[{"fromBlock":"0x0","toBlock":"latest"}]}`,
+		},
+		{
+			query: FilterQuery{BlockHash: "0x2c4b4c9b5c0ab0ef6ec8ba1e7e14f8b3c1e6fae62ef9e1dfe6d6f3b0b5f4e0d6"},
+			rpcRequest: `{"jsonrpc":"2.0","id":1,"method":"eth_getLogs","params":[{"blockHash":"0x2c4b4c9b5c0ab0ef6ec8ba1e7` +
+				`e14f8b3c1e6fae62ef9e1dfe6d6f3b0b5f4e0d6"}]}`,
+		},
+	}
+
+	for _, test := range tests {
+		ts := newTestServer(t, test.rpcRequest, `{"jsonrpc":"2.0","id":1,"result":[`+transferLog+`]}`)
+		defer ts.Close()
+
+		c, err := Dial(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		logs, err := c.FilterLogs(test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(logs, expected) {
+			t.Fatalf("Expected: %+v, received: %+v", expected, logs)
+		}
+	}
+
+	c, err := Dial("http://localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.FilterLogs(FilterQuery{BlockHash: "0x00", FromBlock: big.NewInt(1)}); err == nil {
+		t.Fatal("Expected error for block hash with block range")
+	}
+}
+
+func TestFilterLogsSplitsRange(t *testing.T) {
+	// The node returns one log per block, and rejects ranges of more than 4
+	// blocks.
+	var ranges [][2]uint64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage
+			Method string
+			Params []filterArg
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+
+		var resp string
+		switch req.Method {
+		case "eth_blockNumber":
+			resp = `"result":"0xa"`
+		case "eth_getLogs":
+			if req.Params[0].ToBlock == "latest" {
+				resp = `"error":{"code":-32005,"message":"query returned more than 10000 results"}`
+				break
+			}
+			from, to := util.HexToUint64(req.Params[0].FromBlock), util.HexToUint64(req.Params[0].ToBlock)
+			ranges = append(ranges, [2]uint64{from, to})
+			if to-from >= 4 {
+				resp = `"error":{"code":-32005,"message":"query returned more than 10000 results"}`
+				break
+			}
+			resp = `"result":[`
+			for n := from; n <= to; n++ {
+				if n > from {
+					resp += ","
+				}
+				resp += fmt.Sprintf(`{"blockNumber":"0x%x","topics":[]}`, n)
+			}
+			resp += "]"
+		default:
+			t.Fatalf("Unexpected method %s", req.Method)
+		}
+
+		if _, err := fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,%s}`, req.ID, resp); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	defer ts.Close()
+
+	c, err := Dial(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logs, err := c.FilterLogs(FilterQuery{FromBlock: big.NewInt(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logs) != 10 {
+		t.Fatalf("Expected: 10 logs, received: %d", len(logs))
+	}
+	for i, l := range logs {
+		if l.BlockNumber != uint64(i+1) {
+			t.Fatalf("Expected: %d, received: %d", i+1, l.BlockNumber)
+		}
+	}
+
+	expected := [][2]uint64{{1, 5}, {1, 3}, {4, 5}, {6, 10}, {6, 8}, {9, 10}}
+	if !reflect.DeepEqual(ranges, expected) {
+		t.Fatalf("Expected: %v, received: %v", expected, ranges)
+	}
+}
+
+func TestFilterLogsSingleBlockTooLarge(t *testing.T) {
+	ts := newTestServer(t,
+		`{"jsonrpc":"2.0","id":1,"method":"eth_getLogs","params":[{"fromBlock":"0x5","toBlock":"0x5"}]}`,
+		`{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"query returned more than 10000 results"}}`)
+	defer ts.Close()
+
+	c, err := Dial(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.FilterLogs(FilterQuery{FromBlock: big.NewInt(5), ToBlock: big.NewInt(5)}); err == nil {
+		t.Fatal("Expected error")
+	}
+}
@@ -0,0 +1,215 @@
+package client
+
+import (
+	"errors"
+	"ethereum/contract"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Multicall3Address is the address of the Multicall3 contract, which is
+// deployed at the same address on most chains.
+const Multicall3Address = "0xca11bde05977b3631167028862be2a173976ca11"
+
+const multicall3Abi = `[{"inputs":[{"components":[{"name":"target","type":"address"},{"name":"allowFailure",` +
+	`"type":"bool"},{"name":"callData","type":"bytes"}],"name":"calls","type":"tuple[]"}],"name":"aggregate3",` +
+	`"outputs":[{"components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}],` +
+	`"name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"}]`
+
+// Call is a call of a contract function made by Multicall.Call.
+type Call struct {
+	Contract contract.Contract
+	Func     string
+	Args     []interface{}
+	Output   interface{} // the result is decoded into Output if it isn't nil
+
+	// AllowFailure lets the call revert without failing the others, in which
+	// case Err is set to its *contract.RevertError.
+	AllowFailure bool
+	Err          error
+}
+
+// Multicall batches calls of view functions into a single eth_call to a
+// Multicall3 contract.
+type Multicall struct {
+	client   Client
+	address  string
+	deployed bool // at the latest block
+}
+
+// NewMulticall returns a Multicall using the Multicall3 contract at address,
+// or at Multicall3Address if address is "".  If there is no contract at the
+// address at the block of a call, calls are made in a JSON-RPC batch
+// instead.
+func (c Client) NewMulticall(address string) (*Multicall, error) {
+	if address == "" {
+		address = Multicall3Address
+	}
+	code, err := c.GetCode(address)
+	if err != nil {
+		return nil, err
+	}
+	return &Multicall{client: c, address: address, deployed: len(code) > 0}, nil
+}
+
+// deployedAt reports whether calls with opts can be made with aggregate3:
+// the Multicall3 contract must exist at their block, which is only looked up
+// for blocks before the latest, and opts.From must not be set.
+func (m *Multicall) deployedAt(opts contract.CallOpts) (bool, error) {
+	if !m.deployed || len(opts.From) > 0 {
+		return false, nil
+	}
+
+	block := opts.BlockTag
+	if block == "" {
+		block = blockTag(opts.BlockNumber)
+	}
+	if block == "latest" || block == "pending" {
+		return true, nil
+	}
+
+	var code hexutil.Bytes
+	if err := m.client.Call(&code, "eth_getCode", m.address, block); err != nil {
+		return false, err
+	}
+	return len(code) > 0, nil
+}
+
+// Call makes calls at the block given by opts, decoding the result of each
+// into its Output with its function's outputs.  If a call reverts and
+// doesn't allow failure, its *contract.RevertError is returned, with custom
+// errors decoded with the call's contract ABI.  Value can't be sent.  Calls
+// with opts.From set are made in a JSON-RPC batch, so that they are sent
+// from it rather than from the Multicall3 contract.
+func (m *Multicall) Call(opts contract.CallOpts, calls ...*Call) error {
+	if opts.Value != nil && opts.Value.Sign() != 0 {
+		return errors.New("multicall: value can't be sent")
+	}
+
+	funcs := make([]contract.Function, len(calls))
+	data := make([][]byte, len(calls))
+	for i, call := range calls {
+		f, err := call.Contract.Abi.Function(call.Func, call.Args...)
+		if err != nil {
+			return err
+		}
+		if err := opts.CheckCall(f); err != nil {
+			return err
+		}
+		if data[i], err = f.Encode(call.Args...); err != nil {
+			return err
+		}
+		funcs[i] = f
+	}
+
+	deployed, err := m.deployedAt(opts)
+	if err != nil {
+		return err
+	}
+
+	var results [][]byte
+	if deployed {
+		results, err = m.aggregate(opts, calls, data)
+	} else {
+		results, err = m.batch(opts, calls, data)
+	}
+	if err != nil {
+		return err
+	}
+
+	for i, call := range calls {
+		if call.Err != nil {
+			if !call.AllowFailure {
+				return call.Err
+			}
+			continue
+		}
+		if call.Output != nil {
+			if err := funcs[i].Decode(results[i], call.Output); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// aggregate makes calls with aggregate3.  All calls are allowed to fail, so
+// that the revert data of a failing call is returned, and Call checks
+// AllowFailure itself.
+func (m *Multicall) aggregate(opts contract.CallOpts, calls []*Call, data [][]byte) ([][]byte, error) {
+	abi, err := contract.NewABI(multicall3Abi)
+	if err != nil {
+		return nil, err
+	}
+	f, err := abi.Function("aggregate3")
+	if err != nil {
+		return nil, err
+	}
+
+	type call3 struct {
+		Target       string
+		AllowFailure bool
+		CallData     []byte
+	}
+	in := make([]call3, len(calls))
+	for i, call := range calls {
+		in[i] = call3{Target: call.Contract.Address, AllowFailure: true, CallData: data[i]}
+	}
+	input, err := f.Encode(in)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := m.client.call(opts, m.address, input)
+	if err != nil {
+		return nil, callError(err, abi)
+	}
+
+	var out []struct {
+		Success    bool
+		ReturnData []byte
+	}
+	if err := f.Decode(output, &out); err != nil {
+		return nil, err
+	}
+	if len(out) != len(calls) {
+		return nil, errors.New("multicall: wrong number of results")
+	}
+
+	results := make([][]byte, len(calls))
+	for i, call := range calls {
+		call.Err = nil
+		if !out[i].Success {
+			call.Err = call.Contract.Abi.DecodeRevert(out[i].ReturnData)
+		}
+		results[i] = out[i].ReturnData
+	}
+	return results, nil
+}
+
+// batch makes calls in a JSON-RPC batch of eth_calls.
+func (m *Multicall) batch(opts contract.CallOpts, calls []*Call, data [][]byte) ([][]byte, error) {
+	results := make([]hexutil.Bytes, len(calls))
+	elems := make([]rpc.BatchElem, len(calls))
+	for i, call := range calls {
+		elems[i] = rpc.BatchElem{
+			Method: "eth_call",
+			Args:   callArgs(opts, call.Contract.Address, data[i]),
+			Result: &results[i],
+		}
+	}
+	if err := m.client.BatchCall(elems); err != nil {
+		return nil, err
+	}
+
+	out := make([][]byte, len(calls))
+	for i, call := range calls {
+		call.Err = nil
+		if elems[i].Error != nil {
+			call.Err = callError(elems[i].Error, call.Contract.Abi)
+		}
+		out[i] = results[i]
+	}
+	return out, nil
+}
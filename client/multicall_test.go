@@ -0,0 +1,179 @@
+package client
+
+import (
+	"ethereum/accnt"
+	"ethereum/contract"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+const tokenAbi = `[{"inputs":[{"name":"owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],` +
+	`"stateMutability":"view","type":"function"},{"inputs":[],"name":"totalSupply","outputs":[{"name":"",` +
+	`"type":"uint256"}],"stateMutability":"view","type":"function"}]`
+
+func word(hex string) string {
+	return strings.Repeat("0", 64-len(hex)) + hex
+}
+
+// multicallTestCalls returns calls of a token's balanceOf and totalSupply,
+// the latter allowed to fail.
+func multicallTestCalls(t *testing.T) []*Call {
+	token, err := contract.New(tokenAbi, "0xa10a3b175f0f2641cf41912b887f77d8ef34fae8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var balance, supply *big.Int
+	return []*Call{
+		{
+			Contract: token,
+			Func:     "balanceOf",
+			Args:     []interface{}{"0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a"},
+			Output:   &balance,
+		},
+		{Contract: token, Func: "totalSupply", Output: &supply, AllowFailure: true},
+	}
+}
+
+// revertNope is the revert data of require(false, "nope").
+var revertNope = "08c379a0" + word("20") + word("4") + "6e6f7065" + strings.Repeat("0", 56)
+
+func TestMulticallAggregate(t *testing.T) {
+	ts := newSequenceTestServer(t,
+		[2]string{`{"jsonrpc":"2.0","id":1,"method":"eth_getCode","params":["` + Multicall3Address + `","latest"]}`,
+			`{"jsonrpc":"2.0","id":1,"result":"0x6080"}`},
+		[2]string{`{"jsonrpc":"2.0","id":2,"method":"eth_call","params":[{"data":"0x82ad56cb` + word("20") + word("2") +
+			word("40") + word("100") +
+			word("a10a3b175f0f2641cf41912b887f77d8ef34fae8") + word("1") + word("60") + word("24") +
+			"70a08231" + word("19e7e376e7c213b7e7e7e46cc70a5dd086daff2a") + strings.Repeat("0", 56) +
+			word("a10a3b175f0f2641cf41912b887f77d8ef34fae8") + word("1") + word("60") + word("4") +
+			"18160ddd" + strings.Repeat("0", 56) +
+			`","to":"` + Multicall3Address + `"},"latest"]}`,
+			`{"jsonrpc":"2.0","id":2,"result":"0x` + word("20") + word("2") + word("40") + word("c0") +
+				word("1") + word("40") + word("20") + word("3e8") +
+				word("0") + word("40") + word("64") + revertNope + strings.Repeat("0", 56) + `"}`},
+	)
+	defer ts.Close()
+
+	c, err := Dial(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := c.NewMulticall("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := multicallTestCalls(t)
+	if err := m.Call(contract.CallOpts{}, calls...); err != nil {
+		t.Fatal(err)
+	}
+
+	balance := *calls[0].Output.(**big.Int)
+	if balance == nil || balance.Int64() != 1000 || calls[0].Err != nil {
+		t.Fatalf("Expected: 1000, received: %v, %v", balance, calls[0].Err)
+	}
+	if _, ok := calls[1].Err.(*contract.RevertError); !ok || calls[1].Err.Error() != "execution reverted: nope" {
+		t.Fatalf("Expected: execution reverted: nope, received: %v", calls[1].Err)
+	}
+	if supply := *calls[1].Output.(**big.Int); supply != nil {
+		t.Fatalf("Expected: nil, received: %v", supply)
+	}
+}
+
+func TestMulticallBatch(t *testing.T) {
+	ts := newSequenceTestServer(t,
+		[2]string{`{"jsonrpc":"2.0","id":1,"method":"eth_getCode","params":["` + Multicall3Address + `","latest"]}`,
+			`{"jsonrpc":"2.0","id":1,"result":"0x"}`},
+		[2]string{`[{"jsonrpc":"2.0","id":2,"method":"eth_call","params":[{"data":"0x70a08231` +
+			word("19e7e376e7c213b7e7e7e46cc70a5dd086daff2a") + `","to":"0xa10a3b175f0f2641cf41912b887f77d8ef34fae8"},` +
+			`"0x10"]},{"jsonrpc":"2.0","id":3,"method":"eth_call","params":[{"data":"0x18160ddd","to":` +
+			`"0xa10a3b175f0f2641cf41912b887f77d8ef34fae8"},"0x10"]}]`,
+			`[{"jsonrpc":"2.0","id":2,"result":"0x` + word("3e8") + `"},{"jsonrpc":"2.0","id":3,"result":"0x` +
+				word("f4240") + `"}]`},
+		[2]string{`[{"jsonrpc":"2.0","id":4,"method":"eth_call","params":[{"data":"0x70a08231` +
+			word("19e7e376e7c213b7e7e7e46cc70a5dd086daff2a") + `","to":"0xa10a3b175f0f2641cf41912b887f77d8ef34fae8"},` +
+			`"latest"]},{"jsonrpc":"2.0","id":5,"method":"eth_call","params":[{"data":"0x18160ddd","to":` +
+			`"0xa10a3b175f0f2641cf41912b887f77d8ef34fae8"},"latest"]}]`,
+			`[{"jsonrpc":"2.0","id":4,"error":{"code":3,"message":"execution reverted: nope","data":"0x` + revertNope +
+				`"}},{"jsonrpc":"2.0","id":5,"result":"0x` + word("f4240") + `"}]`},
+	)
+	defer ts.Close()
+
+	c, err := Dial(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := c.NewMulticall("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := multicallTestCalls(t)
+	if err := m.Call(contract.CallOpts{BlockNumber: big.NewInt(16)}, calls...); err != nil {
+		t.Fatal(err)
+	}
+	balance, supply := *calls[0].Output.(**big.Int), *calls[1].Output.(**big.Int)
+	if balance.Int64() != 1000 || supply.Int64() != 1000000 {
+		t.Fatalf("Expected: 1000 1000000, received: %v %v", balance, supply)
+	}
+
+	// The balance call doesn't allow failure.
+	err = m.Call(contract.CallOpts{}, calls...)
+	if _, ok := err.(*contract.RevertError); !ok || err.Error() != "execution reverted: nope" {
+		t.Fatalf("Expected: execution reverted: nope, received: %v", err)
+	}
+}
+
+func TestMulticallFallback(t *testing.T) {
+	balanceOf := `{"data":"0x70a08231` + word("19e7e376e7c213b7e7e7e46cc70a5dd086daff2a") +
+		`","to":"0xa10a3b175f0f2641cf41912b887f77d8ef34fae8"}`
+	totalSupply := `{"data":"0x18160ddd","to":"0xa10a3b175f0f2641cf41912b887f77d8ef34fae8"}`
+	results := func(id1, id2 int) string {
+		return fmt.Sprintf(`[{"jsonrpc":"2.0","id":%d,"result":"0x%s"},{"jsonrpc":"2.0","id":%d,"result":"0x%s"}]`,
+			id1, word("3e8"), id2, word("f4240"))
+	}
+
+	ts := newSequenceTestServer(t,
+		[2]string{`{"jsonrpc":"2.0","id":1,"method":"eth_getCode","params":["` + Multicall3Address + `","latest"]}`,
+			`{"jsonrpc":"2.0","id":1,"result":"0x6080"}`},
+		// Multicall3 is deployed, but not yet at block 16.
+		[2]string{`{"jsonrpc":"2.0","id":2,"method":"eth_getCode","params":["` + Multicall3Address + `","0x10"]}`,
+			`{"jsonrpc":"2.0","id":2,"result":"0x"}`},
+		[2]string{`[{"jsonrpc":"2.0","id":3,"method":"eth_call","params":[` + balanceOf + `,"0x10"]},` +
+			`{"jsonrpc":"2.0","id":4,"method":"eth_call","params":[` + totalSupply + `,"0x10"]}]`,
+			results(3, 4)},
+		// Calls from a sender are batched.
+		[2]string{`[{"jsonrpc":"2.0","id":5,"method":"eth_call","params":[{"from":"0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a",` +
+			balanceOf[1:] + `,"latest"]},{"jsonrpc":"2.0","id":6,"method":"eth_call","params":[{"from":` +
+			`"0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a",` + totalSupply[1:] + `,"latest"]}]`,
+			results(5, 6)},
+	)
+	defer ts.Close()
+
+	c, err := Dial(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := c.NewMulticall("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from, _ := accnt.NewAddress("0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a")
+	for _, opts := range []contract.CallOpts{
+		{BlockNumber: big.NewInt(16)},
+		{From: from},
+	} {
+		calls := multicallTestCalls(t)
+		if err := m.Call(opts, calls...); err != nil {
+			t.Fatal(err)
+		}
+		balance, supply := *calls[0].Output.(**big.Int), *calls[1].Output.(**big.Int)
+		if balance.Int64() != 1000 || supply.Int64() != 1000000 {
+			t.Fatalf("Expected: 1000 1000000, received: %v %v", balance, supply)
+		}
+	}
+}
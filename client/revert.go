@@ -0,0 +1,78 @@
+package client
+
+import (
+	"errors"
+	"ethereum/contract"
+	"ethereum/txn"
+	"ethereum/util"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ErrTransactionFailed is returned by TransactionError for a failed
+// transaction which didn't revert when replayed, e.g. because it ran out of
+// gas.
+var ErrTransactionFailed = errors.New("transaction failed")
+
+// TransactionError returns nil if the transaction of receipt succeeded, as
+// reported by TransactionReceipt.Succeeded.
+// Otherwise it replays the transaction with eth_call on the state before its
+// block to find out why it failed, returning a *contract.RevertError, whose
+// custom errors are decoded with abi, if it reverted.  Transactions earlier
+// in the same block aren't replayed, so the result is not always accurate.
+func (c Client) TransactionError(receipt txn.TransactionReceipt, abi contract.ABI) error {
+	if receipt.Succeeded() {
+		return nil
+	}
+
+	t, err := c.GetTransaction(receipt.TransactionHash)
+	if err != nil {
+		return err
+	}
+
+	msg := struct {
+		From  string       `json:"from"`
+		To    string       `json:"to,omitempty"`
+		Gas   *hexutil.Big `json:"gas,omitempty"`
+		Value *hexutil.Big `json:"value,omitempty"`
+		Data  util.Data    `json:"data"`
+	}{
+		From:  t.From,
+		To:    t.To,
+		Gas:   (*hexutil.Big)(t.Gas),
+		Value: (*hexutil.Big)(t.Value),
+		Data:  util.Data(t.Input),
+	}
+
+	var parent *big.Int
+	if receipt.BlockNumber > 0 {
+		parent = new(big.Int).SetUint64(receipt.BlockNumber - 1)
+	}
+
+	var result hexutil.Bytes
+	if err := c.Call(&result, "eth_call", msg, blockTag(parent)); err != nil {
+		return callError(err, abi)
+	}
+	return ErrTransactionFailed
+}
+
+// callError returns a *contract.RevertError if err is an eth_call reverting,
+// and otherwise err.  Nodes return the revert data as the data of the error.
+func callError(err error, abi contract.ABI) error {
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if s, ok := dataErr.ErrorData().(string); ok {
+			if data, decErr := hexutil.Decode(s); decErr == nil {
+				return abi.DecodeRevert(data)
+			}
+		}
+	}
+
+	if strings.HasPrefix(err.Error(), "execution reverted") {
+		return &contract.RevertError{}
+	}
+	return err
+}
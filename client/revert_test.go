@@ -0,0 +1,146 @@
+package client
+
+import (
+	"ethereum/contract"
+	"ethereum/txn"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newSequenceTestServer is like newTestServer, but expects a sequence of
+// requests, given with their responses.
+func newSequenceTestServer(t *testing.T, exchanges ...[2]string) *httptest.Server {
+	n := 0
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n >= len(exchanges) {
+			t.Fatalf("Unexpected request: %s", data)
+		}
+		if d := string(data); d != exchanges[n][0] {
+			t.Fatalf("Expected: %s, received: %s", exchanges[n][0], d)
+		}
+
+		if _, err := w.Write([]byte(exchanges[n][1])); err != nil {
+			t.Fatal(err)
+		}
+		n++
+	}))
+}
+
+// errorAbi declares the custom error InsufficientBalance(uint256,uint256),
+// with selector 0xcf479181.
+const errorAbi = `[{"inputs":[],"name":"withdraw","outputs":[],"type":"function"},{"inputs":[{"name":"available",` +
+	`"type":"uint256"},{"name":"required","type":"uint256"}],"name":"InsufficientBalance","type":"error"}]`
+
+func TestCallContractRevert(t *testing.T) {
+	var tests = []struct {
+		rpcResponse string
+		expected    string
+	}{
+		{
+			rpcResponse: `{"jsonrpc":"2.0","id":1,"error":{"code":3,"message":"execution reverted: nope","data":"0x08c379a0` +
+				`0000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000` +
+				`00000000000000000000000000046e6f706500000000000000000000000000000000000000000000000000000000"}}`,
+			expected: "execution reverted: nope",
+		},
+		{
+			rpcResponse: `{"jsonrpc":"2.0","id":1,"error":{"code":3,"message":"execution reverted","data":"0xcf479181` +
+				`000000000000000000000000000000000000000000000000000000000000000a000000000000000000000000000000000000` +
+				`0000000000000000000000000014"}}`,
+			expected: "execution reverted: InsufficientBalance(10, 20)",
+		},
+		{
+			rpcResponse: `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"execution reverted"}}`,
+			expected:    "execution reverted",
+		},
+	}
+
+	cont, err := contract.New(errorAbi, "0xa10a3b175f0f2641cf41912b887f77d8ef34fae8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range tests {
+		ts := newTestServer(t, `{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{"data":"0x3ccfd60b","to":`+
+			`"0xa10a3b175f0f2641cf41912b887f77d8ef34fae8"},"latest"]}`, test.rpcResponse)
+		defer ts.Close()
+
+		c, err := Dial(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = c.CallContract(cont, "withdraw", nil, nil)
+		if _, ok := err.(*contract.RevertError); !ok {
+			t.Fatalf("Expected: *contract.RevertError, received: %#v", err)
+		}
+		if err.Error() != test.expected {
+			t.Fatalf("Expected: %s, received: %s", test.expected, err)
+		}
+	}
+}
+
+func TestTransactionError(t *testing.T) {
+	cont, err := contract.New(errorAbi, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Successful transactions need no requests.
+	c, err := Dial("http://localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.TransactionError(txn.TransactionReceipt{Status: 1}, cont.Abi); err != nil {
+		t.Fatal(err)
+	}
+
+	hash := "0xd866f3672a3cef05f66dec56d30562bbffcc42aa11b54450e6973d52c89d1719"
+	txResponse := `{"jsonrpc":"2.0","id":1,"result":{"blockHash":"0x2c4b4c9b5c0ab0ef6ec8ba1e7e14f8b3c1e6fae62ef9e1dfe6d6f3b0` +
+		`b5f4e0d6","blockNumber":"0x12ca","from":"0x9d39856f91822ff0bdc2e234bb0d40124a201677","gas":"0x7530","gasPrice":` +
+		`"0x4a817c800","hash":"` + hash + `","input":"0x3ccfd60b","nonce":"0x1","to":"0xa10a3b175f0f2641cf41912b887f77d8e` +
+		`f34fae8","transactionIndex":"0x0","value":"0x0","v":"0x1c","r":"0x1","s":"0x1"}}`
+	call := `{"jsonrpc":"2.0","id":2,"method":"eth_call","params":[{"from":"0x9d39856f91822ff0bdc2e234bb0d40124a201677",` +
+		`"to":"0xa10a3b175f0f2641cf41912b887f77d8ef34fae8","gas":"0x7530","value":"0x0","data":"0x3ccfd60b"},"0x12c9"]}`
+
+	var tests = []struct {
+		callResponse string
+		expected     string
+	}{
+		{
+			callResponse: `{"jsonrpc":"2.0","id":2,"error":{"code":3,"message":"execution reverted","data":"0xcf479181` +
+				`000000000000000000000000000000000000000000000000000000000000000a000000000000000000000000000000000000` +
+				`0000000000000000000000000014"}}`,
+			expected: "execution reverted: InsufficientBalance(10, 20)",
+		},
+		{
+			// The replay succeeds, so the transaction failed for another
+			// reason.
+			callResponse: `{"jsonrpc":"2.0","id":2,"result":"0x"}`,
+			expected:     ErrTransactionFailed.Error(),
+		},
+	}
+
+	for _, test := range tests {
+		ts := newSequenceTestServer(t,
+			[2]string{`{"jsonrpc":"2.0","id":1,"method":"eth_getTransactionByHash","params":["` + hash + `"]}`, txResponse},
+			[2]string{call, test.callResponse})
+		defer ts.Close()
+
+		c, err := Dial(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = c.TransactionError(txn.TransactionReceipt{BlockNumber: 4810, TransactionHash: hash}, cont.Abi)
+		if err == nil || err.Error() != test.expected {
+			t.Fatalf("Expected: %s, received: %v", test.expected, err)
+		}
+	}
+}
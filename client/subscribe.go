@@ -0,0 +1,204 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"ethereum/block"
+	"ethereum/txn"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Delays between attempts to resubscribe after the connection to the node is
+// lost.  The delay doubles after each failed attempt, up to the maximum.
+var (
+	resubscribeDelay    = time.Second
+	maxResubscribeDelay = 30 * time.Second
+)
+
+// Subscription is a subscription to notifications from a node.  If the
+// connection to the node is lost, it reconnects and resubscribes until
+// Unsubscribe is called.  Notifications sent while disconnected are lost,
+// e.g. a gap in a log subscription can be filled with FilterLogs.
+type Subscription struct {
+	unsub chan struct{}
+	done  chan struct{}
+	err   chan error
+	once  sync.Once
+}
+
+// Err returns a channel which receives the error which ended the
+// subscription, if any, and is closed once the subscription has ended.
+func (s *Subscription) Err() <-chan error {
+	return s.err
+}
+
+// Unsubscribe ends the subscription.  No more notifications are sent to the
+// subscription's channel once it returns.
+func (s *Subscription) Unsubscribe() {
+	s.once.Do(func() { close(s.unsub) })
+	<-s.done
+}
+
+// SubscribeNewHead sends the header of each new block at the head of the
+// chain to ch.
+func (c Client) SubscribeNewHead(ch chan<- block.Header) (*Subscription, error) {
+	return c.subscribe(func(raw json.RawMessage, quit <-chan struct{}) (bool, error) {
+		var h rawHeader
+		if err := json.Unmarshal(raw, &h); err != nil {
+			return false, err
+		}
+
+		select {
+		case ch <- h.header():
+			return true, nil
+		case <-quit:
+			return false, nil
+		}
+	}, "newHeads")
+}
+
+// SubscribeLogs sends each new log matching q to ch.  The block range and
+// hash of q are ignored.  Logs removed by a chain reorganisation are sent
+// again with Removed set.
+func (c Client) SubscribeLogs(q FilterQuery, ch chan<- txn.Log) (*Subscription, error) {
+	return c.subscribe(func(raw json.RawMessage, quit <-chan struct{}) (bool, error) {
+		var l rawLog
+		if err := json.Unmarshal(raw, &l); err != nil {
+			return false, err
+		}
+
+		select {
+		case ch <- l.log():
+			return true, nil
+		case <-quit:
+			return false, nil
+		}
+	}, "logs", filterArg{Address: q.Addresses, Topics: q.Topics})
+}
+
+// SubscribePendingTransactions sends the hash of each transaction entering
+// the node's transaction pool to ch.
+func (c Client) SubscribePendingTransactions(ch chan<- string) (*Subscription, error) {
+	return c.subscribe(func(raw json.RawMessage, quit <-chan struct{}) (bool, error) {
+		var hash string
+		if err := json.Unmarshal(raw, &hash); err != nil {
+			return false, err
+		}
+
+		select {
+		case ch <- hash:
+			return true, nil
+		case <-quit:
+			return false, nil
+		}
+	}, "newPendingTransactions")
+}
+
+// notifyFunc parses a notification and sends it on to the subscriber, unless
+// quit is closed first, in which case it returns false.
+type notifyFunc func(raw json.RawMessage, quit <-chan struct{}) (bool, error)
+
+// subscribe subscribes to the notifications selected by the eth_subscribe
+// args, and passes them to notify until the subscription ends.
+func (c Client) subscribe(notify notifyFunc, args ...interface{}) (*Subscription, error) {
+	in := make(chan json.RawMessage)
+	sub, err := c.EthSubscribe(context.Background(), in, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Subscription{
+		unsub: make(chan struct{}),
+		done:  make(chan struct{}),
+		err:   make(chan error, 1),
+	}
+	go s.run(c.url, sub, in, notify, args)
+	return s, nil
+}
+
+func (s *Subscription) run(url string, sub *rpc.ClientSubscription, in chan json.RawMessage, notify notifyFunc,
+	args []interface{}) {
+	// conn is the connection dialled to resubscribe, if any.
+	var conn *rpc.Client
+	defer func() {
+		if sub != nil {
+			sub.Unsubscribe()
+		}
+		if conn != nil {
+			conn.Close()
+		}
+		close(s.err)
+		close(s.done)
+	}()
+
+	for {
+		select {
+		case raw := <-in:
+			ok, err := notify(raw, s.unsub)
+			if err != nil {
+				s.err <- err
+				return
+			}
+			if !ok {
+				return
+			}
+		case err := <-sub.Err():
+			if err == nil {
+				// Unsubscribed by the node.
+				return
+			}
+
+			if conn != nil {
+				conn.Close()
+			}
+			conn, sub, err = s.resubscribe(url, in, args)
+			if err != nil {
+				s.err <- err
+				return
+			}
+			if sub == nil {
+				// Unsubscribed while reconnecting.
+				return
+			}
+		case <-s.unsub:
+			return
+		}
+	}
+}
+
+// resubscribe dials a new connection to the node and subscribes again,
+// retrying until it succeeds or the subscription ends.  An error is only
+// returned if the node rejects the subscription.
+func (s *Subscription) resubscribe(url string, in chan json.RawMessage, args []interface{}) (*rpc.Client, *rpc.ClientSubscription, error) {
+	delay := resubscribeDelay
+	for {
+		select {
+		case <-time.After(delay):
+		case <-s.unsub:
+			return nil, nil, nil
+		}
+
+		conn, err := rpc.Dial(url)
+		if err == nil {
+			var sub *rpc.ClientSubscription
+			sub, err = conn.EthSubscribe(context.Background(), in, args...)
+			if err == nil {
+				return conn, sub, nil
+			}
+			conn.Close()
+
+			var rpcErr rpc.Error
+			if errors.As(err, &rpcErr) {
+				return nil, nil, err
+			}
+		}
+
+		if delay *= 2; delay > maxResubscribeDelay {
+			delay = maxResubscribeDelay
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package client
+
+import (
+	"encoding/json"
+	"ethereum/block"
+	"ethereum/txn"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newWSTestServer starts a WebSocket stand-in for a node.  Each connection
+// must open with an eth_subscribe request with the given params, which is
+// answered with subscription id 0x1, after which the notifications for the
+// connection (indexed by the number of previous connections) are sent.  If
+// there are notifications for a later connection, the connection is then
+// dropped, otherwise it is kept open, answering eth_unsubscribe, until the
+// client closes it.
+func newWSTestServer(t *testing.T, params string, notifications [][]string) (*httptest.Server, chan string) {
+	var (
+		upgrader = websocket.Upgrader{}
+		conns    int32
+		requests = make(chan string, 10)
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+
+		n := int(atomic.AddInt32(&conns, 1)) - 1
+
+		var req struct {
+			ID     json.RawMessage
+			Method string
+			Params json.RawMessage
+		}
+		if err := conn.ReadJSON(&req); err != nil {
+			t.Error(err)
+			return
+		}
+		if req.Method != "eth_subscribe" || string(req.Params) != params {
+			t.Errorf("Expected: eth_subscribe %s, received: %s %s", params, req.Method, req.Params)
+			return
+		}
+
+		reply := fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":"0x1"}`, req.ID)
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(reply)); err != nil {
+			t.Error(err)
+			return
+		}
+
+		for _, result := range notifications[n] {
+			msg := `{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0x1","result":` + result + `}}`
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+		if n+1 < len(notifications) {
+			return
+		}
+
+		for {
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			requests <- req.Method + " " + string(req.Params)
+
+			// Unsubscribe waits for the reply.
+			if req.Method == "eth_unsubscribe" {
+				reply := fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":true}`, req.ID)
+				if err := conn.WriteMessage(websocket.TextMessage, []byte(reply)); err != nil {
+					return
+				}
+			}
+		}
+	}))
+
+	return ts, requests
+}
+
+func dialWS(t *testing.T, ts *httptest.Server) Client {
+	c, err := Dial("ws" + strings.TrimPrefix(ts.URL, "http"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestSubscribeNewHead(t *testing.T) {
+	header := strings.TrimSuffix(strings.TrimPrefix(genesisResponse, `{"jsonrpc":"2.0","id":1,"result":`), "}")
+	ts, requests := newWSTestServer(t, `["newHeads"]`, [][]string{{header}})
+	defer ts.Close()
+
+	c := dialWS(t, ts)
+	defer c.Close()
+
+	ch := make(chan block.Header)
+	sub, err := c.SubscribeNewHead(ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if h := <-ch; !reflect.DeepEqual(h, genesisHeader) {
+		t.Fatalf("Expected: %+v, received: %+v", genesisHeader, h)
+	}
+
+	sub.Unsubscribe()
+	if err, ok := <-sub.Err(); ok {
+		t.Fatalf("Expected: closed error channel, received: %v", err)
+	}
+	if r := <-requests; r != `eth_unsubscribe ["0x1"]` {
+		t.Fatalf(`Expected: eth_unsubscribe ["0x1"], received: %s`, r)
+	}
+}
+
+func TestSubscribeLogs(t *testing.T) {
+	params := `["logs",{"address":["0xa10a3b175f0f2641cf41912b887f77d8ef34fae8"],"topics":[["0xddf252ad1be2c89b69c2b068` +
+		`fc378daa952ba7f163c4a11628f55a4df523b3ef"]]}]`
+	ts, _ := newWSTestServer(t, params, [][]string{{transferLog}})
+	defer ts.Close()
+
+	c := dialWS(t, ts)
+	defer c.Close()
+
+	ch := make(chan txn.Log)
+	sub, err := c.SubscribeLogs(FilterQuery{
+		Addresses: []string{"0xa10a3b175f0f2641cf41912b887f77d8ef34fae8"},
+		Topics:    [][]string{{"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"}},
+	}, ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	l := <-ch
+	if l.BlockNumber != 4810 || l.LogIndex != 3 || len(l.Topics) != 3 || len(l.Data) != 32 {
+		t.Fatalf("Unexpected log: %+v", l)
+	}
+}
+
+func TestSubscribePendingTransactionsResubscribes(t *testing.T) {
+	defer func(d time.Duration) { resubscribeDelay = d }(resubscribeDelay)
+	resubscribeDelay = 10 * time.Millisecond
+
+	// The first connection drops straight after subscribing, so the
+	// notifications arrive over the second.
+	ts, _ := newWSTestServer(t, `["newPendingTransactions"]`, [][]string{
+		nil,
+		{
+			`"0xd866f3672a3cef05f66dec56d30562bbffcc42aa11b54450e6973d52c89d1719"`,
+			`"0x2c4b4c9b5c0ab0ef6ec8ba1e7e14f8b3c1e6fae62ef9e1dfe6d6f3b0b5f4e0d6"`,
+		},
+	})
+	defer ts.Close()
+
+	c := dialWS(t, ts)
+	defer c.Close()
+
+	ch := make(chan string)
+	sub, err := c.SubscribePendingTransactions(ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	for _, expected := range []string{
+		"0xd866f3672a3cef05f66dec56d30562bbffcc42aa11b54450e6973d52c89d1719",
+		"0x2c4b4c9b5c0ab0ef6ec8ba1e7e14f8b3c1e6fae62ef9e1dfe6d6f3b0b5f4e0d6",
+	} {
+		select {
+		case hash := <-ch:
+			if hash != expected {
+				t.Fatalf("Expected: %s, received: %s", expected, hash)
+			}
+		case err := <-sub.Err():
+			t.Fatal(err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for notification")
+		}
+	}
+}
+
+func TestSubscribeOverHTTP(t *testing.T) {
+	ts := newTestServer(t, "", "")
+	defer ts.Close()
+
+	c, err := Dial(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.SubscribePendingTransactions(make(chan string)); err == nil {
+		t.Fatal("Expected error subscribing over HTTP")
+	}
+}
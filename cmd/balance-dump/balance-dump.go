@@ -3,7 +3,7 @@ package main
 import (
 	"bytes"
 	"encoding/hex"
-	"ethereum/util"
+	"ethereum/trie"
 	"flag"
 	"fmt"
 	"math/big"
@@ -22,7 +22,7 @@ import (
 // - output file
 // OUTPUTS
 // - print num addresses written
-// - write balance,nonce,contract as new line in file
+// - write hashed address,balance,contract as new line in file
 
 var (
 	dbDir       string
@@ -83,57 +83,26 @@ func main() {
 	}
 	defer errFile.Close()
 
-	// Recursively traverse every node.
-	processNode(key)
-}
-
-func processNode(hash []byte) {
-	r, err := getNode(hash)
-	if err != nil {
-		errFile.WriteString(fmt.Sprintf("Error getting node %+v: %s\n", hash, err))
-		return
-	}
-	switch len(r) {
-	case 2:
-		if err := processLeaf(r[1]); err != nil {
-			errFile.WriteString(fmt.Sprintf("Error processing leaf %+v: %s\n", hash, err))
-		}
-	case 17:
-		for _, v := range r {
-			if len(v) == 0 {
+	// Traverse every node of the state trie, whose leaves are accounts keyed
+	// by the keccak256 hash of their address.  A subtree whose node is
+	// missing from the database is logged and skipped, and the rest of the
+	// trie is still dumped.
+	it := trie.Open(key, ldb).NodeIterator()
+	for {
+		for it.Next() {
+			if !it.Leaf() {
 				continue
 			}
-			processNode(v)
+			if err := processLeaf(it.LeafKey(), it.LeafValue()); err != nil {
+				errFile.WriteString(fmt.Sprintf("Error processing leaf 0x%x: %s\n", it.LeafKey(), err))
+			}
 		}
-	}
-}
-
-func getNode(hash []byte) ([][]byte, error) {
-	r, err := ldb.Get(hash)
-	if err != nil {
-		return nil, err
-	}
-
-	out, err := util.DecodeRLP(bytes.NewBuffer(r))
-	if err != nil {
-		return nil, err
-	}
-
-	outArr, ok := out.([]interface{})
-	if !ok {
-		return nil, err
-	}
-
-	vals := make([][]byte, len(outArr))
-	for i, v := range outArr {
-		outVal, ok := v.([]byte)
-		if !ok {
-			return nil, fmt.Errorf("Unable to process rlp")
+		if it.Err() == nil {
+			break
 		}
-		vals[i] = outVal
+		errFile.WriteString(fmt.Sprintf("Error traversing trie: %s\n", it.Err()))
+		it.Skip()
 	}
-
-	return vals, nil
 }
 
 type account struct {
@@ -143,7 +112,11 @@ type account struct {
 	CodeHash []byte
 }
 
-func processLeaf(in []byte) error {
+func processLeaf(key, in []byte) error {
+	if len(key) != 32 {
+		return fmt.Errorf("invalid account key length %d", len(key))
+	}
+
 	var a account
 	if err := rlp.Decode(bytes.NewReader(in), &a); err != nil {
 		return err
@@ -151,7 +124,7 @@ func processLeaf(in []byte) error {
 
 	isContract := !bytes.Equal(a.CodeHash, emptyCodeHash)
 
-	if _, err := outFile.WriteString(fmt.Sprintf("%s,%t\n", a.Balance.String(), isContract)); err != nil {
+	if _, err := outFile.WriteString(fmt.Sprintf("0x%x,%s,%t\n", key, a.Balance.String(), isContract)); err != nil {
 		return err
 	}
 	numProcessed++
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"ethereum/contract"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// INPUTS
+// - one of: an ABI JSON file (and optionally its code), solc --combined-json
+//   output, or a Solidity file to compile
+// - package name
+// OUTPUTS
+// - Go source of typed bindings, written to a file or stdout
+
+var (
+	abiFile      string
+	binFile      string
+	combinedFile string
+	solFile      string
+	typeName     string
+	pkgName      string
+	outFileName  string
+)
+
+func init() {
+	flag.StringVar(&abiFile, "abi", "", "ABI JSON file of the contract")
+	flag.StringVar(&binFile, "bin", "", "Hex encoded deployment code of the contract given by -abi")
+	flag.StringVar(&combinedFile, "combined", "", "Output of solc --combined-json abi,bin")
+	flag.StringVar(&solFile, "sol", "", "Solidity file to compile with solc")
+	flag.StringVar(&typeName, "type", "", "Go type name of the binding, or the contract to bind from -combined or -sol")
+	flag.StringVar(&pkgName, "pkg", "", "Package name of the generated code")
+	flag.StringVar(&outFileName, "o", "", "File to write the generated code to, instead of stdout")
+}
+
+func main() {
+	flag.Parse()
+	if pkgName == "" {
+		fatalf("please enter a package name with -pkg")
+	}
+
+	var (
+		bindings []binding
+		err      error
+	)
+	switch {
+	case abiFile != "":
+		bindings, err = fromABIFile()
+	case combinedFile != "":
+		bindings, err = fromCombinedFile()
+	case solFile != "":
+		bindings, err = fromSolFile()
+	default:
+		fatalf("please enter one of -abi, -combined or -sol")
+	}
+	if err != nil {
+		fatalf("%s", err)
+	}
+
+	src, err := generate(pkgName, bindings)
+	if err != nil {
+		fatalf("%s", err)
+	}
+
+	if outFileName == "" {
+		os.Stdout.Write(src)
+		return
+	}
+	if err := ioutil.WriteFile(outFileName, src, 0644); err != nil {
+		fatalf("%s", err)
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "bindgen: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+func fromABIFile() ([]binding, error) {
+	if typeName == "" {
+		return nil, fmt.Errorf("please enter a type name with -type")
+	}
+
+	data, err := ioutil.ReadFile(abiFile)
+	if err != nil {
+		return nil, err
+	}
+	a, err := contract.NewABI(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	b := binding{Type: typeName, Abi: a}
+	if binFile != "" {
+		data, err := ioutil.ReadFile(binFile)
+		if err != nil {
+			return nil, err
+		}
+		if b.Bin, err = decodeBin(string(data)); err != nil {
+			return nil, err
+		}
+	}
+	return []binding{b}, nil
+}
+
+// fromCombinedFile binds the contracts in solc --combined-json output, or
+// only the one named by -type.
+func fromCombinedFile() ([]binding, error) {
+	data, err := ioutil.ReadFile(combinedFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var out struct {
+		Contracts map[string]struct {
+			// Older versions of solc give the ABI as a string.
+			Abi json.RawMessage
+			Bin string
+		}
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for name := range out.Contracts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var bindings []binding
+	for _, name := range names {
+		c := out.Contracts[name]
+		typ := exported(name[strings.LastIndex(name, ":")+1:])
+		if typeName != "" && typ != exported(typeName) {
+			continue
+		}
+
+		abi := string(c.Abi)
+		var s string
+		if json.Unmarshal(c.Abi, &s) == nil {
+			abi = s
+		}
+		a, err := contract.NewABI(abi)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+
+		b := binding{Type: typ, Abi: a}
+		if c.Bin != "" {
+			if b.Bin, err = decodeBin(c.Bin); err != nil {
+				return nil, fmt.Errorf("%s: %s", name, err)
+			}
+		}
+		bindings = append(bindings, b)
+	}
+
+	if len(bindings) == 0 {
+		return nil, fmt.Errorf("no contract to bind in %s", combinedFile)
+	}
+	return bindings, nil
+}
+
+// fromSolFile compiles a Solidity file and binds the contracts in it, or only
+// the one named by -type.
+func fromSolFile() ([]binding, error) {
+	out, err := contract.Compiler{}.Compile(solFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range out.Diagnostics {
+		fmt.Fprintln(os.Stderr, d)
+	}
+
+	var bindings []binding
+	for _, name := range contract.Names(out.Contracts) {
+		typ := exported(name[strings.LastIndex(name, ":")+1:])
+		if typeName != "" && typ != exported(typeName) {
+			continue
+		}
+
+		c := out.Contracts[name]
+		bindings = append(bindings, binding{Type: typ, Abi: c.Abi, Bin: c.Bin, LinkRefs: c.LinkRefs})
+	}
+
+	if len(bindings) == 0 {
+		return nil, fmt.Errorf("no contract to bind in %s", solFile)
+	}
+	return bindings, nil
+}
+
+func decodeBin(s string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(s), "0x"))
+}
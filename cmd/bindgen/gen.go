@@ -0,0 +1,589 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"ethereum/contract"
+	"fmt"
+	"go/format"
+	"go/token"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// binding is a contract to generate a Go binding for.
+type binding struct {
+	Type string // Go type name
+	Abi  contract.ABI
+	Bin  []byte // code for deployment, if known
+
+	// LinkRefs holds the libraries which Bin must be linked with.
+	LinkRefs []contract.LinkRef
+}
+
+// generator writes the Go source of bindings, keeping track of the imports
+// which the source needs.
+type generator struct {
+	buf     bytes.Buffer
+	imports map[string]bool
+
+	// structs maps the canonical type of tuples to the names of the Go
+	// structs generated for them.
+	structs     map[string]string
+	structNames map[string]bool
+}
+
+// generate returns the formatted Go source of package pkg holding bindings
+// for the given contracts.
+func generate(pkg string, bindings []binding) ([]byte, error) {
+	g := &generator{
+		imports:     map[string]bool{"ethereum/contract": true},
+		structs:     make(map[string]string),
+		structNames: make(map[string]bool),
+	}
+
+	for _, b := range bindings {
+		if err := g.contract(b); err != nil {
+			return nil, fmt.Errorf("%s: %s", b.Type, err)
+		}
+	}
+
+	var imports []string
+	for imp := range g.imports {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by bindgen. DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkg)
+	for _, imp := range imports {
+		fmt.Fprintf(&out, "\t%q\n", imp)
+	}
+	fmt.Fprintf(&out, ")\n")
+	out.Write(g.buf.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid source: %s\n%s", err, out.Bytes())
+	}
+	return src, nil
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) contract(b binding) error {
+	abiJSON, err := json.Marshal(b.Abi)
+	if err != nil {
+		return err
+	}
+
+	g.printf("\n// %sABI is the ABI of the %s contract.\n", b.Type, b.Type)
+	g.printf("const %sABI = %q\n", b.Type, abiJSON)
+	if b.Bin != nil {
+		g.printf("\n// %sBin is the code which deploys the %s contract.\n", b.Type, b.Type)
+		g.printf("const %sBin = %q\n", b.Type, hex.EncodeToString(b.Bin))
+	}
+	if b.Bin != nil && len(b.LinkRefs) > 0 {
+		g.printf("\n// %sLinkRefs holds the libraries which %sBin must be linked with.\n", b.Type, b.Type)
+		g.printf("var %sLinkRefs = []contract.LinkRef{\n", b.Type)
+		for _, r := range b.LinkRefs {
+			g.printf("{Library: %q, Offset: %d},\n", r.Library, r.Offset)
+		}
+		g.printf("}\n")
+	}
+
+	g.printf(`
+// %[1]s is a binding to the %[1]s contract.
+type %[1]s struct {
+	contract.Contract
+}
+
+// New%[1]s returns a binding to the %[1]s contract at address.
+func New%[1]s(address string) (*%[1]s, error) {
+	c, err := contract.New(%[1]sABI, address)
+	if err != nil {
+		return nil, err
+	}
+	return &%[1]s{c}, nil
+}
+`, b.Type)
+
+	if b.Bin != nil {
+		if err := g.deploy(b); err != nil {
+			return err
+		}
+	}
+
+	// Method names must not clash with each other, nor shadow the fields
+	// and methods of the embedded contract.Contract, which generated code
+	// uses.
+	used := promoted()
+
+	sigs := make([]string, 0, len(b.Abi.Functions))
+	for sig := range b.Abi.Functions {
+		sigs = append(sigs, sig)
+	}
+	sort.Slice(sigs, func(i, j int) bool {
+		fi, fj := b.Abi.Functions[sigs[i]], b.Abi.Functions[sigs[j]]
+		if fi.Name != fj.Name {
+			return fi.Name < fj.Name
+		}
+		return len(fi.Inputs) < len(fj.Inputs) || len(fi.Inputs) == len(fj.Inputs) && sigs[i] < sigs[j]
+	})
+	for _, sig := range sigs {
+		f := b.Abi.Functions[sig]
+		name := uniqueName(exported(f.Name), used)
+		if err := g.function(b.Type, name, sig, f); err != nil {
+			return err
+		}
+	}
+
+	used = make(map[string]bool)
+	sigs = sigs[:0]
+	for sig := range b.Abi.Events {
+		sigs = append(sigs, sig)
+	}
+	sort.Strings(sigs)
+	for _, sig := range sigs {
+		ev := b.Abi.Events[sig]
+		name := uniqueName(exported(ev.Name), used)
+		if err := g.event(b.Type, name, sig, ev); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *generator) deploy(b binding) error {
+	g.imports["encoding/hex"] = true
+	g.imports["ethereum/txn"] = true
+
+	var inputs []contract.Param
+	if b.Abi.Constructor != nil {
+		inputs = b.Abi.Constructor.Inputs
+	}
+	params, args, err := g.params(b.Type, inputs)
+	if err != nil {
+		return err
+	}
+
+	if len(b.LinkRefs) == 0 {
+		g.printf(`
+// Deploy%[1]s sets the transaction's data to deploy the %[1]s contract with
+// the given constructor args.
+func Deploy%[1]s(_t *txn.Transaction%[2]s) error {
+	c, err := contract.New(%[1]sABI, "")
+	if err != nil {
+		return err
+	}
+	if c.Bin, err = hex.DecodeString(%[1]sBin); err != nil {
+		return err
+	}
+	return c.Deploy(_t%[3]s)
+}
+`, b.Type, prefixComma(params), prefixComma(args))
+		return nil
+	}
+
+	// Code which uses libraries is linked with their addresses, without
+	// which Deploy fails.
+	g.imports["ethereum/accnt"] = true
+	g.printf(`
+// Deploy%[1]s sets the transaction's data to deploy the %[1]s contract with
+// the given constructor args, linked with the libraries in _libs, keyed as by
+// contract.Contract.Link.
+func Deploy%[1]s(_t *txn.Transaction, _libs map[string]accnt.Address%[2]s) error {
+	c, err := contract.New(%[1]sABI, "")
+	if err != nil {
+		return err
+	}
+	if c.Bin, err = hex.DecodeString(%[1]sBin); err != nil {
+		return err
+	}
+	c.LinkRefs = %[1]sLinkRefs
+	if c, err = c.Link(_libs); err != nil {
+		return err
+	}
+	return c.Deploy(_t%[3]s)
+}
+`, b.Type, prefixComma(params), prefixComma(args))
+	return nil
+}
+
+func (g *generator) function(typ, name, sig string, f contract.Function) error {
+	params, args, err := g.params(typ, f.Inputs)
+	if err != nil {
+		return err
+	}
+
+	if !f.Constant {
+		g.imports["ethereum/txn"] = true
+		g.printf(`
+// %[2]s sets the transaction's data to call %[3]s.
+func (_c *%[1]s) %[2]s(_t *txn.Transaction%[4]s) error {
+	return _c.Contract.Call(%[3]q, _t%[5]s)
+}
+`, typ, name, sig, prefixComma(params), prefixComma(args))
+		return nil
+	}
+
+	g.imports["ethereum/client"] = true
+	callArgs := "nil"
+	if args != "" {
+		callArgs = "[]interface{}{" + args + "}"
+	}
+
+	switch len(f.Outputs) {
+	case 0:
+		g.printf(`
+// %[2]s calls %[3]s.
+func (_c *%[1]s) %[2]s(_cl client.Client%[4]s) error {
+	return _cl.CallContract(_c.Contract, %[3]q, %[5]s, nil)
+}
+`, typ, name, sig, prefixComma(params), callArgs)
+		return nil
+	case 1:
+		t, err := contract.NewType(f.Outputs[0].Type, f.Outputs[0].Components)
+		if err != nil {
+			return err
+		}
+		// An unnamed output is named after the function.
+		hint := exported(f.Outputs[0].Name)
+		if hint == "" {
+			hint = exported(f.Name)
+		}
+		out, err := g.goType(typ, hint, t)
+		if err != nil {
+			return err
+		}
+		g.printf(`
+// %[2]s calls %[3]s.
+func (_c *%[1]s) %[2]s(_cl client.Client%[4]s) (%[6]s, error) {
+	var out %[6]s
+	err := _cl.CallContract(_c.Contract, %[3]q, %[5]s, &out)
+	return out, err
+}
+`, typ, name, sig, prefixComma(params), callArgs, out)
+		return nil
+	}
+
+	// Multiple outputs are returned in a struct.
+	out := uniqueName(typ+name+"Output", g.structNames)
+	if err := g.structType(out, "holds the outputs of "+typ+"."+name, typ, f.Outputs, "Ret"); err != nil {
+		return err
+	}
+	g.printf(`
+// %[2]s calls %[3]s.
+func (_c *%[1]s) %[2]s(_cl client.Client%[4]s) (%[6]s, error) {
+	var out %[6]s
+	err := _cl.CallContract(_c.Contract, %[3]q, %[5]s, &out)
+	return out, err
+}
+`, typ, name, sig, prefixComma(params), callArgs, out)
+	return nil
+}
+
+func (g *generator) event(typ, name, sig string, ev contract.Event) error {
+	g.imports["ethereum/client"] = true
+	g.imports["ethereum/txn"] = true
+	g.imports["math/big"] = true
+
+	st := uniqueName(typ+name, g.structNames)
+	if err := g.structType(st, "is a "+ev.Name+" event of the "+typ+" contract", typ, ev.Inputs, "Arg"); err != nil {
+		return err
+	}
+
+	// Each indexed input can be filtered by a set of values.
+	var (
+		params []string
+		rules  bytes.Buffer
+		sets   []string
+	)
+	for i, in := range ev.Inputs {
+		if !in.Indexed {
+			continue
+		}
+
+		t, err := contract.NewType(in.Type, in.Components)
+		if err != nil {
+			return err
+		}
+		goType, err := g.goType(typ, exported(in.Name), t)
+		if err != nil {
+			return err
+		}
+
+		p := paramName(in.Name, i, "arg")
+		params = append(params, fmt.Sprintf("%s []%s", p, goType))
+		fmt.Fprintf(&rules, "\tvar %[1]sRule []interface{}\n\tfor _, v := range %[1]s {\n\t\t%[1]sRule = append(%[1]sRule, v)\n\t}\n", p)
+		sets = append(sets, p+"Rule")
+	}
+
+	g.printf(`
+// Filter%[2]s returns the %[3]s events logged by the contract between
+// fromBlock and toBlock, which default to the genesis and latest blocks.  The
+// events can be filtered by the values of their indexed inputs, any of the
+// given values matching.
+func (_c *%[1]s) Filter%[2]s(_cl client.Client, _fromBlock, _toBlock *big.Int%[4]s) ([]%[5]s, error) {
+	ev, err := _c.Abi.Event(%[6]q)
+	if err != nil {
+		return nil, err
+	}
+
+%[7]s	topics, err := ev.TopicSets(%[8]s)
+	if err != nil {
+		return nil, err
+	}
+
+	logs, err := _cl.FilterLogs(client.FilterQuery{
+		FromBlock: _fromBlock,
+		ToBlock:   _toBlock,
+		Addresses: []string{_c.Address},
+		Topics:    topics,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]%[5]s, len(logs))
+	for i, l := range logs {
+		if err := ev.Decode(l, &events[i]); err != nil {
+			return nil, err
+		}
+		events[i].Raw = l
+	}
+	return events, nil
+}
+
+// Parse%[2]s decodes a %[3]s event from a log of the contract.
+func (_c *%[1]s) Parse%[2]s(log txn.Log) (%[5]s, error) {
+	var event %[5]s
+	ev, err := _c.Abi.Event(%[6]q)
+	if err != nil {
+		return event, err
+	}
+
+	err = ev.Decode(log, &event)
+	event.Raw = log
+	return event, err
+}
+`, typ, name, ev.Name, prefixComma(strings.Join(params, ", ")), st, sig, rules.String(), strings.Join(sets, ", "))
+	return nil
+}
+
+// params returns the parameter list and argument list of a Go function
+// taking the given ABI params.
+func (g *generator) params(typ string, ps []contract.Param) (string, string, error) {
+	var params, args []string
+	for i, p := range ps {
+		t, err := contract.NewType(p.Type, p.Components)
+		if err != nil {
+			return "", "", err
+		}
+		goType, err := g.goType(typ, exported(p.Name), t)
+		if err != nil {
+			return "", "", err
+		}
+
+		name := paramName(p.Name, i, "arg")
+		params = append(params, name+" "+goType)
+		args = append(args, name)
+	}
+	return strings.Join(params, ", "), strings.Join(args, ", "), nil
+}
+
+// structType generates a struct with a field per ABI param.  Unnamed params
+// are named by prefix and their position.  Events get a Raw field holding
+// the log they were decoded from.
+func (g *generator) structType(name, doc, typ string, ps []contract.Param, prefix string) error {
+	var fields bytes.Buffer
+	for i, p := range ps {
+		t, err := contract.NewType(p.Type, p.Components)
+		if err != nil {
+			return err
+		}
+
+		goType := "[32]byte"
+		if !p.Indexed || !hashedTopic(t) {
+			if goType, err = g.goType(typ, exported(p.Name), t); err != nil {
+				return err
+			}
+		}
+
+		fields.WriteString(structField(p.Name, i, prefix, goType))
+	}
+	if prefix == "Arg" {
+		fields.WriteString("\n\tRaw txn.Log // the log the event was decoded from\n")
+	}
+
+	g.printf("\n// %s %s.\ntype %s struct {\n%s}\n", name, doc, name, fields.String())
+	return nil
+}
+
+// goType returns the Go type which values of t are bound to.  Tuples are
+// bound to generated structs, named after the contract and hint.
+func (g *generator) goType(typ, hint string, t contract.Type) (string, error) {
+	switch t.Kind {
+	case contract.UintKind, contract.IntKind:
+		switch t.Size {
+		case 8, 16, 32, 64:
+			if t.Kind == contract.UintKind {
+				return fmt.Sprintf("uint%d", t.Size), nil
+			}
+			return fmt.Sprintf("int%d", t.Size), nil
+		}
+		g.imports["math/big"] = true
+		return "*big.Int", nil
+	case contract.BoolKind:
+		return "bool", nil
+	case contract.AddressKind:
+		g.imports["ethereum/accnt"] = true
+		return "accnt.Address", nil
+	case contract.FixedBytesKind:
+		return fmt.Sprintf("[%d]byte", t.Size), nil
+	case contract.BytesKind:
+		return "[]byte", nil
+	case contract.StringKind:
+		return "string", nil
+	case contract.SliceKind:
+		elem, err := g.goType(typ, hint, *t.Elem)
+		return "[]" + elem, err
+	case contract.ArrayKind:
+		elem, err := g.goType(typ, hint, *t.Elem)
+		return fmt.Sprintf("[%d]%s", t.Size, elem), err
+	case contract.TupleKind:
+		return g.tupleType(typ, hint, t)
+	}
+	return "", fmt.Errorf("unsupported type %s", t)
+}
+
+// tupleType returns the name of the struct generated for a tuple type,
+// generating it the first time the tuple type is seen.
+func (g *generator) tupleType(typ, hint string, t contract.Type) (string, error) {
+	key := typ + t.String() + strings.Join(t.ComponentNames, ",")
+	if name, ok := g.structs[key]; ok {
+		return name, nil
+	}
+
+	if hint == "" {
+		hint = "Tuple"
+	}
+	name := uniqueName(typ+hint, g.structNames)
+	g.structs[key] = name
+
+	var fields bytes.Buffer
+	for i, c := range t.Components {
+		goType, err := g.goType(typ, exported(t.ComponentNames[i]), c)
+		if err != nil {
+			return "", err
+		}
+
+		fields.WriteString(structField(t.ComponentNames[i], i, "Field", goType))
+	}
+
+	g.printf("\n// %s is the Go binding of the tuple %s.\ntype %s struct {\n%s}\n", name, t, name, fields.String())
+	return name, nil
+}
+
+// structField returns the declaration of the struct field for the ABI value
+// with the given name and position.  Unnamed values are named by prefix and
+// position, and matched to their field by position when decoding, so only
+// named values are tagged.
+func structField(name string, i int, prefix, goType string) string {
+	field := exported(name)
+	if field == "" || field == "Raw" {
+		return fmt.Sprintf("\t%s%d %s\n", prefix, i, goType)
+	}
+	return fmt.Sprintf("\t%s %s `abi:%q`\n", field, goType, name)
+}
+
+// hashedTopic reports whether indexed values of type t are logged as hashes.
+func hashedTopic(t contract.Type) bool {
+	switch t.Kind {
+	case contract.BytesKind, contract.StringKind, contract.SliceKind, contract.ArrayKind, contract.TupleKind:
+		return true
+	}
+	return false
+}
+
+// exported converts a Solidity identifier to an exported Go identifier, e.g.
+// "_owner" to "Owner".
+func exported(name string) string {
+	name = strings.TrimLeft(name, "_")
+	if name == "" {
+		return ""
+	}
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// paramName converts a Solidity parameter name to a Go parameter name,
+// naming unnamed parameters by prefix and position.
+func paramName(name string, i int, prefix string) string {
+	name = strings.TrimLeft(name, "_")
+	if name == "" {
+		return fmt.Sprintf("%s%d", prefix, i)
+	}
+
+	r := []rune(name)
+	r[0] = unicode.ToLower(r[0])
+	name = string(r)
+	if token.Lookup(name).IsKeyword() || isPredeclared(name) {
+		name += "_"
+	}
+	return name
+}
+
+// isPredeclared reports whether a parameter called name would shadow a
+// predeclared identifier, an imported package or a local variable of the
+// generated methods.
+func isPredeclared(name string) bool {
+	switch name {
+	case "accnt", "big", "client", "contract", "hex", "txn",
+		"nil", "true", "false", "len", "append", "bool", "byte", "string", "error", "int", "uint", "copy", "make",
+		"new",
+		"c", "err", "out", "ev", "topics", "logs", "events", "event", "log", "i", "l", "v":
+		return true
+	}
+	return false
+}
+
+// promoted returns the names which a binding promotes from its embedded
+// contract.Contract.
+func promoted() map[string]bool {
+	names := map[string]bool{"Contract": true}
+	t := reflect.TypeOf(contract.Contract{})
+	for i := 0; i < t.NumField(); i++ {
+		names[t.Field(i).Name] = true
+	}
+	pt := reflect.PtrTo(t)
+	for i := 0; i < pt.NumMethod(); i++ {
+		names[pt.Method(i).Name] = true
+	}
+	return names
+}
+
+// uniqueName returns name, or name with a numeric suffix if it's already
+// used, and marks it used.
+func uniqueName(name string, used map[string]bool) string {
+	unique := name
+	for i := 0; used[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	used[unique] = true
+	return unique
+}
+
+func prefixComma(s string) string {
+	if s == "" {
+		return ""
+	}
+	return ", " + s
+}
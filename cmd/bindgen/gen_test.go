@@ -0,0 +1,174 @@
+package main
+
+import (
+	"ethereum/contract"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+)
+
+const tokenAbi = `[
+	{"inputs":[{"name":"_name","type":"string"},{"name":"_supply","type":"uint256"}],"stateMutability":"nonpayable",
+	 "type":"constructor"},
+	{"inputs":[{"name":"owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],
+	 "stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"name":"transfer",
+	 "outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"}],
+	 "name":"transfer","outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"type","type":"uint8"}],"name":"getReserves","outputs":[{"name":"_reserve0","type":"uint112"},
+	 {"name":"_reserve1","type":"uint112"},{"name":"","type":"uint32"}],"stateMutability":"view","type":"function"},
+	{"inputs":[{"components":[{"name":"id","type":"uint256"},{"name":"tags","type":"bytes32[]"}],"name":"item",
+	 "type":"tuple"}],"name":"store","outputs":[],"stateMutability":"payable","type":"function"},
+	{"inputs":[],"name":"address","outputs":[{"name":"","type":"address"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"abi","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"link","outputs":[],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[],"name":"item","outputs":[{"components":[{"name":"id","type":"uint256"},{"name":"tags",
+	 "type":"bytes32[]"}],"name":"","type":"tuple"}],"stateMutability":"view","type":"function"},
+	{"anonymous":false,"inputs":[{"indexed":true,"name":"from","type":"address"},{"indexed":true,"name":"to",
+	 "type":"address"},{"indexed":false,"name":"value","type":"uint256"}],"name":"Transfer","type":"event"},
+	{"anonymous":false,"inputs":[{"indexed":true,"name":"key","type":"string"},{"indexed":false,"name":"","type":"bool"}],
+	 "name":"Stored","type":"event"}
+]`
+
+func TestGenerate(t *testing.T) {
+	a, err := contract.NewABI(tokenAbi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := generate("token", []binding{{Type: "Token", Abi: a, Bin: []byte{0x60, 0x60}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "token.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decls := make(map[string]string)
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			decls[d.Name.Name] = string(src[fset.Position(d.Pos()).Offset:fset.Position(d.Body.Lbrace).Offset])
+		case *ast.GenDecl:
+			for _, s := range d.Specs {
+				if ts, ok := s.(*ast.TypeSpec); ok {
+					decls[ts.Name.Name] = string(src[fset.Position(ts.Pos()).Offset:fset.Position(ts.End()).Offset])
+				}
+			}
+		}
+	}
+
+	var expected = map[string]string{
+		"DeployToken":    "func DeployToken(_t *txn.Transaction, name string, supply *big.Int) error ",
+		"NewToken":       "func NewToken(address string) (*Token, error) ",
+		"BalanceOf":      "func (_c *Token) BalanceOf(_cl client.Client, owner accnt.Address) (*big.Int, error) ",
+		"Decimals":       "func (_c *Token) Decimals(_cl client.Client) (uint8, error) ",
+		"Transfer":       "func (_c *Token) Transfer(_t *txn.Transaction, to accnt.Address, value *big.Int) error ",
+		"Transfer0":      "func (_c *Token) Transfer0(_t *txn.Transaction, to accnt.Address, value *big.Int, data []byte) error ",
+		"GetReserves":    "func (_c *Token) GetReserves(_cl client.Client, type_ uint8) (TokenGetReservesOutput, error) ",
+		"Store":          "func (_c *Token) Store(_t *txn.Transaction, item TokenItem) error ",
+		"Item":           "func (_c *Token) Item(_cl client.Client) (TokenItem, error) ",
+		"Address0":       "func (_c *Token) Address0(_cl client.Client) (accnt.Address, error) ",
+		"Abi0":           "func (_c *Token) Abi0(_cl client.Client) (string, error) ",
+		"Link0":          "func (_c *Token) Link0(_t *txn.Transaction) error ",
+		"FilterTransfer": "func (_c *Token) FilterTransfer(_cl client.Client, _fromBlock, _toBlock *big.Int, from []accnt.Address, to []accnt.Address) ([]TokenTransfer, error) ",
+		"ParseTransfer":  "func (_c *Token) ParseTransfer(log txn.Log) (TokenTransfer, error) ",
+		"FilterStored":   "func (_c *Token) FilterStored(_cl client.Client, _fromBlock, _toBlock *big.Int, key []string) ([]TokenStored, error) ",
+		"TokenItem":      "TokenItem struct {\n\tId   *big.Int   `abi:\"id\"`\n\tTags [][32]byte `abi:\"tags\"`\n}",
+		"TokenGetReservesOutput": "TokenGetReservesOutput struct {\n\tReserve0 *big.Int `abi:\"_reserve0\"`\n\tReserve1 *big.Int " +
+			"`abi:\"_reserve1\"`\n\tRet2     uint32\n}",
+		"TokenStored": "TokenStored struct {\n\tKey  [32]byte `abi:\"key\"`\n\tArg1 bool\n\n\tRaw txn.Log // the log the event was " +
+			"decoded from\n}",
+	}
+
+	for name, decl := range expected {
+		if decls[name] != decl {
+			t.Fatalf("Expected: %s, received: %s", decl, decls[name])
+		}
+	}
+
+	// The generated code must compile against the repo's packages.
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check("token", fset, []*ast.File{f}, nil); err != nil {
+		t.Fatalf("%s\n%s", err, src)
+	}
+}
+
+func TestGenerateLinked(t *testing.T) {
+	a, err := contract.NewABI(`[{"inputs":[{"name":"x","type":"uint256"}],"name":"root",` +
+		`"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// PUSH20 of the address of the library lib.sol:Math, zeroed until linked.
+	bin := append([]byte{0x73}, make([]byte, 20)...)
+	refs := []contract.LinkRef{{Library: "lib.sol:Math", Offset: 1}}
+	src, err := generate("calc", []binding{{Type: "Calc", Abi: a, Bin: bin, LinkRefs: refs}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, decl := range []string{
+		"var CalcLinkRefs = []contract.LinkRef{\n\t{Library: \"lib.sol:Math\", Offset: 1},\n}",
+		"func DeployCalc(_t *txn.Transaction, _libs map[string]accnt.Address) error {",
+		"c.LinkRefs = CalcLinkRefs\n\tif c, err = c.Link(_libs); err != nil {",
+	} {
+		if !strings.Contains(string(src), decl) {
+			t.Errorf("Expected: %s, received:\n%s", decl, src)
+		}
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "calc.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check("calc", fset, []*ast.File{f}, nil); err != nil {
+		t.Fatalf("%s\n%s", err, src)
+	}
+}
+
+func TestGenerateNames(t *testing.T) {
+	var tests = []struct {
+		in, exported, param string
+	}{
+		{"_owner", "Owner", "owner"},
+		{"Value", "Value", "value"},
+		{"range", "Range", "range_"},
+		{"err", "Err", "err_"},
+		{"", "", "arg3"},
+	}
+
+	for _, test := range tests {
+		if e := exported(test.in); e != test.exported {
+			t.Fatalf("Expected: %s, received: %s", test.exported, e)
+		}
+		if p := paramName(test.in, 3, "arg"); p != test.param {
+			t.Fatalf("Expected: %s, received: %s", test.param, p)
+		}
+	}
+
+	used := map[string]bool{}
+	for _, expected := range []string{"Transfer", "Transfer0", "Transfer1"} {
+		if n := uniqueName("Transfer", used); n != expected {
+			t.Fatalf("Expected: %s, received: %s", expected, n)
+		}
+	}
+
+	if _, err := generate("bad", []binding{{Type: "Bad", Abi: contract.ABI{Functions: map[string]contract.Function{
+		"f(fixed128x18)": {Type: "function", Name: "f", Inputs: []contract.Param{{Type: "fixed128x18"}}},
+	}}}}); err == nil || !strings.Contains(err.Error(), "Bad") {
+		t.Fatalf("Expected error for unsupported type, received: %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/hex"
+	"ethereum/evm/asm"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// INPUTS
+// - hex encoded creation or runtime code, from a file or stdin
+// OUTPUTS
+// - instruction listing of the code, split into init and runtime code if it
+//   is creation code, with the runtime code's selectors and metadata
+
+var (
+	blocks  bool
+	noSplit bool
+)
+
+func init() {
+	flag.BoolVar(&blocks, "blocks", false, "Separate basic blocks with blank lines")
+	flag.BoolVar(&noSplit, "nosplit", false, "Don't split creation code into init and runtime code")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: disasm [flags] [file]\n")
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	code, err := readCode(flag.Arg(0))
+	if err != nil {
+		fatalf("%s", err)
+	}
+	if len(code) == 0 {
+		fatalf("no code")
+	}
+
+	if !noSplit {
+		if c, ok := asm.SplitCreation(code); ok {
+			fmt.Println("; init code")
+			list(c.Init)
+			fmt.Println("\n; runtime code")
+			runtime(c.Runtime)
+			if len(c.Args) > 0 {
+				fmt.Printf("\n; constructor args\n0x%x\n", c.Args)
+			}
+			return
+		}
+	}
+	runtime(code)
+}
+
+// readCode reads hex encoded code from the named file, or stdin if name is
+// "".
+func readCode(name string) ([]byte, error) {
+	var r io.Reader = os.Stdin
+	if name != "" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	s := strings.TrimPrefix(strings.TrimSpace(string(data)), "0x")
+	return hex.DecodeString(s)
+}
+
+// runtime lists runtime code without its metadata trailer, followed by its
+// selectors and metadata.
+func runtime(code []byte) {
+	meta, err := asm.ParseMetadata(code)
+	if err == nil {
+		code = code[:len(code)-meta.Length]
+	}
+	list(code)
+
+	if sels := asm.Selectors(code); len(sels) > 0 {
+		fmt.Println("\n; selectors")
+		for _, sel := range sels {
+			fmt.Printf("0x%x\n", sel)
+		}
+	}
+
+	if err != nil {
+		return
+	}
+	fmt.Println("\n; metadata")
+	if meta.Solc != "" {
+		fmt.Printf("solc %s\n", meta.Solc)
+	}
+	if len(meta.IPFS) > 0 {
+		fmt.Printf("ipfs %s\n", meta.IPFSHash())
+	}
+	if len(meta.Swarm) > 0 {
+		fmt.Printf("bzzr 0x%x\n", meta.Swarm)
+	}
+	if meta.Experimental {
+		fmt.Println("experimental")
+	}
+}
+
+func list(code []byte) {
+	if !blocks {
+		fmt.Print(asm.Format(asm.Disassemble(code)))
+		return
+	}
+	for i, b := range asm.BasicBlocks(code) {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Print(asm.Format(b.Instructions))
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "disasm: "+format+"\n", args...)
+	os.Exit(1)
+}
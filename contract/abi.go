@@ -0,0 +1,367 @@
+package contract
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ABI is a contract's application binary interface.  Functions, events and
+// errors are keyed by their canonical signature, so overloads are kept
+// apart.
+type ABI struct {
+	Constructor *Function // nil if the contract declares none
+	Fallback    *Function
+	Receive     *Function
+	Functions   map[string]Function
+	Events      map[string]Event
+	Errors      map[string]Error
+
+	// selectors maps hex encoded selectors to function signatures.
+	selectors map[string]string
+}
+
+// Function is a function, constructor, fallback or receive function.
+type Function struct {
+	Type            string
+	Name            string
+	Inputs          []Param
+	Outputs         []Param
+	Constant        bool
+	Payable         bool
+	StateMutability string
+}
+
+// Event is an event which a contract may emit.
+type Event struct {
+	Name      string
+	Inputs    []Param
+	Anonymous bool
+}
+
+// Error is a custom error which a contract may revert with.
+type Error struct {
+	Name   string
+	Inputs []Param
+}
+
+type Param struct {
+	Name       string  `json:"name"`
+	Type       string  `json:"type"`
+	Components []Param `json:"components,omitempty"` // members of tuple types
+	Indexed    bool    `json:"indexed,omitempty"`    // event params only
+}
+
+// NewABI parses the JSON description of a contract's ABI.
+func NewABI(abi string) (ABI, error) {
+	var entries []struct {
+		Type            string
+		Name            string
+		Inputs          []Param
+		Outputs         []Param
+		Constant        bool
+		Payable         bool
+		StateMutability string
+		Anonymous       bool
+	}
+	if err := json.Unmarshal([]byte(abi), &entries); err != nil {
+		return ABI{}, err
+	}
+
+	a := ABI{
+		Functions: make(map[string]Function),
+		Events:    make(map[string]Event),
+		Errors:    make(map[string]Error),
+		selectors: make(map[string]string),
+	}
+	for _, e := range entries {
+		f := Function{
+			Type:            e.Type,
+			Name:            e.Name,
+			Inputs:          e.Inputs,
+			Outputs:         e.Outputs,
+			Constant:        e.Constant,
+			Payable:         e.Payable,
+			StateMutability: e.StateMutability,
+		}
+
+		// Older compilers only set constant and payable, newer ones only set
+		// stateMutability.
+		switch f.StateMutability {
+		case "pure", "view":
+			f.Constant = true
+		case "payable":
+			f.Payable = true
+		case "":
+			switch {
+			case f.Constant:
+				f.StateMutability = "view"
+			case f.Payable:
+				f.StateMutability = "payable"
+			default:
+				f.StateMutability = "nonpayable"
+			}
+		}
+
+		switch e.Type {
+		case "constructor":
+			a.Constructor = &f
+		case "fallback":
+			a.Fallback = &f
+		case "receive":
+			a.Receive = &f
+		case "event":
+			ev := Event{Name: e.Name, Inputs: e.Inputs, Anonymous: e.Anonymous}
+			sig, err := ev.Signature()
+			if err != nil {
+				return ABI{}, err
+			}
+			a.Events[sig] = ev
+		case "error":
+			er := Error{Name: e.Name, Inputs: e.Inputs}
+			sig, err := er.Signature()
+			if err != nil {
+				return ABI{}, err
+			}
+			a.Errors[sig] = er
+		case "function", "":
+			// Type defaults to function.
+			f.Type = "function"
+			sig, err := f.Signature()
+			if err != nil {
+				return ABI{}, err
+			}
+			a.Functions[sig] = f
+			a.selectors[hex.EncodeToString(f.Id())] = sig
+		default:
+			return ABI{}, fmt.Errorf("unknown abi entry type %q", e.Type)
+		}
+	}
+
+	return a, nil
+}
+
+// MarshalJSON encodes the ABI in the JSON format which NewABI parses.
+// Entries are sorted by kind and signature.
+func (a ABI) MarshalJSON() ([]byte, error) {
+	type entry struct {
+		Type            string  `json:"type"`
+		Name            string  `json:"name,omitempty"`
+		Inputs          []Param `json:"inputs"`
+		Outputs         []Param `json:"outputs,omitempty"`
+		StateMutability string  `json:"stateMutability,omitempty"`
+		Anonymous       *bool   `json:"anonymous,omitempty"`
+	}
+	params := func(ps []Param) []Param {
+		if ps == nil {
+			return []Param{}
+		}
+		return ps
+	}
+	function := func(f Function) entry {
+		e := entry{Type: f.Type, Name: f.Name, Inputs: params(f.Inputs), StateMutability: f.StateMutability}
+		if f.Type == "function" {
+			e.Outputs = params(f.Outputs)
+		}
+		return e
+	}
+
+	entries := []entry{}
+	for _, f := range []*Function{a.Constructor, a.Fallback, a.Receive} {
+		if f != nil {
+			entries = append(entries, function(*f))
+		}
+	}
+	for _, sig := range sortedKeys(a.Functions) {
+		entries = append(entries, function(a.Functions[sig]))
+	}
+	for _, sig := range sortedKeys(a.Events) {
+		ev := a.Events[sig]
+		anonymous := ev.Anonymous
+		entries = append(entries, entry{Type: "event", Name: ev.Name, Inputs: params(ev.Inputs), Anonymous: &anonymous})
+	}
+	for _, sig := range sortedKeys(a.Errors) {
+		er := a.Errors[sig]
+		entries = append(entries, entry{Type: "error", Name: er.Name, Inputs: params(er.Inputs)})
+	}
+
+	return json.Marshal(entries)
+}
+
+// sortedKeys returns the keys of a map keyed by signature, sorted.
+func sortedKeys(m interface{}) []string {
+	keys := reflect.ValueOf(m).MapKeys()
+	s := make([]string, len(keys))
+	for i, k := range keys {
+		s[i] = k.String()
+	}
+	sort.Strings(s)
+	return s
+}
+
+// Function returns the function to call for name and args.  name is either a
+// signature such as "transfer(address,uint256)", or a function name, in
+// which case overloads are resolved by which one args can be encoded for.
+func (a ABI) Function(name string, args ...interface{}) (Function, error) {
+	candidates, err := a.lookup(name)
+	if err != nil {
+		return Function{}, err
+	}
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+
+	var matches []Function
+	for _, f := range candidates {
+		if len(f.Inputs) != len(args) {
+			continue
+		}
+		if _, err := f.Encode(args...); err == nil {
+			matches = append(matches, f)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return Function{}, fmt.Errorf("no overload of %s matches args %v", name, args)
+	case 1:
+		return matches[0], nil
+	default:
+		return Function{}, fmt.Errorf("args %v match several overloads of %s: %s", args, name, signatures(matches))
+	}
+}
+
+// Overloads returns all functions with the given name.
+func (a ABI) Overloads(name string) []Function {
+	var fs []Function
+	for _, f := range a.Functions {
+		if f.Name == name {
+			fs = append(fs, f)
+		}
+	}
+	return fs
+}
+
+// FunctionBySelector returns the function with the given 4 byte selector.
+func (a ABI) FunctionBySelector(sel []byte) (Function, bool) {
+	if a.selectors != nil {
+		f, ok := a.Functions[a.selectors[hex.EncodeToString(sel)]]
+		return f, ok
+	}
+
+	for _, f := range a.Functions {
+		if string(f.Id()) == string(sel) {
+			return f, true
+		}
+	}
+	return Function{}, false
+}
+
+// lookup returns the functions which name, a function name or signature,
+// refers to.
+func (a ABI) lookup(name string) ([]Function, error) {
+	if strings.Contains(name, "(") {
+		f, ok := a.Functions[name]
+		if !ok {
+			return nil, fmt.Errorf("no function %s in abi", name)
+		}
+		return []Function{f}, nil
+	}
+
+	fs := a.Overloads(name)
+	if len(fs) == 0 {
+		return nil, fmt.Errorf("no function %q in abi", name)
+	}
+	return fs, nil
+}
+
+func signatures(fs []Function) string {
+	s := make([]string, len(fs))
+	for i, f := range fs {
+		s[i], _ = f.Signature()
+	}
+	return strings.Join(s, ", ")
+}
+
+// signature returns the canonical signature for name and params.
+func signature(name string, params []Param) (string, error) {
+	types, err := paramTypes(params)
+	if err != nil {
+		return "", err
+	}
+
+	s := make([]string, len(types))
+	for i, t := range types {
+		s[i] = t.String()
+	}
+	return name + "(" + strings.Join(s, ",") + ")", nil
+}
+
+// Signature returns the canonical signature of the function, such as
+// "transfer(address,uint256)".
+func (f Function) Signature() (string, error) {
+	return signature(f.Name, f.Inputs)
+}
+
+// Id returns the 4 byte selector of the function, or nil if the function has
+// an invalid signature.
+func (f Function) Id() []byte {
+	sig, err := f.Signature()
+	if err != nil {
+		return nil
+	}
+	return crypto.Keccak256([]byte(sig))[:4]
+}
+
+// Encode returns the call data for calling the function with the given args:
+// the function's selector followed by the ABI encoded args.
+func (f Function) Encode(args ...interface{}) ([]byte, error) {
+	types, err := paramTypes(f.Inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	enc, err := EncodeArgs(types, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", f.Name, err)
+	}
+	return append(f.Id(), enc...), nil
+}
+
+// Decode decodes the return data of the function into v.  See DecodeArgs for
+// the supported types of v.
+func (f Function) Decode(data []byte, v interface{}) error {
+	return decodeParams(f.Outputs, data, v)
+}
+
+// Signature returns the canonical signature of the event, such as
+// "Transfer(address,address,uint256)".
+func (e Event) Signature() (string, error) {
+	return signature(e.Name, e.Inputs)
+}
+
+// Signature returns the canonical signature of the error, such as
+// "InsufficientBalance(uint256,uint256)".
+func (e Error) Signature() (string, error) {
+	return signature(e.Name, e.Inputs)
+}
+
+// decodeParams decodes ABI encoded values of the given params into v.
+func decodeParams(params []Param, data []byte, v interface{}) error {
+	types, err := paramTypes(params)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, len(params))
+	for i, p := range params {
+		names[i] = p.Name
+	}
+
+	return DecodeArgs(types, names, data, v)
+}
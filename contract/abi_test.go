@@ -0,0 +1,174 @@
+package contract
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"ethereum/accnt"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+const overloadedAbi = `[
+	{"inputs":[{"name":"_owner","type":"address"}],"stateMutability":"nonpayable","type":"constructor"},
+	{"stateMutability":"payable","type":"fallback"},
+	{"stateMutability":"payable","type":"receive"},
+	{"inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"id","type":"uint256"}],
+	 "name":"safeTransferFrom","outputs":[],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"id","type":"uint256"},
+	 {"name":"data","type":"bytes"}],"name":"safeTransferFrom","outputs":[],"stateMutability":"nonpayable",
+	 "type":"function"},
+	{"inputs":[{"name":"x","type":"uint256"}],"name":"set","outputs":[],"type":"function"},
+	{"inputs":[{"name":"s","type":"string"}],"name":"set","outputs":[],"type":"function"},
+	{"inputs":[{"name":"owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],
+	 "stateMutability":"view","type":"function"},
+	{"anonymous":false,"inputs":[{"indexed":true,"name":"from","type":"address"},
+	 {"indexed":true,"name":"to","type":"address"},{"indexed":false,"name":"value","type":"uint256"}],
+	 "name":"Transfer","type":"event"},
+	{"inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}],
+	 "name":"InsufficientBalance","type":"error"}
+]`
+
+func TestNewABI(t *testing.T) {
+	a, err := NewABI(overloadedAbi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.Constructor == nil || len(a.Constructor.Inputs) != 1 {
+		t.Fatalf("Unexpected constructor: %+v", a.Constructor)
+	}
+	if a.Fallback == nil || !a.Fallback.Payable {
+		t.Fatalf("Unexpected fallback: %+v", a.Fallback)
+	}
+	if a.Receive == nil {
+		t.Fatal("Expected receive function")
+	}
+
+	for _, sig := range []string{
+		"safeTransferFrom(address,address,uint256)",
+		"safeTransferFrom(address,address,uint256,bytes)",
+		"set(uint256)",
+		"set(string)",
+		"balanceOf(address)",
+	} {
+		if _, ok := a.Functions[sig]; !ok {
+			t.Fatalf("Expected function %s", sig)
+		}
+	}
+	if len(a.Functions) != 5 {
+		t.Fatalf("Expected: 5 functions, received: %d", len(a.Functions))
+	}
+
+	if f := a.Functions["balanceOf(address)"]; !f.Constant || f.StateMutability != "view" {
+		t.Fatalf("Unexpected mutability: %+v", f)
+	}
+	if f := a.Functions["set(uint256)"]; f.Constant || f.StateMutability != "nonpayable" {
+		t.Fatalf("Unexpected mutability: %+v", f)
+	}
+
+	ev, ok := a.Events["Transfer(address,address,uint256)"]
+	if !ok || !ev.Inputs[0].Indexed || ev.Inputs[2].Indexed {
+		t.Fatalf("Unexpected event: %+v", ev)
+	}
+	if _, ok := a.Errors["InsufficientBalance(uint256,uint256)"]; !ok {
+		t.Fatal("Expected error InsufficientBalance")
+	}
+
+	f, ok := a.FunctionBySelector([]byte{0x70, 0xa0, 0x82, 0x31})
+	if !ok || f.Name != "balanceOf" {
+		t.Fatalf("Unexpected function for selector: %+v", f)
+	}
+	if _, ok := a.FunctionBySelector([]byte{1, 2, 3, 4}); ok {
+		t.Fatal("Expected no function for unknown selector")
+	}
+
+	if _, err := NewABI(`[{"type":"bogus"}]`); err == nil {
+		t.Fatal("Expected error for unknown entry type")
+	}
+}
+
+func TestFunctionOverloads(t *testing.T) {
+	a, err := NewABI(overloadedAbi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr, _ := accnt.NewAddress("0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a")
+
+	var tests = []struct {
+		name     string
+		args     []interface{}
+		expected string
+	}{
+		{"safeTransferFrom", []interface{}{addr, addr, 1}, "safeTransferFrom(address,address,uint256)"},
+		{"safeTransferFrom", []interface{}{addr, addr, 1, []byte{}}, "safeTransferFrom(address,address,uint256,bytes)"},
+		{"set", []interface{}{big.NewInt(1)}, "set(uint256)"},
+		{"set", []interface{}{"one"}, "set(string)"},
+		{"set(string)", []interface{}{"one"}, "set(string)"},
+		{"balanceOf", []interface{}{addr}, "balanceOf(address)"},
+	}
+
+	for _, test := range tests {
+		f, err := a.Function(test.name, test.args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if sig, _ := f.Signature(); sig != test.expected {
+			t.Fatalf("Expected: %s, received: %s", test.expected, sig)
+		}
+	}
+
+	if _, err := a.Function("set", true); err == nil {
+		t.Fatal("Expected error for args matching no overload")
+	}
+	if _, err := a.Function("set(bool)"); err == nil {
+		t.Fatal("Expected error for unknown signature")
+	}
+	if _, err := a.Function("missing"); err == nil {
+		t.Fatal("Expected error for unknown function")
+	}
+
+	c := Contract{Abi: a}
+	data, err := c.EncodeCall("set", "one")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := hex.EncodeToString(data[:4]); h != "4ed3885e" {
+		t.Fatalf("Expected: 4ed3885e, received: %s", h)
+	}
+}
+
+func TestABIMarshalJSON(t *testing.T) {
+	a, err := NewABI(overloadedAbi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewABI(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Functions) != len(a.Functions) || len(b.Events) != 1 || len(b.Errors) != 1 || b.Constructor == nil ||
+		b.Fallback == nil || b.Receive == nil {
+		t.Fatalf("Unexpected abi: %+v", b)
+	}
+	if f := b.Functions["balanceOf(address)"]; !reflect.DeepEqual(f, a.Functions["balanceOf(address)"]) {
+		t.Fatalf("Expected: %+v, received: %+v", a.Functions["balanceOf(address)"], f)
+	}
+
+	// Encoding is stable.
+	again, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(data) {
+		t.Fatalf("Expected: %s, received: %s", data, again)
+	}
+}
@@ -0,0 +1,323 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Compiled is a contract compiled by solc, along with the compiler's other
+// output for it.
+type Compiled struct {
+	Contract
+
+	DeployedBin       []byte // runtime code, as stored on chain
+	DeployedLinkRefs  []LinkRef
+	SourceMap         string
+	DeployedSourceMap string
+	Metadata          string // JSON metadata, as hashed into the code
+}
+
+// Compiler compiles Solidity through solc's standard JSON interface.
+type Compiler struct {
+	// Solc is the path to solc.  If empty, solc is selected from SolcDir by
+	// the pragmas of the sources, or found on PATH.
+	Solc    string
+	SolcDir string // directory of solc binaries, see SolcVersions
+
+	CacheDir string // directory to cache output in, no caching if empty
+
+	Optimize   bool
+	Runs       int    // optimizer runs, 200 if zero
+	EVMVersion string // e.g. "istanbul", solc's default if empty
+	Remappings []string
+}
+
+// CompileOutput holds the contracts compiled by solc keyed by file:Name, and
+// the warnings it emitted.
+type CompileOutput struct {
+	Contracts   map[string]Compiled
+	Diagnostics []Diagnostic
+}
+
+// Diagnostic is an error or warning emitted by solc.
+type Diagnostic struct {
+	Severity  string // "error" or "warning"
+	Type      string // e.g. "TypeError", "Warning"
+	Message   string
+	Formatted string // message with the source location, as printed by solc
+
+	// Location in the source, if any.  Start and End are byte offsets.
+	File       string
+	Start, End int
+}
+
+func (d Diagnostic) String() string {
+	if d.Formatted != "" {
+		return strings.TrimSpace(d.Formatted)
+	}
+	if d.File != "" {
+		return fmt.Sprintf("%s:%d:%d: %s: %s", d.File, d.Start, d.End, d.Type, d.Message)
+	}
+	return fmt.Sprintf("%s: %s", d.Type, d.Message)
+}
+
+// CompileError is returned when solc fails with errors.  Diagnostics holds
+// the errors as well as any warnings.
+type CompileError struct {
+	Diagnostics []Diagnostic
+}
+
+func (e *CompileError) Error() string {
+	var msgs []string
+	for _, d := range e.Diagnostics {
+		if d.Severity == "error" {
+			msgs = append(msgs, d.String())
+		}
+	}
+	return "solc: " + strings.Join(msgs, "\n")
+}
+
+// StandardInput is the input of solc --standard-json.
+type StandardInput struct {
+	Language string                    `json:"language"`
+	Sources  map[string]StandardSource `json:"sources"`
+	Settings StandardSettings          `json:"settings"`
+}
+
+// StandardSource is a source file of StandardInput, given by its content or
+// by URLs to read it from.
+type StandardSource struct {
+	Content string   `json:"content,omitempty"`
+	URLs    []string `json:"urls,omitempty"`
+}
+
+// StandardSettings are the settings of StandardInput.
+type StandardSettings struct {
+	Remappings []string `json:"remappings,omitempty"`
+	Optimizer  struct {
+		Enabled bool `json:"enabled"`
+		Runs    int  `json:"runs"`
+	} `json:"optimizer"`
+	EVMVersion      string                         `json:"evmVersion,omitempty"`
+	OutputSelection map[string]map[string][]string `json:"outputSelection"`
+}
+
+// outputSelection is the output requested from solc for every contract.
+var outputSelection = map[string]map[string][]string{
+	"*": {"*": {
+		"abi",
+		"metadata",
+		"evm.bytecode.object",
+		"evm.bytecode.sourceMap",
+		"evm.bytecode.linkReferences",
+		"evm.deployedBytecode.object",
+		"evm.deployedBytecode.sourceMap",
+		"evm.deployedBytecode.linkReferences",
+	}},
+}
+
+// standardOutput is the output of solc --standard-json.
+type standardOutput struct {
+	Errors []struct {
+		SourceLocation *struct {
+			File  string
+			Start int
+			End   int
+		}
+		Type             string
+		Severity         string
+		Message          string
+		FormattedMessage string
+	}
+	Contracts map[string]map[string]struct {
+		Abi      json.RawMessage
+		Metadata string
+		Evm      struct {
+			Bytecode         bytecodeOutput
+			DeployedBytecode bytecodeOutput
+		}
+	}
+}
+
+type bytecodeOutput struct {
+	Object         string
+	SourceMap      string
+	LinkReferences map[string]map[string][]struct{ Start, Length int }
+}
+
+// Compile compiles Solidity files with solc's default settings, and returns
+// all the contracts in them keyed by file:Name.
+func Compile(filenames ...string) (map[string]Compiled, error) {
+	out, err := Compiler{}.Compile(filenames...)
+	if err != nil {
+		return nil, err
+	}
+	return out.Contracts, nil
+}
+
+// Compile compiles Solidity files.  Imports are resolved relative to the
+// importing file and through the compiler's remappings.
+func (c Compiler) Compile(filenames ...string) (CompileOutput, error) {
+	in := c.Input()
+	var dirs []string
+	for _, name := range filenames {
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			return CompileOutput{}, err
+		}
+		in.Sources[name] = StandardSource{Content: string(data)}
+
+		dir, err := filepath.Abs(filepath.Dir(name))
+		if err != nil {
+			return CompileOutput{}, err
+		}
+		dirs = append(dirs, dir)
+	}
+
+	var args []string
+	if len(dirs) > 0 {
+		args = append(args, "--allow-paths", strings.Join(dirs, ","))
+	}
+	return c.compile(in, args...)
+}
+
+// CompileSources compiles Solidity sources keyed by file name.
+func (c Compiler) CompileSources(sources map[string]string) (CompileOutput, error) {
+	in := c.Input()
+	for name, src := range sources {
+		in.Sources[name] = StandardSource{Content: src}
+	}
+	return c.compile(in)
+}
+
+// CompileStandard compiles standard JSON input as given, except that output
+// is always selected for all contracts.
+func (c Compiler) CompileStandard(in StandardInput) (CompileOutput, error) {
+	if in.Language == "" {
+		in.Language = "Solidity"
+	}
+	in.Settings.OutputSelection = outputSelection
+	return c.compile(in)
+}
+
+// Input returns standard JSON input without sources, with the settings of c.
+func (c Compiler) Input() StandardInput {
+	in := StandardInput{
+		Language: "Solidity",
+		Sources:  make(map[string]StandardSource),
+	}
+	in.Settings.Remappings = c.Remappings
+	in.Settings.Optimizer.Enabled = c.Optimize
+	in.Settings.Optimizer.Runs = c.Runs
+	if in.Settings.Optimizer.Runs == 0 {
+		in.Settings.Optimizer.Runs = 200
+	}
+	in.Settings.EVMVersion = c.EVMVersion
+	in.Settings.OutputSelection = outputSelection
+	return in
+}
+
+func runSolc(solc string, input []byte, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command(solc, append([]string{"--standard-json"}, args...)...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if stderr.Len() > 0 {
+			return nil, fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return nil, err
+	}
+	return output, nil
+}
+
+// parseStandardOutput parses the output of solc --standard-json, returning a
+// *CompileError if it holds any errors.
+func parseStandardOutput(data []byte) (CompileOutput, error) {
+	var out standardOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return CompileOutput{}, fmt.Errorf("solc: invalid output: %s", err)
+	}
+
+	var (
+		diags  []Diagnostic
+		failed bool
+	)
+	for _, e := range out.Errors {
+		d := Diagnostic{
+			Severity:  e.Severity,
+			Type:      e.Type,
+			Message:   e.Message,
+			Formatted: e.FormattedMessage,
+		}
+		if e.SourceLocation != nil {
+			d.File, d.Start, d.End = e.SourceLocation.File, e.SourceLocation.Start, e.SourceLocation.End
+		}
+		if d.Severity == "error" {
+			failed = true
+		}
+		diags = append(diags, d)
+	}
+	if failed {
+		return CompileOutput{}, &CompileError{Diagnostics: diags}
+	}
+
+	res := CompileOutput{
+		Contracts:   make(map[string]Compiled),
+		Diagnostics: diags,
+	}
+	for file, cs := range out.Contracts {
+		for name, c := range cs {
+			key := file + ":" + name
+
+			a, err := NewABI(string(c.Abi))
+			if err != nil {
+				return CompileOutput{}, fmt.Errorf("%s: %s", key, err)
+			}
+
+			bin, refs, err := decodeCode(c.Evm.Bytecode.Object, c.Evm.Bytecode.LinkReferences)
+			if err != nil {
+				return CompileOutput{}, fmt.Errorf("%s: bytecode: %s", key, err)
+			}
+			deployed, deployedRefs, err := decodeCode(c.Evm.DeployedBytecode.Object,
+				c.Evm.DeployedBytecode.LinkReferences)
+			if err != nil {
+				return CompileOutput{}, fmt.Errorf("%s: deployed bytecode: %s", key, err)
+			}
+
+			res.Contracts[key] = Compiled{
+				Contract:          Contract{Abi: a, Bin: nilIfEmpty(bin), LinkRefs: refs},
+				DeployedBin:       nilIfEmpty(deployed),
+				DeployedLinkRefs:  deployedRefs,
+				SourceMap:         c.Evm.Bytecode.SourceMap,
+				DeployedSourceMap: c.Evm.DeployedBytecode.SourceMap,
+				Metadata:          c.Metadata,
+			}
+		}
+	}
+	return res, nil
+}
+
+// Names returns the keys of contracts, sorted.
+func Names(contracts map[string]Compiled) []string {
+	names := make([]string, 0, len(contracts))
+	for name := range contracts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func nilIfEmpty(b []byte) []byte {
+	if len(b) == 0 {
+		return nil
+	}
+	return b
+}
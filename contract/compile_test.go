@@ -0,0 +1,157 @@
+package contract
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const standardOutputJSON = `{
+	"errors": [{
+		"sourceLocation": {"file": "a.sol", "start": 10, "end": 42},
+		"type": "Warning", "component": "general", "severity": "warning",
+		"message": "Unused local variable.",
+		"formattedMessage": "a.sol:2:5: Warning: Unused local variable.\n"
+	}],
+	"contracts": {
+		"a.sol": {
+			"A": {
+				"abi": [{"inputs":[],"name":"f","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"}],
+				"metadata": "{\"compiler\":{\"version\":\"0.8.4\"}}",
+				"evm": {
+					"bytecode": {"object": "6080604052", "sourceMap": "0:10:0:-:0"},
+					"deployedBytecode": {"object": "60806040", "sourceMap": "0:8:0:-:0"}
+				}
+			},
+			"I": {
+				"abi": [],
+				"metadata": "{}",
+				"evm": {"bytecode": {"object": ""}, "deployedBytecode": {"object": ""}}
+			}
+		},
+		"b.sol": {
+			"A": {"abi": [], "evm": {"bytecode": {"object": "00"}, "deployedBytecode": {"object": "00"}}}
+		}
+	}
+}`
+
+func TestParseStandardOutput(t *testing.T) {
+	out, err := parseStandardOutput([]byte(standardOutputJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"a.sol:A", "a.sol:I", "b.sol:A"}
+	if names := Names(out.Contracts); !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Expected: %v, received: %v", expected, names)
+	}
+
+	a := out.Contracts["a.sol:A"]
+	if _, err := a.Abi.Function("f"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(a.Bin, []byte{0x60, 0x80, 0x60, 0x40, 0x52}) ||
+		!reflect.DeepEqual(a.DeployedBin, []byte{0x60, 0x80, 0x60, 0x40}) {
+		t.Fatalf("Unexpected code: %x, %x", a.Bin, a.DeployedBin)
+	}
+	if a.SourceMap != "0:10:0:-:0" || a.DeployedSourceMap != "0:8:0:-:0" ||
+		a.Metadata != `{"compiler":{"version":"0.8.4"}}` {
+		t.Fatalf("Unexpected output: %+v", a)
+	}
+	if i := out.Contracts["a.sol:I"]; i.Bin != nil || i.DeployedBin != nil {
+		t.Fatalf("Expected no code for interface, received: %x", i.Bin)
+	}
+
+	expectedDiag := Diagnostic{
+		Severity:  "warning",
+		Type:      "Warning",
+		Message:   "Unused local variable.",
+		Formatted: "a.sol:2:5: Warning: Unused local variable.\n",
+		File:      "a.sol",
+		Start:     10,
+		End:       42,
+	}
+	if len(out.Diagnostics) != 1 || out.Diagnostics[0] != expectedDiag {
+		t.Fatalf("Expected: %+v, received: %+v", expectedDiag, out.Diagnostics)
+	}
+}
+
+func TestParseStandardOutputErrors(t *testing.T) {
+	_, err := parseStandardOutput([]byte(`{"errors": [
+		{"type": "Warning", "severity": "warning", "message": "Unused local variable."},
+		{"sourceLocation": {"file": "a.sol", "start": 3, "end": 5}, "type": "TypeError", "severity": "error",
+		 "message": "Undeclared identifier."}
+	]}`))
+
+	cerr, ok := err.(*CompileError)
+	if !ok {
+		t.Fatalf("Expected: *CompileError, received: %v", err)
+	}
+	if len(cerr.Diagnostics) != 2 {
+		t.Fatalf("Expected: 2 diagnostics, received: %+v", cerr.Diagnostics)
+	}
+	if msg := cerr.Error(); msg != "solc: a.sol:3:5: TypeError: Undeclared identifier." {
+		t.Fatalf("Expected: solc: a.sol:3:5: TypeError: Undeclared identifier., received: %s", msg)
+	}
+}
+
+func TestCompilerCompile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "solc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A fake solc saves its args and input and prints canned output.
+	out := filepath.Join(dir, "out.json")
+	if err := ioutil.WriteFile(out, []byte(standardOutputJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+	solc := filepath.Join(dir, "solc")
+	script := "#!/bin/sh\necho \"$@\" > " + dir + "/args\ncat > " + dir + "/in.json\ncat " + out + "\n"
+	if err := ioutil.WriteFile(solc, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	src := filepath.Join(dir, "a.sol")
+	if err := ioutil.WriteFile(src, []byte("contract A {}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := Compiler{
+		Solc:       solc,
+		Optimize:   true,
+		EVMVersion: "london",
+		Remappings: []string{"lib/=node_modules/lib/"},
+	}
+	res, err := c.Compile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Contracts) != 3 {
+		t.Fatalf("Expected: 3 contracts, received: %v", Names(res.Contracts))
+	}
+
+	args, _ := ioutil.ReadFile(filepath.Join(dir, "args"))
+	if expected := "--standard-json --allow-paths " + dir; strings.TrimSpace(string(args)) != expected {
+		t.Fatalf("Expected: %s, received: %s", expected, args)
+	}
+
+	data, _ := ioutil.ReadFile(filepath.Join(dir, "in.json"))
+	var in StandardInput
+	if err := json.Unmarshal(data, &in); err != nil {
+		t.Fatal(err)
+	}
+	expected := c.Input()
+	expected.Sources[src] = StandardSource{Content: "contract A {}"}
+	if !reflect.DeepEqual(in, expected) {
+		t.Fatalf("Expected: %+v, received: %+v", expected, in)
+	}
+	if !in.Settings.Optimizer.Enabled || in.Settings.Optimizer.Runs != 200 {
+		t.Fatalf("Unexpected optimizer settings: %+v", in.Settings.Optimizer)
+	}
+}
@@ -1,185 +1,100 @@
 package contract
 
 import (
-	"bytes"
-	"encoding/binary"
-	"encoding/hex"
-	"encoding/json"
-	"errors"
-	"ethereum/accnt"
 	"ethereum/txn"
-	"os/exec"
-	"reflect"
-
-	"github.com/ethereum/go-ethereum/crypto"
+	"fmt"
+	"strings"
 )
 
 type Contract struct {
-	Abi     map[string]Function
+	Abi     ABI
 	Address string
 	Bin     []byte
-}
-
-type Function struct {
-	Type     string
-	Name     string
-	Inputs   []Param
-	Outputs  []Param
-	Constant bool
-	Payable  bool
-}
-
-func (f Function) Id() []byte {
-	// TODO very naive implementation only works with function with no args
-	return crypto.Keccak256([]byte(f.Name + "()"))[:4]
-}
 
-type Param struct {
-	Name string
-	Type string
+	// LinkRefs holds the libraries which Bin must be linked with.
+	LinkRefs []LinkRef
 }
 
 func New(abi, address string) (Contract, error) {
-	funcs := make([]Function, 0)
-	if err := json.Unmarshal([]byte(abi), &funcs); err != nil {
+	a, err := NewABI(abi)
+	if err != nil {
 		return Contract{}, err
 	}
 
-	c := Contract{
+	return Contract{
 		Address: address,
-		Abi:     make(map[string]Function),
-	}
-	for _, function := range funcs {
-		c.Abi[function.Name] = function
-	}
-
-	return c, nil
+		Abi:     a,
+	}, nil
 }
 
-func Compile(filename string) (Contract, error) {
-	// Contract binary
-	cmdOut, err := exec.Command("solc",
-		"--combined-json", "abi,bin",
-		filename,
-	).Output()
-	if err != nil {
-		return Contract{}, err
+// Deploy sets the transaction's data to the contract's code followed by the
+// ABI encoded constructor args.
+func (c Contract) Deploy(t *txn.Transaction, args ...interface{}) error {
+	if libs := c.Libraries(); len(libs) > 0 {
+		return fmt.Errorf("contract is not linked with libraries %s", strings.Join(libs, ", "))
 	}
 
-	// Extract abi/bin from solc output
-	var data struct {
-		Contracts map[string]struct {
-			Abi string
-			Bin string
-		}
-		Version string
-	}
-	if err := json.Unmarshal(cmdOut, &data); err != nil {
-		return Contract{}, err
+	var inputs []Param
+	if c.Abi.Constructor != nil {
+		inputs = c.Abi.Constructor.Inputs
 	}
 
-	// Take first contract output.
-	for _, contract := range data.Contracts {
-		cont, err := New(contract.Abi, "")
-		if err != nil {
-			return Contract{}, err
-		}
-
-		bin, err := hex.DecodeString(contract.Bin)
-		if err != nil {
-			return Contract{}, err
-		}
-		cont.Bin = bin
+	types, err := paramTypes(inputs)
+	if err != nil {
+		return err
+	}
 
-		return cont, nil
+	enc, err := EncodeArgs(types, args...)
+	if err != nil {
+		return fmt.Errorf("constructor: %s", err)
 	}
 
-	return Contract{}, errors.New("no contract in solc output")
+	t.Data = append(append([]byte{}, c.Bin...), enc...)
+	return nil
 }
 
-func (c Contract) Deploy(t *txn.Transaction, args ...interface{}) error {
-	t.Data = c.Bin
-
-	constructor := c.Abi[""]
-	if len(args) != len(constructor.Inputs) {
-		return errors.New("not enough args")
-	}
+// Call sets the transaction's data to call the named function with args, and
+// its recipient to the contract if not set.  funcName may be a function name
+// or, to pick an overload explicitly, a signature.  The transaction's value
+// must be zero unless the function is payable.
+func (c Contract) Call(funcName string, t *txn.Transaction, args ...interface{}) error {
+	return c.Transact(CallOpts{}, funcName, t, args...)
+}
 
-	for i, in := range constructor.Inputs {
-		switch in.Type {
-		case "address":
-			a, ok := args[i].(accnt.Address)
-			if !ok {
-				return errors.New("wrong type")
-			}
-			arg := append(make([]byte, 12), a...)
-			t.Data = append(t.Data, arg...)
-		}
+// EncodeCall returns the call data for calling the named function with args.
+// Overloaded functions are resolved by the types of args.
+func (c Contract) EncodeCall(funcName string, args ...interface{}) ([]byte, error) {
+	f, err := c.Abi.Function(funcName, args...)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return f.Encode(args...)
 }
 
-func (c Contract) Call(funcName string, t *txn.Transaction) error {
-	t.Data = c.Abi[funcName].Id()
-	return nil
-}
-
-// TODO for now, only unmarshals into a string
+// UnmarshalResponse decodes the return data of the named function into v.
+// See DecodeArgs for the supported types of v.  Overloaded functions must be
+// named by signature unless all overloads have the same outputs.
 func (c Contract) UnmarshalResponse(funcName string, resp []byte, v interface{}) error {
-	rv := reflect.ValueOf(v)
-	if rv.Kind() != reflect.Ptr || rv.IsNil() {
-		return errors.New("invalid input")
+	fs, err := c.Abi.lookup(funcName)
+	if err != nil {
+		return err
 	}
 
-	outputs := c.Abi[funcName].Outputs
-	if len(outputs) > 1 {
-		return errors.New("only supporting single output unmarshaling")
+	for _, f := range fs[1:] {
+		if !sameTypes(f.Outputs, fs[0].Outputs) {
+			return fmt.Errorf("%s is overloaded with different outputs: %s", funcName, signatures(fs))
+		}
 	}
 
-	e := rv.Elem()
-
-	// Parse response
-	buf := bytes.NewBuffer(resp)
-	for _, output := range outputs {
-		r := make([]byte, 32)
-		if n, err := buf.Read(r); n != 32 {
-			return errors.New("not enough bytes in resp")
-		} else if err != nil {
-			return errors.New("can't read bytes")
-		}
+	return fs[0].Decode(resp, v)
+}
 
-		switch output.Type {
-		case "string":
-			// Next 32 of resp will show location of the string.
-			// TODO: naive, what if loc exceeds uint64
-			loc_64 := binary.BigEndian.Uint64(r[24:])
-
-			// Take 32 bytes from location, and that is length.
-			// TODO: also, limited to uint64 here
-			stringLength := resp[loc_64 : loc_64+32]
-			stringLength_64 := binary.BigEndian.Uint64(stringLength[24:])
-
-			stringOut := resp[loc_64+32 : loc_64+32+stringLength_64]
-
-			if e.Kind() != reflect.String {
-				return errors.New("expected value kind string")
-			}
-			e.SetString(string(stringOut))
-		case "address":
-			if e.Kind() != reflect.Slice {
-				return errors.New("expected value kind address")
-			}
-			e.SetBytes(r[12:])
-		case "uint256":
-			if e.Kind() != reflect.Uint64 {
-				return errors.New("expected value kind uint64")
-			}
-			e.SetUint(binary.BigEndian.Uint64(r[24:]))
-		default:
-			return errors.New("unsupported output type")
-		}
+func sameTypes(a, b []Param) bool {
+	sa, err := signature("", a)
+	if err != nil {
+		return false
 	}
-
-	return nil
+	sb, err := signature("", b)
+	return err == nil && sa == sb
 }
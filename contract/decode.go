@@ -0,0 +1,336 @@
+package contract
+
+import (
+	"encoding/hex"
+	"errors"
+	"ethereum/accnt"
+	"fmt"
+	"math/big"
+	"reflect"
+)
+
+var (
+	errShortData      = errors.New("abi: data too short")
+	errInvalidOffset  = errors.New("abi: offset out of bounds")
+	errInvalidLength  = errors.New("abi: length out of bounds")
+	interfaceListType = reflect.TypeOf([]interface{}{})
+)
+
+// DecodeArgs decodes ABI encoded values of the given types, such as the
+// return values of a function, into v, which must be a non-nil pointer.
+//
+// If there is a single type, v points to a value of that type.  Otherwise v
+// points to a struct, whose fields are matched to the values by name (see
+// below) or, for unnamed values, by position, or to a slice.  A
+// *[]interface{} always receives one element per type.
+//
+// Integers decode into Go integers of sufficient size, big.Int or *big.Int;
+// address into accnt.Address, [20]byte or string; bytesN into []byte or
+// [N]byte; bytes into []byte; string into string; arrays into slices or
+// arrays; and tuples into structs, whose fields are matched by an `abi:"name"`
+// tag or by name ignoring case and leading underscores.  Any type decodes
+// into an interface{}, yielding *big.Int, bool, accnt.Address, []byte, string
+// or []interface{} values.
+//
+// Offsets and lengths in data are checked, so malformed data results in an
+// error rather than a panic.
+func DecodeArgs(types []Type, names []string, data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("abi: decode target must be a non-nil pointer")
+	}
+
+	tuple := Type{Kind: TupleKind, Components: types, ComponentNames: names}
+	if len(tuple.ComponentNames) != len(types) {
+		tuple.ComponentNames = make([]string, len(types))
+	}
+
+	vals, err := tuple.decode(data)
+	if err != nil {
+		return err
+	}
+
+	if len(types) == 1 && rv.Elem().Type() != interfaceListType {
+		return setValue(rv.Elem(), types[0], vals.([]interface{})[0])
+	}
+	return setValue(rv.Elem(), tuple, vals)
+}
+
+// decode decodes a value of type t, which starts at the beginning of data.
+// Dynamic values are located relative to the start of their enclosing tuple,
+// which the caller has resolved.
+func (t Type) decode(data []byte) (interface{}, error) {
+	switch t.Kind {
+	case UintKind, IntKind:
+		w, err := readWord(data, 0)
+		if err != nil {
+			return nil, err
+		}
+		i := new(big.Int).SetBytes(w)
+		if t.Kind == IntKind && w[0]&0x80 != 0 {
+			i.Sub(i, tt256)
+		}
+		if err := t.checkRange(i); err != nil {
+			return nil, fmt.Errorf("abi: %s", err)
+		}
+		return i, nil
+	case BoolKind:
+		w, err := readWord(data, 0)
+		if err != nil {
+			return nil, err
+		}
+		i := new(big.Int).SetBytes(w)
+		if i.BitLen() > 1 {
+			return nil, fmt.Errorf("abi: invalid bool %s", i)
+		}
+		return i.Sign() == 1, nil
+	case AddressKind:
+		w, err := readWord(data, 0)
+		if err != nil {
+			return nil, err
+		}
+		return accnt.Address(append([]byte{}, w[12:]...)), nil
+	case FixedBytesKind:
+		w, err := readWord(data, 0)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{}, w[:t.Size]...), nil
+	case BytesKind, StringKind:
+		n, err := length(data, 0, 1)
+		if err != nil {
+			return nil, err
+		}
+		b := append([]byte{}, data[32:32+n]...)
+		if t.Kind == StringKind {
+			return string(b), nil
+		}
+		return b, nil
+	case SliceKind:
+		n, err := length(data, 0, t.Elem.headSize())
+		if err != nil {
+			return nil, err
+		}
+		return decodeSequence(repeat(*t.Elem, n), data[32:])
+	case ArrayKind:
+		return decodeSequence(repeat(*t.Elem, t.Size), data)
+	case TupleKind:
+		return decodeSequence(t.Components, data)
+	default:
+		return nil, fmt.Errorf("abi: cannot decode %s", t)
+	}
+}
+
+// decodeSequence decodes a tuple of the given types.  The head of each
+// dynamic value is its offset from the start of the tuple.
+func decodeSequence(types []Type, data []byte) ([]interface{}, error) {
+	vals := make([]interface{}, len(types))
+	pos := 0
+	for i, t := range types {
+		start := pos
+		if t.IsDynamic() {
+			off, err := offset(data, pos)
+			if err != nil {
+				return nil, err
+			}
+			start = off
+		}
+
+		v, err := t.decode(data[start:])
+		if err != nil {
+			return nil, err
+		}
+		vals[i] = v
+		pos += t.headSize()
+	}
+	return vals, nil
+}
+
+func repeat(t Type, n int) []Type {
+	types := make([]Type, n)
+	for i := range types {
+		types[i] = t
+	}
+	return types
+}
+
+// readWord returns the 32 byte word at pos.
+func readWord(data []byte, pos int) ([]byte, error) {
+	if pos < 0 || pos+32 > len(data) {
+		return nil, errShortData
+	}
+	return data[pos : pos+32], nil
+}
+
+// offset reads the offset at pos, and checks that it lies within data.
+func offset(data []byte, pos int) (int, error) {
+	w, err := readWord(data, pos)
+	if err != nil {
+		return 0, err
+	}
+
+	o := new(big.Int).SetBytes(w)
+	if !o.IsInt64() || o.Int64() > int64(len(data)) {
+		return 0, errInvalidOffset
+	}
+	return int(o.Int64()), nil
+}
+
+// length reads the length of a dynamic value at pos, and checks that data
+// after the length word holds at least length elements of elemSize bytes.
+func length(data []byte, pos int, elemSize int) (int, error) {
+	w, err := readWord(data, pos)
+	if err != nil {
+		return 0, err
+	}
+
+	l := new(big.Int).SetBytes(w)
+	avail := int64(len(data) - pos - 32)
+	if !l.IsInt64() || l.Int64() > avail || l.Int64()*int64(elemSize) > avail {
+		return 0, errInvalidLength
+	}
+	return int(l.Int64()), nil
+}
+
+// setValue stores a decoded value of type t into dst.
+func setValue(dst reflect.Value, t Type, src interface{}) error {
+	if dst.Kind() == reflect.Interface && dst.NumMethod() == 0 {
+		dst.Set(reflect.ValueOf(src))
+		return nil
+	}
+
+	if dst.Kind() == reflect.Ptr {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		if dst.Type().Elem() == bigIntType {
+			i, ok := src.(*big.Int)
+			if !ok {
+				return typeError(t, dst)
+			}
+			dst.Elem().Set(reflect.ValueOf(*i))
+			return nil
+		}
+		return setValue(dst.Elem(), t, src)
+	}
+
+	switch t.Kind {
+	case UintKind, IntKind:
+		i := src.(*big.Int)
+		switch dst.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if i.Sign() < 0 || !i.IsUint64() || dst.OverflowUint(i.Uint64()) {
+				return fmt.Errorf("abi: %s overflows %s", i, dst.Type())
+			}
+			dst.SetUint(i.Uint64())
+			return nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if !i.IsInt64() || dst.OverflowInt(i.Int64()) {
+				return fmt.Errorf("abi: %s overflows %s", i, dst.Type())
+			}
+			dst.SetInt(i.Int64())
+			return nil
+		case reflect.Struct:
+			if dst.Type() == bigIntType {
+				dst.Set(reflect.ValueOf(*i))
+				return nil
+			}
+		}
+	case BoolKind:
+		if dst.Kind() == reflect.Bool {
+			dst.SetBool(src.(bool))
+			return nil
+		}
+	case AddressKind, FixedBytesKind, BytesKind:
+		b := []byte(nil)
+		switch s := src.(type) {
+		case accnt.Address:
+			b = s
+		case []byte:
+			b = s
+		}
+
+		switch {
+		case dst.Kind() == reflect.Slice && dst.Type().Elem().Kind() == reflect.Uint8:
+			dst.SetBytes(append([]byte{}, b...))
+			return nil
+		case dst.Kind() == reflect.Array && dst.Type().Elem().Kind() == reflect.Uint8 && dst.Len() == len(b):
+			reflect.Copy(dst, reflect.ValueOf(b))
+			return nil
+		case dst.Kind() == reflect.String && t.Kind == AddressKind:
+			dst.SetString("0x" + hex.EncodeToString(b))
+			return nil
+		}
+	case StringKind:
+		if dst.Kind() == reflect.String {
+			dst.SetString(src.(string))
+			return nil
+		}
+	case SliceKind, ArrayKind:
+		return setList(dst, *t.Elem, src.([]interface{}), t)
+	case TupleKind:
+		return setTuple(dst, t, src.([]interface{}))
+	}
+
+	return typeError(t, dst)
+}
+
+// setList stores the elements of an array, slice, or tuple of identical
+// types, into a Go slice or array.
+func setList(dst reflect.Value, elem Type, vals []interface{}, t Type) error {
+	switch dst.Kind() {
+	case reflect.Slice:
+		dst.Set(reflect.MakeSlice(dst.Type(), len(vals), len(vals)))
+	case reflect.Array:
+		if dst.Len() != len(vals) {
+			return typeError(t, dst)
+		}
+	default:
+		return typeError(t, dst)
+	}
+
+	for i, v := range vals {
+		if err := setValue(dst.Index(i), elem, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setTuple stores a tuple into a struct, or into a slice or array.
+func setTuple(dst reflect.Value, t Type, vals []interface{}) error {
+	switch dst.Kind() {
+	case reflect.Struct:
+		for i, name := range t.ComponentNames {
+			f, ok := structField(dst, name)
+			if !ok && name == "" && i < dst.NumField() {
+				// Unnamed values are matched by position.
+				f, ok = dst.Field(i), dst.Type().Field(i).PkgPath == ""
+			}
+			if !ok {
+				return fmt.Errorf("abi: %s has no field for %q", dst.Type(), name)
+			}
+			if err := setValue(f, t.Components[i], vals[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Slice, reflect.Array:
+		if dst.Kind() == reflect.Slice {
+			dst.Set(reflect.MakeSlice(dst.Type(), len(vals), len(vals)))
+		} else if dst.Len() != len(vals) {
+			return typeError(t, dst)
+		}
+		for i, v := range vals {
+			if err := setValue(dst.Index(i), t.Components[i], v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return typeError(t, dst)
+}
+
+func typeError(t Type, dst reflect.Value) error {
+	return fmt.Errorf("abi: cannot decode %s into %s", t, dst.Type())
+}
@@ -0,0 +1,219 @@
+package contract
+
+import (
+	"encoding/hex"
+	"ethereum/accnt"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func mustTypes(t *testing.T, in ...string) []Type {
+	types := make([]Type, len(in))
+	for i, s := range in {
+		typ, err := NewType(s, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		types[i] = typ
+	}
+	return types
+}
+
+func mustDecodeHex(t *testing.T, h string) []byte {
+	b, err := hex.DecodeString(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestDecodeArgsSingle(t *testing.T) {
+	addr, _ := accnt.NewAddress("0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a")
+	maxUint, _ := new(big.Int).SetString(strings.Repeat("f", 64), 16)
+
+	var tests = []struct {
+		typ      string
+		value    interface{}
+		target   interface{} // pointer to decode into
+		expected interface{}
+	}{
+		{"uint256", maxUint, new(*big.Int), maxUint},
+		{"uint256", big.NewInt(5), new(big.Int), *big.NewInt(5)},
+		{"uint64", uint64(1 << 63), new(uint64), uint64(1 << 63)},
+		{"int8", -5, new(int8), int8(-5)},
+		{"int256", big.NewInt(-1), new(*big.Int), big.NewInt(-1)},
+		{"bool", true, new(bool), true},
+		{"address", addr, new(accnt.Address), addr},
+		{"address", addr, new(string), "0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a"},
+		{"address", addr, new([20]byte), [20]byte{0x19, 0xe7, 0xe3, 0x76, 0xe7, 0xc2, 0x13, 0xb7, 0xe7, 0xe7, 0xe4, 0x6c, 0xc7,
+			0x0a, 0x5d, 0xd0, 0x86, 0xda, 0xff, 0x2a}},
+		{"bytes4", []byte{1, 2, 3, 4}, new([4]byte), [4]byte{1, 2, 3, 4}},
+		{"bytes4", []byte{1, 2, 3, 4}, new([]byte), []byte{1, 2, 3, 4}},
+		{"bytes", []byte("hello"), new([]byte), []byte("hello")},
+		{"string", "hello", new(string), "hello"},
+		{"string", "hello", new(interface{}), "hello"},
+		{"uint8[]", []uint8{1, 2}, new([]uint8), []uint8{1, 2}},
+		{"uint8[2]", []uint8{1, 2}, new([2]int), [2]int{1, 2}},
+		{"uint[][]", [][]int{{1, 2}, {3}}, new([][]uint64), [][]uint64{{1, 2}, {3}}},
+		{"string[]", []string{"one", "two", "three"}, new([]string), []string{"one", "two", "three"}},
+		{"int8[]", []int{-1}, new(interface{}), []interface{}{big.NewInt(-1)}},
+	}
+
+	for _, test := range tests {
+		types := mustTypes(t, test.typ)
+		data, err := EncodeArgs(types, test.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := DecodeArgs(types, nil, data, test.target); err != nil {
+			t.Fatalf("%s: %s", test.typ, err)
+		}
+
+		if v := reflect.ValueOf(test.target).Elem().Interface(); !reflect.DeepEqual(v, test.expected) {
+			t.Fatalf("%s: Expected: %#v, received: %#v", test.typ, test.expected, v)
+		}
+	}
+}
+
+func TestDecodeArgsMultiple(t *testing.T) {
+	types := mustTypes(t, "uint256", "string", "bool")
+	data, err := EncodeArgs(types, 7, "seven", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// By name, ignoring case and leading underscores, or by tag.
+	var named struct {
+		Count *big.Int
+		Label string `abi:"_name"`
+		Ok    bool
+	}
+	if err := DecodeArgs(types, []string{"_count", "_name", "ok"}, data, &named); err != nil {
+		t.Fatal(err)
+	}
+	if named.Count.Int64() != 7 || named.Label != "seven" || !named.Ok {
+		t.Fatalf("Unexpected result: %+v", named)
+	}
+
+	// Unnamed values by position.
+	var positional struct {
+		A uint8
+		B string
+		C bool
+	}
+	if err := DecodeArgs(types, []string{"", "", ""}, data, &positional); err != nil {
+		t.Fatal(err)
+	}
+	if positional.A != 7 || positional.B != "seven" || !positional.C {
+		t.Fatalf("Unexpected result: %+v", positional)
+	}
+
+	var list []interface{}
+	if err := DecodeArgs(types, nil, data, &list); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []interface{}{big.NewInt(7), "seven", true}; !reflect.DeepEqual(list, expected) {
+		t.Fatalf("Expected: %#v, received: %#v", expected, list)
+	}
+
+	// A single value can also be decoded into a list.
+	list = nil
+	if err := DecodeArgs(types[:1], nil, data[:32], &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("Expected: 1 value, received: %d", len(list))
+	}
+}
+
+func TestDecodeArgsTuple(t *testing.T) {
+	tuple, err := NewType("tuple[]", []Param{
+		{Name: "id", Type: "uint256"},
+		{Name: "tags", Type: "string[]"},
+		{Name: "owner", Type: "tuple", Components: []Param{{Name: "addr", Type: "address"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type owner struct {
+		Addr accnt.Address
+	}
+	type item struct {
+		ID    uint64
+		Tags  []string
+		Owner owner
+	}
+
+	addr, _ := accnt.NewAddress("0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a")
+	in := []item{
+		{ID: 1, Tags: []string{"a", "b"}, Owner: owner{addr}},
+		{ID: 2, Tags: []string{}, Owner: owner{addr}},
+	}
+
+	data, err := EncodeArgs([]Type{tuple}, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out []item
+	if err := DecodeArgs([]Type{tuple}, nil, data, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(in, out) {
+		t.Fatalf("Expected: %+v, received: %+v", in, out)
+	}
+}
+
+func TestDecodeArgsErrors(t *testing.T) {
+	var tests = []struct {
+		name   string
+		types  []string
+		data   string
+		target interface{}
+	}{
+		{"short", []string{"uint256"}, word("1")[:62], new(*big.Int)},
+		{"huge offset", []string{"string"}, word("ffffffffffffffffffffffffffffffff"), new(string)},
+		{"offset past end", []string{"string"}, word("40"), new(string)},
+		{"huge length", []string{"bytes"}, word("20") + word("ffffffffffffffff"), new([]byte)},
+		{"length past end", []string{"bytes"}, word("20") + word("21") + text("a"), new([]byte)},
+		{"huge array", []string{"uint256[]"}, word("20") + word("1000000"), new([]*big.Int)},
+		{"invalid bool", []string{"bool"}, word("2"), new(bool)},
+		{"uint8 out of range", []string{"uint8"}, word("100"), new(uint8)},
+		{"overflows target", []string{"uint256"}, word("100"), new(uint8)},
+		{"wrong target", []string{"string"}, word("20") + word("1") + text("a"), new(int)},
+	}
+
+	for _, test := range tests {
+		err := DecodeArgs(mustTypes(t, test.types...), nil, mustDecodeHex(t, test.data), test.target)
+		if err == nil {
+			t.Fatalf("%s: Expected error", test.name)
+		}
+	}
+
+	if err := DecodeArgs(mustTypes(t, "uint256"), nil, make([]byte, 32), uint64(0)); err == nil {
+		t.Fatal("Expected error for non-pointer target")
+	}
+}
+
+func TestFunctionDecode(t *testing.T) {
+	f := Function{
+		Name:    "getReserves",
+		Outputs: []Param{{Name: "_reserve0", Type: "uint112"}, {Name: "_reserve1", Type: "uint112"}},
+	}
+
+	var out struct {
+		Reserve0 *big.Int
+		Reserve1 *big.Int
+	}
+	if err := f.Decode(mustDecodeHex(t, word("a")+word("b")), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Reserve0.Int64() != 10 || out.Reserve1.Int64() != 11 {
+		t.Fatalf("Unexpected result: %+v", out)
+	}
+}
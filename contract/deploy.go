@@ -0,0 +1,144 @@
+package contract
+
+import (
+	"errors"
+	"ethereum/accnt"
+	"ethereum/txn"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+var (
+	// ErrDeployFailed is returned when the deploy transaction is mined but
+	// fails, e.g. because the constructor reverted.
+	ErrDeployFailed = errors.New("contract deployment failed")
+
+	// ErrNoCode is returned when the deploy transaction succeeds but leaves
+	// no code at the contract's address.
+	ErrNoCode = errors.New("no code at contract address after deployment")
+
+	// ErrNotFound is returned by a Backend for the receipt of a transaction
+	// which isn't mined.
+	ErrNotFound = errors.New("not found")
+)
+
+// Backend is the chain which DeployAndWait deploys contracts to.
+// client.Client implements it.  GetTransactionReceipt returns ErrNotFound
+// for a transaction which isn't mined.
+type Backend interface {
+	GetTransactionCount(addr accnt.Address) (uint64, error)
+	GasPrice() (*big.Int, error)
+	EstimateGas(from accnt.Address, t txn.Transaction) (uint64, error)
+	SendTransaction(t txn.Transaction) (string, error)
+	GetTransactionReceipt(hash string) (txn.TransactionReceipt, error)
+	GetCode(addr string) ([]byte, error)
+}
+
+// DeployOpts are the options of DeployAndWait.  All are optional.
+type DeployOpts struct {
+	Value    *big.Int
+	GasPrice *big.Int // the backend's suggested price if nil
+	GasLimit *big.Int // estimated if nil
+
+	PollInterval time.Duration // between receipt polls, 1s if zero
+	Timeout      time.Duration // for the transaction to be mined, 5m if zero
+}
+
+// DeployAndWait deploys c with the constructor args, signing the transaction
+// with s, and waits for it to be mined.  It returns c with its Address set,
+// along with the receipt of the transaction.  If the transaction is mined but
+// fails, the error is ErrDeployFailed or ErrNoCode and the receipt is returned.
+func DeployAndWait(b Backend, s txn.Signer, c Contract, opts DeployOpts, args ...interface{}) (Contract, txn.TransactionReceipt, error) {
+	ctor := Function{Type: "constructor"}
+	if c.Abi.Constructor != nil {
+		ctor = *c.Abi.Constructor
+	}
+	if err := checkValue(ctor, opts.Value); err != nil {
+		return Contract{}, txn.TransactionReceipt{}, err
+	}
+
+	var t txn.Transaction
+	if err := c.Deploy(&t, args...); err != nil {
+		return Contract{}, txn.TransactionReceipt{}, err
+	}
+	t.Value = opts.Value
+
+	from := s.Address()
+	nonce, err := b.GetTransactionCount(from)
+	if err != nil {
+		return Contract{}, txn.TransactionReceipt{}, fmt.Errorf("nonce: %s", err)
+	}
+	t.Nonce = nonce
+
+	t.GasPrice = opts.GasPrice
+	if t.GasPrice == nil {
+		if t.GasPrice, err = b.GasPrice(); err != nil {
+			return Contract{}, txn.TransactionReceipt{}, fmt.Errorf("gas price: %s", err)
+		}
+	}
+
+	t.GasLimit = opts.GasLimit
+	if t.GasLimit == nil {
+		gas, err := b.EstimateGas(from, t)
+		if err != nil {
+			return Contract{}, txn.TransactionReceipt{}, fmt.Errorf("estimate gas: %s", err)
+		}
+		t.GasLimit = new(big.Int).SetUint64(gas)
+	}
+
+	if err := s.SignTransaction(&t); err != nil {
+		return Contract{}, txn.TransactionReceipt{}, err
+	}
+
+	hash, err := b.SendTransaction(t)
+	if err != nil {
+		return Contract{}, txn.TransactionReceipt{}, err
+	}
+
+	receipt, err := waitReceipt(b, hash, opts.PollInterval, opts.Timeout)
+	if err != nil {
+		return Contract{}, txn.TransactionReceipt{}, err
+	}
+	if !receipt.Succeeded() || receipt.ContractAddress == "" {
+		return Contract{}, receipt, ErrDeployFailed
+	}
+
+	code, err := b.GetCode(receipt.ContractAddress)
+	if err != nil {
+		return Contract{}, receipt, err
+	}
+	if len(code) == 0 {
+		return Contract{}, receipt, ErrNoCode
+	}
+
+	c.Address = receipt.ContractAddress
+	return c, receipt, nil
+}
+
+// waitReceipt polls for the receipt of a transaction until it is mined.
+func waitReceipt(b Backend, hash string, interval, timeout time.Duration) (txn.TransactionReceipt, error) {
+	if interval == 0 {
+		interval = time.Second
+	}
+	if timeout == 0 {
+		timeout = 5 * time.Minute
+	}
+	deadline := time.Now().Add(timeout)
+
+	for {
+		receipt, err := b.GetTransactionReceipt(hash)
+		if err == nil {
+			return receipt, nil
+		}
+		// A pending transaction has no receipt.
+		if err != ErrNotFound {
+			return txn.TransactionReceipt{}, err
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			return txn.TransactionReceipt{}, fmt.Errorf("transaction %s not mined after %s", hash, timeout)
+		}
+		time.Sleep(interval)
+	}
+}
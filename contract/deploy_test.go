@@ -0,0 +1,162 @@
+package contract
+
+import (
+	"encoding/hex"
+	"ethereum/accnt"
+	"ethereum/txn"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeBackend mines the transaction sent to it after a number of receipt
+// polls.
+type fakeBackend struct {
+	pending  int // receipt polls before the transaction is mined
+	status   uint64
+	root     string // set for pre-Byzantium receipts, which have no status
+	code     []byte
+	sent     []txn.Transaction
+	estimate txn.Transaction
+}
+
+func (b *fakeBackend) GetTransactionCount(addr accnt.Address) (uint64, error) {
+	return 7, nil
+}
+
+func (b *fakeBackend) GasPrice() (*big.Int, error) {
+	return big.NewInt(2e9), nil
+}
+
+func (b *fakeBackend) EstimateGas(from accnt.Address, t txn.Transaction) (uint64, error) {
+	b.estimate = t
+	return 90000, nil
+}
+
+func (b *fakeBackend) SendTransaction(t txn.Transaction) (string, error) {
+	b.sent = append(b.sent, t)
+	return "0x" + t.Hash(), nil
+}
+
+func (b *fakeBackend) GetTransactionReceipt(hash string) (txn.TransactionReceipt, error) {
+	if b.pending > 0 {
+		b.pending--
+		return txn.TransactionReceipt{}, ErrNotFound
+	}
+	return txn.TransactionReceipt{
+		BlockHash:       "0x8e38b4dbf6b11fcc3b9dee84fb7986e29ca0a02cecd8977c161ff7333329681e",
+		ContractAddress: "0x1563915e194d8cfba1943570603f7606a3115508",
+		Root:            b.root,
+		Status:          b.status,
+		TransactionHash: hash,
+	}, nil
+}
+
+func (b *fakeBackend) GetCode(addr string) ([]byte, error) {
+	return b.code, nil
+}
+
+func TestDeployAndWait(t *testing.T) {
+	priv, err := accnt.NewAccount("cb4aab9577130f5c4622f355e5c6c3cad2661518ac968c34e4f14a9fde071bfd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := txn.KeySigner{Private: priv}
+
+	a, err := NewABI(`[{"inputs":[{"name":"name","type":"string"},{"name":"supply","type":"uint256"},` +
+		`{"name":"owners","type":"address[]"}],"stateMutability":"nonpayable","type":"constructor"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := Contract{Abi: a, Bin: []byte{0x60, 0x80}}
+
+	owner, _ := accnt.NewAddress("0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a")
+	b := &fakeBackend{pending: 2, status: 1, code: []byte{0x60}}
+	opts := DeployOpts{PollInterval: time.Millisecond}
+
+	deployed, receipt, err := DeployAndWait(b, signer, c, opts, "Token", big.NewInt(1000), []accnt.Address{owner})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deployed.Address != "0x1563915e194d8cfba1943570603f7606a3115508" {
+		t.Fatalf("Expected: 0x1563915e194d8cfba1943570603f7606a3115508, received: %s", deployed.Address)
+	}
+	if receipt.Status != 1 || b.pending != 0 {
+		t.Fatalf("Unexpected receipt: %+v", receipt)
+	}
+
+	if len(b.sent) != 1 {
+		t.Fatalf("Expected: 1 transaction, received: %d", len(b.sent))
+	}
+	tx := b.sent[0]
+	expectedData := "6080" + word("60") + word("3e8") + word("a0") + word("5") + text("Token") + word("1") +
+		word("19e7e376e7c213b7e7e7e46cc70a5dd086daff2a")
+	if d := hex.EncodeToString(tx.Data); d != expectedData {
+		t.Fatalf("Expected: %s, received: %s", expectedData, d)
+	}
+	if tx.Nonce != 7 || tx.GasPrice.Int64() != 2e9 || tx.GasLimit.Int64() != 90000 || tx.To != "" {
+		t.Fatalf("Unexpected transaction: %+v", tx)
+	}
+	if !reflect.DeepEqual(b.estimate.Data, tx.Data) {
+		t.Fatal("Expected gas to be estimated for the deploy data")
+	}
+
+	sender, err := tx.Sender()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sender.String() != priv.Address().String() {
+		t.Fatalf("Expected: %s, received: %s", priv.Address(), sender)
+	}
+}
+
+func TestDeployAndWaitErrors(t *testing.T) {
+	priv, err := accnt.NewAccount("cb4aab9577130f5c4622f355e5c6c3cad2661518ac968c34e4f14a9fde071bfd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := txn.KeySigner{Private: priv}
+	c := Contract{Bin: []byte{0x60, 0x80}}
+
+	var tests = []struct {
+		backend  *fakeBackend
+		opts     DeployOpts
+		expected string
+	}{
+		{&fakeBackend{status: 0, code: []byte{0x60}}, DeployOpts{}, ErrDeployFailed.Error()},
+		{&fakeBackend{status: 1}, DeployOpts{}, ErrNoCode.Error()},
+		{
+			&fakeBackend{pending: 100, status: 1},
+			DeployOpts{PollInterval: time.Millisecond, Timeout: 5 * time.Millisecond},
+			"not mined",
+		},
+	}
+
+	for _, test := range tests {
+		_, _, err := DeployAndWait(test.backend, signer, c, test.opts)
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Fatalf("Expected: %s, received: %v", test.expected, err)
+		}
+	}
+
+	// Given gas settings aren't looked up.
+	b := &fakeBackend{status: 1, code: []byte{0x60}}
+	if _, _, err := DeployAndWait(b, signer, c, DeployOpts{GasPrice: big.NewInt(1), GasLimit: big.NewInt(21000)}); err != nil {
+		t.Fatal(err)
+	}
+	if b.estimate.Data != nil || b.sent[0].GasPrice.Int64() != 1 || b.sent[0].GasLimit.Int64() != 21000 {
+		t.Fatalf("Unexpected transaction: %+v", b.sent[0])
+	}
+
+	// Receipts before Byzantium hold a state root instead of a status.
+	b = &fakeBackend{root: "0xd7f8974fb5ac78d9ac099b9ad5018bedc2ce0a72dad1827a1709da30580f0544", code: []byte{0x60}}
+	if _, _, err := DeployAndWait(b, signer, c, DeployOpts{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := DeployAndWait(b, signer, c, DeployOpts{}, "unexpected"); err == nil {
+		t.Fatal("Expected error for too many constructor args")
+	}
+}
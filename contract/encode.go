@@ -0,0 +1,278 @@
+package contract
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+)
+
+var (
+	bigIntType = reflect.TypeOf(big.Int{})
+	tt256      = new(big.Int).Lsh(big.NewInt(1), 256)
+)
+
+// EncodeArgs ABI encodes values of the given types, as for the arguments of a
+// function call.
+func EncodeArgs(types []Type, args ...interface{}) ([]byte, error) {
+	if len(args) != len(types) {
+		return nil, fmt.Errorf("expected %d args, received %d", len(types), len(args))
+	}
+
+	vals := make([]reflect.Value, len(args))
+	for i, a := range args {
+		vals[i] = reflect.ValueOf(a)
+	}
+
+	return encodeTuple(types, vals)
+}
+
+// encodeTuple encodes a sequence of values: static values are written in
+// place, dynamic values are appended after all heads and referenced by their
+// offset from the start of the tuple.
+func encodeTuple(types []Type, vals []reflect.Value) ([]byte, error) {
+	headLen := 0
+	for _, t := range types {
+		headLen += t.headSize()
+	}
+
+	var head, tail []byte
+	for i, t := range types {
+		enc, err := t.encode(vals[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if !t.IsDynamic() {
+			head = append(head, enc...)
+			continue
+		}
+
+		head = append(head, encodeUint(big.NewInt(int64(headLen+len(tail))))...)
+		tail = append(tail, enc...)
+	}
+
+	return append(head, tail...), nil
+}
+
+func (t Type) encode(v reflect.Value) ([]byte, error) {
+	v = indirect(v)
+	if !v.IsValid() {
+		return nil, fmt.Errorf("cannot encode nil as %s", t)
+	}
+
+	switch t.Kind {
+	case UintKind, IntKind:
+		i, err := toBigInt(v)
+		if err != nil {
+			return nil, fmt.Errorf("cannot encode %s as %s: %s", v.Type(), t, err)
+		}
+		if err := t.checkRange(i); err != nil {
+			return nil, err
+		}
+		if i.Sign() < 0 {
+			// Two's complement.
+			i = new(big.Int).Add(tt256, i)
+		}
+		return encodeUint(i), nil
+	case BoolKind:
+		if v.Kind() != reflect.Bool {
+			return nil, fmt.Errorf("cannot encode %s as bool", v.Type())
+		}
+		if v.Bool() {
+			return encodeUint(big.NewInt(1)), nil
+		}
+		return make([]byte, 32), nil
+	case AddressKind:
+		a, err := toBytes(v)
+		if err != nil || len(a) != 20 {
+			return nil, fmt.Errorf("cannot encode %s as address", v.Type())
+		}
+		return leftPad(a), nil
+	case FixedBytesKind:
+		b, err := toBytes(v)
+		if err != nil || len(b) > t.Size {
+			return nil, fmt.Errorf("cannot encode %s as %s", v.Type(), t)
+		}
+		out := make([]byte, 32)
+		copy(out, b)
+		return out, nil
+	case BytesKind, StringKind:
+		var b []byte
+		switch {
+		case v.Kind() == reflect.String:
+			b = []byte(v.String())
+		case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+			b = v.Bytes()
+		default:
+			return nil, fmt.Errorf("cannot encode %s as %s", v.Type(), t)
+		}
+		return append(encodeUint(big.NewInt(int64(len(b)))), rightPad(b)...), nil
+	case SliceKind, ArrayKind:
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return nil, fmt.Errorf("cannot encode %s as %s", v.Type(), t)
+		}
+		if t.Kind == ArrayKind && v.Len() != t.Size {
+			return nil, fmt.Errorf("cannot encode %d elements as %s", v.Len(), t)
+		}
+
+		types := make([]Type, v.Len())
+		vals := make([]reflect.Value, v.Len())
+		for i := range vals {
+			types[i] = *t.Elem
+			vals[i] = v.Index(i)
+		}
+
+		enc, err := encodeTuple(types, vals)
+		if err != nil {
+			return nil, err
+		}
+		if t.Kind == SliceKind {
+			enc = append(encodeUint(big.NewInt(int64(v.Len()))), enc...)
+		}
+		return enc, nil
+	case TupleKind:
+		vals, err := t.tupleValues(v)
+		if err != nil {
+			return nil, err
+		}
+		return encodeTuple(t.Components, vals)
+	default:
+		return nil, fmt.Errorf("cannot encode %s", t)
+	}
+}
+
+// tupleValues returns the values of a tuple's components from either a
+// struct, whose fields are matched to components by name, or a slice of
+// values in component order.
+func (t Type) tupleValues(v reflect.Value) ([]reflect.Value, error) {
+	vals := make([]reflect.Value, len(t.Components))
+	switch v.Kind() {
+	case reflect.Struct:
+		for i, name := range t.ComponentNames {
+			f, ok := structField(v, name)
+			if !ok {
+				return nil, fmt.Errorf("%s has no field for tuple component %q", v.Type(), name)
+			}
+			vals[i] = f
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Len() != len(t.Components) {
+			return nil, fmt.Errorf("cannot encode %d values as %s", v.Len(), t)
+		}
+		for i := range vals {
+			vals[i] = v.Index(i)
+		}
+	default:
+		return nil, fmt.Errorf("cannot encode %s as %s", v.Type(), t)
+	}
+	return vals, nil
+}
+
+// structField finds the field of a struct which corresponds to an ABI name:
+// either the field tagged `abi:"name"`, or the field whose name matches
+// ignoring case and leading underscores.
+func structField(v reflect.Value, name string) (reflect.Value, bool) {
+	st := v.Type()
+	for i := 0; i < st.NumField(); i++ {
+		if tag, ok := st.Field(i).Tag.Lookup("abi"); ok && tag == name {
+			return v.Field(i), true
+		}
+	}
+
+	want := strings.TrimLeft(name, "_")
+	for i := 0; i < st.NumField(); i++ {
+		f := st.Field(i)
+		if _, ok := f.Tag.Lookup("abi"); ok || f.PkgPath != "" {
+			continue
+		}
+		if strings.EqualFold(f.Name, want) {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// checkRange returns an error if i does not fit into the integer type.
+func (t Type) checkRange(i *big.Int) error {
+	if t.Kind == UintKind {
+		if i.Sign() < 0 || i.BitLen() > t.Size {
+			return fmt.Errorf("%s out of range for %s", i, t)
+		}
+		return nil
+	}
+
+	max := new(big.Int).Lsh(big.NewInt(1), uint(t.Size-1))
+	min := new(big.Int).Neg(max)
+	if i.Cmp(min) < 0 || i.Cmp(max) >= 0 {
+		return fmt.Errorf("%s out of range for %s", i, t)
+	}
+	return nil
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Interface || (v.Kind() == reflect.Ptr && v.Type().Elem() != bigIntType)) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func toBigInt(v reflect.Value) (*big.Int, error) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return new(big.Int).SetUint64(v.Uint()), nil
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil, errors.New("nil *big.Int")
+		}
+		return v.Interface().(*big.Int), nil
+	case reflect.Struct:
+		if v.Type() == bigIntType {
+			i := v.Interface().(big.Int)
+			return &i, nil
+		}
+	}
+	return nil, errors.New("not an integer")
+}
+
+// toBytes accepts byte slices (including accnt.Address), byte arrays and
+// `0xHEX` formatted strings.
+func toBytes(v reflect.Value) ([]byte, error) {
+	switch {
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		return v.Bytes(), nil
+	case v.Kind() == reflect.Array && v.Type().Elem().Kind() == reflect.Uint8:
+		b := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(b), v)
+		return b, nil
+	case v.Kind() == reflect.String:
+		s := v.String()
+		if !strings.HasPrefix(s, "0x") {
+			return nil, errors.New("hex string must have '0x' prefix")
+		}
+		return hex.DecodeString(s[2:])
+	}
+	return nil, errors.New("not bytes")
+}
+
+func encodeUint(i *big.Int) []byte {
+	return leftPad(i.Bytes())
+}
+
+// leftPad pads b with zeros to 32 bytes on the left.
+func leftPad(b []byte) []byte {
+	return append(make([]byte, 32-len(b)), b...)
+}
+
+// rightPad pads b with zeros to a multiple of 32 bytes.
+func rightPad(b []byte) []byte {
+	n := (len(b) + 31) / 32 * 32
+	return append(append([]byte{}, b...), make([]byte, n-len(b))...)
+}
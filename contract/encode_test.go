@@ -0,0 +1,234 @@
+package contract
+
+import (
+	"encoding/hex"
+	"ethereum/accnt"
+	"ethereum/txn"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// word returns the hex encoding of a 32 byte word holding the given hex
+// number.
+func word(h string) string {
+	return strings.Repeat("0", 64-len(h)) + h
+}
+
+// text returns the hex encoding of s, right padded to a multiple of 32 bytes.
+func text(s string) string {
+	h := hex.EncodeToString([]byte(s))
+	if r := len(h) % 64; r != 0 {
+		h += strings.Repeat("0", 64-r)
+	}
+	return h
+}
+
+func TestFunctionEncode(t *testing.T) {
+	// Examples from https://solidity.readthedocs.io/en/develop/abi-spec.html
+	var tests = []struct {
+		f        Function
+		args     []interface{}
+		expected string
+	}{
+		{
+			f:        Function{Name: "baz", Inputs: []Param{{Type: "uint32"}, {Type: "bool"}}},
+			args:     []interface{}{69, true},
+			expected: "cdcd77c0" + word("45") + word("1"),
+		},
+		{
+			f:        Function{Name: "bar", Inputs: []Param{{Type: "bytes3[2]"}}},
+			args:     []interface{}{[][]byte{[]byte("abc"), []byte("def")}},
+			expected: "fce353f6" + text("abc") + text("def"),
+		},
+		{
+			f:    Function{Name: "sam", Inputs: []Param{{Type: "bytes"}, {Type: "bool"}, {Type: "uint[]"}}},
+			args: []interface{}{[]byte("dave"), true, []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}},
+			expected: "a5643bf2" + word("60") + word("1") + word("a0") + word("4") + text("dave") + word("3") + word("1") +
+				word("2") + word("3"),
+		},
+		{
+			f: Function{Name: "f", Inputs: []Param{{Type: "uint"}, {Type: "uint32[]"}, {Type: "bytes10"},
+				{Type: "bytes"}}},
+			args: []interface{}{big.NewInt(0x123), []uint32{0x456, 0x789}, []byte("1234567890"), []byte("Hello, world!")},
+			expected: "8be65246" + word("123") + word("80") + text("1234567890") + word("e0") + word("2") + word("456") +
+				word("789") + word("d") + text("Hello, world!"),
+		},
+		{
+			f:    Function{Name: "g", Inputs: []Param{{Type: "uint[][]"}, {Type: "string[]"}}},
+			args: []interface{}{[][]int{{1, 2}, {3}}, []string{"one", "two", "three"}},
+			expected: "2289b18c" + word("40") + word("140") + word("2") + word("40") + word("a0") + word("2") + word("1") +
+				word("2") + word("1") + word("3") + word("3") + word("60") + word("a0") + word("e0") + word("3") + text("one") +
+				word("3") + text("two") + word("5") + text("three"),
+		},
+	}
+
+	for _, test := range tests {
+		enc, err := test.f.Encode(test.args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if h := hex.EncodeToString(enc); h != test.expected {
+			t.Fatalf("%s: Expected: %s, received: %s", test.f.Name, test.expected, h)
+		}
+	}
+}
+
+func TestEncodeArgs(t *testing.T) {
+	addr, _ := accnt.NewAddress("0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a")
+
+	type inner struct {
+		Flag bool
+	}
+	type outer struct {
+		Name  string `abi:"_name"`
+		Inner inner
+	}
+
+	tuple, _ := NewType("tuple", []Param{
+		{Name: "_name", Type: "string"},
+		{Name: "inner", Type: "tuple", Components: []Param{{Name: "flag", Type: "bool"}}},
+	})
+
+	var tests = []struct {
+		types    []string
+		args     []interface{}
+		expected string
+	}{
+		{
+			types:    []string{"int8"},
+			args:     []interface{}{-1},
+			expected: strings.Repeat("f", 64),
+		},
+		{
+			types:    []string{"int256"},
+			args:     []interface{}{big.NewInt(-2)},
+			expected: strings.Repeat("f", 63) + "e",
+		},
+		{
+			types:    []string{"address", "address", "address"},
+			args:     []interface{}{addr, "0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a", [20]byte{1}},
+			expected: word("19e7e376e7c213b7e7e7e46cc70a5dd086daff2a") + word("19e7e376e7c213b7e7e7e46cc70a5dd086daff2a") + "0000000000000000000000000100000000000000000000000000000000000000",
+		},
+		{
+			types:    []string{"bytes", "string"},
+			args:     []interface{}{[]byte{}, ""},
+			expected: word("40") + word("60") + word("0") + word("0"),
+		},
+		{
+			types:    []string{"uint8[2]", "bool"},
+			args:     []interface{}{[2]uint8{1, 2}, false},
+			expected: word("1") + word("2") + word("0"),
+		},
+	}
+
+	for _, test := range tests {
+		types := make([]Type, len(test.types))
+		for i, s := range test.types {
+			typ, err := NewType(s, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			types[i] = typ
+		}
+
+		enc, err := EncodeArgs(types, test.args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if h := hex.EncodeToString(enc); h != test.expected {
+			t.Fatalf("%v: Expected: %s, received: %s", test.types, test.expected, h)
+		}
+	}
+
+	// Tuples from structs and from slices encode the same.
+	fromStruct, err := EncodeArgs([]Type{tuple}, outer{Name: "joe", Inner: inner{Flag: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromSlice, err := EncodeArgs([]Type{tuple}, []interface{}{"joe", []interface{}{true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := word("20") + word("40") + word("1") + word("3") + text("joe")
+	if h := hex.EncodeToString(fromStruct); h != expected {
+		t.Fatalf("Expected: %s, received: %s", expected, h)
+	}
+	if h := hex.EncodeToString(fromSlice); h != expected {
+		t.Fatalf("Expected: %s, received: %s", expected, h)
+	}
+}
+
+func TestEncodeArgsErrors(t *testing.T) {
+	var tests = []struct {
+		typ string
+		arg interface{}
+	}{
+		{"uint8", 256},
+		{"uint256", -1},
+		{"int8", 128},
+		{"int8", -129},
+		{"bool", 1},
+		{"address", []byte{1, 2, 3}},
+		{"bytes2", []byte{1, 2, 3}},
+		{"uint8[2]", []uint8{1}},
+		{"string", 1},
+		{"uint256", nil},
+	}
+
+	for _, test := range tests {
+		typ, err := NewType(test.typ, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := EncodeArgs([]Type{typ}, test.arg); err == nil {
+			t.Fatalf("Expected error encoding %v as %s", test.arg, test.typ)
+		}
+	}
+
+	if _, err := EncodeArgs([]Type{}, 1); err == nil {
+		t.Fatal("Expected error for wrong number of args")
+	}
+}
+
+func TestCallAndDeploy(t *testing.T) {
+	abi := `[{"constant":false,"inputs":[{"name":"_to","type":"address"},{"name":"_value","type":"uint256"}],` +
+		`"name":"transfer","outputs":[{"name":"","type":"bool"}],"payable":false,"type":"function"},` +
+		`{"inputs":[{"name":"_seller","type":"address"},{"name":"_price","type":"uint256"}],"payable":false,` +
+		`"type":"constructor"}]`
+	c, err := New(abi, "0x73b647cba2fe75ba05b8e12ef8f8d6327d6367bf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Bin = []byte{0x60, 0x60}
+
+	addr, _ := accnt.NewAddress("0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a")
+
+	var tx txn.Transaction
+	if err := c.Call("transfer", &tx, addr, big.NewInt(10)); err != nil {
+		t.Fatal(err)
+	}
+	expected := "a9059cbb" + word("19e7e376e7c213b7e7e7e46cc70a5dd086daff2a") + word("a")
+	if h := hex.EncodeToString(tx.Data); h != expected {
+		t.Fatalf("Expected: %s, received: %s", expected, h)
+	}
+
+	if err := c.Deploy(&tx, addr, 10); err != nil {
+		t.Fatal(err)
+	}
+	expected = "6060" + word("19e7e376e7c213b7e7e7e46cc70a5dd086daff2a") + word("a")
+	if h := hex.EncodeToString(tx.Data); h != expected {
+		t.Fatalf("Expected: %s, received: %s", expected, h)
+	}
+
+	if err := c.Call("transfer", &tx, addr); err == nil {
+		t.Fatal("Expected error for missing arg")
+	}
+
+	if err := c.Call("missing", &tx); err == nil {
+		t.Fatal("Expected error for unknown function")
+	}
+}
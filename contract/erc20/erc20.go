@@ -0,0 +1,202 @@
+// Package erc20 is a client for ERC-20 tokens.
+package erc20
+
+import (
+	"errors"
+	"ethereum/accnt"
+	"ethereum/client"
+	"ethereum/contract"
+	"ethereum/txn"
+	"math/big"
+	"strings"
+)
+
+// ABI is the standard ERC-20 ABI, including the optional name, symbol and
+// decimals.
+const ABI = `[
+	{"inputs":[],"name":"name","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"symbol","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"totalSupply","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view",
+	 "type":"function"},
+	{"inputs":[{"name":"owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],
+	 "stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"owner","type":"address"},{"name":"spender","type":"address"}],"name":"allowance",
+	 "outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"name":"transfer",
+	 "outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"spender","type":"address"},{"name":"value","type":"uint256"}],"name":"approve",
+	 "outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"value","type":"uint256"}],
+	 "name":"transferFrom","outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable","type":"function"},
+	{"anonymous":false,"inputs":[{"indexed":true,"name":"from","type":"address"},
+	 {"indexed":true,"name":"to","type":"address"},{"indexed":false,"name":"value","type":"uint256"}],
+	 "name":"Transfer","type":"event"},
+	{"anonymous":false,"inputs":[{"indexed":true,"name":"owner","type":"address"},
+	 {"indexed":true,"name":"spender","type":"address"},{"indexed":false,"name":"value","type":"uint256"}],
+	 "name":"Approval","type":"event"}
+]`
+
+var (
+	// ErrFalse is returned when a token's function returns false rather than
+	// reverting.
+	ErrFalse = errors.New("erc20: token returned false")
+
+	// ErrNoCode is returned when a call returns nothing because there is no
+	// contract at the token's address.
+	ErrNoCode = errors.New("erc20: no code at token address")
+)
+
+// Token is an ERC-20 token.
+type Token struct {
+	contract.Contract
+
+	client client.Client
+}
+
+// New returns the token at address, using cl to call it.
+func New(cl client.Client, address string) (*Token, error) {
+	c, err := contract.New(ABI, address)
+	if err != nil {
+		return nil, err
+	}
+	return &Token{Contract: c, client: cl}, nil
+}
+
+// Name returns the name of the token.  Tokens which return it as bytes32,
+// such as MKR, are supported.
+func (t *Token) Name() (string, error) {
+	return t.stringCall("name")
+}
+
+// Symbol returns the symbol of the token, which may also be bytes32.
+func (t *Token) Symbol() (string, error) {
+	return t.stringCall("symbol")
+}
+
+func (t *Token) stringCall(funcName string) (string, error) {
+	f, err := t.Abi.Function(funcName)
+	if err != nil {
+		return "", err
+	}
+	data, err := f.Encode()
+	if err != nil {
+		return "", err
+	}
+
+	result, err := t.client.CallData(contract.CallOpts{}, t.Address, data)
+	if err != nil {
+		return "", err
+	}
+
+	// A string is encoded in at least 64 bytes: its offset and length.
+	if len(result) == 32 {
+		return strings.TrimRight(string(result), "\x00"), nil
+	}
+
+	var s string
+	err = f.Decode(result, &s)
+	return s, err
+}
+
+// Decimals returns the number of decimals of the token's amounts.
+func (t *Token) Decimals() (uint8, error) {
+	var d uint8
+	err := t.client.CallContract(t.Contract, "decimals", nil, &d)
+	return d, err
+}
+
+// TotalSupply returns the amount of the token in existence.
+func (t *Token) TotalSupply() (*big.Int, error) {
+	var s *big.Int
+	err := t.client.CallContract(t.Contract, "totalSupply", nil, &s)
+	return s, err
+}
+
+// BalanceOf returns the amount of the token owned by an account.
+func (t *Token) BalanceOf(owner accnt.Address) (*big.Int, error) {
+	var b *big.Int
+	err := t.client.CallContract(t.Contract, "balanceOf", []interface{}{owner}, &b)
+	return b, err
+}
+
+// Allowance returns the amount which spender may still transfer from owner.
+func (t *Token) Allowance(owner, spender accnt.Address) (*big.Int, error) {
+	var a *big.Int
+	err := t.client.CallContract(t.Contract, "allowance", []interface{}{owner, spender}, &a)
+	return a, err
+}
+
+// Transfer sets up tx to transfer value to an account.
+func (t *Token) Transfer(tx *txn.Transaction, to accnt.Address, value *big.Int) error {
+	return t.Call("transfer", tx, to, value)
+}
+
+// Approve sets up tx to allow spender to transfer up to value from the
+// sender's account.
+func (t *Token) Approve(tx *txn.Transaction, spender accnt.Address, value *big.Int) error {
+	return t.Call("approve", tx, spender, value)
+}
+
+// TransferFrom sets up tx to transfer value between accounts, using the
+// sender's allowance.
+func (t *Token) TransferFrom(tx *txn.Transaction, from, to accnt.Address, value *big.Int) error {
+	return t.Call("transferFrom", tx, from, to, value)
+}
+
+// CheckTransfer checks with eth_call that from can transfer value to an
+// account.  It returns the revert error if the transfer would revert, and
+// ErrFalse if the token would return false.  Tokens which return nothing,
+// such as USDT, are supported, but an address without code, which also
+// returns nothing, gives ErrNoCode.
+func (t *Token) CheckTransfer(from, to accnt.Address, value *big.Int) error {
+	return t.checkCall(from, "transfer", to, value)
+}
+
+// CheckApprove checks with eth_call that owner can approve spender, as
+// CheckTransfer.
+func (t *Token) CheckApprove(owner, spender accnt.Address, value *big.Int) error {
+	return t.checkCall(owner, "approve", spender, value)
+}
+
+// CheckTransferFrom checks with eth_call that spender can transfer value
+// between accounts, as CheckTransfer.
+func (t *Token) CheckTransferFrom(spender, from, to accnt.Address, value *big.Int) error {
+	return t.checkCall(spender, "transferFrom", from, to, value)
+}
+
+func (t *Token) checkCall(from accnt.Address, funcName string, args ...interface{}) error {
+	f, err := t.Abi.Function(funcName, args...)
+	if err != nil {
+		return err
+	}
+	data, err := f.Encode(args...)
+	if err != nil {
+		return err
+	}
+
+	result, err := t.client.CallData(contract.CallOpts{From: from}, t.Address, data)
+	if err != nil {
+		return err
+	}
+	if len(result) == 0 {
+		// As SafeERC20, only a contract may return nothing.
+		code, err := t.client.GetCode(t.Address)
+		if err != nil {
+			return err
+		}
+		if len(code) == 0 {
+			return ErrNoCode
+		}
+		return nil
+	}
+
+	var ok bool
+	if err := f.Decode(result, &ok); err != nil {
+		return err
+	}
+	if !ok {
+		return ErrFalse
+	}
+	return nil
+}
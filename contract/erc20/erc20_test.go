@@ -0,0 +1,168 @@
+package erc20
+
+import (
+	"ethereum/accnt"
+	"ethereum/client"
+	"ethereum/contract"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const tokenAddress = "0xa10a3b175f0f2641cf41912b887f77d8ef34fae8"
+
+// newTestToken returns a token whose node expects the given requests, in
+// order, and responds to each with the given result.
+func newTestToken(t *testing.T, exchanges ...[2]string) (*Token, *httptest.Server) {
+	n := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n >= len(exchanges) {
+			t.Fatalf("Unexpected request: %s", data)
+		}
+		if d := string(data); d != exchanges[n][0] {
+			t.Fatalf("Expected: %s, received: %s", exchanges[n][0], d)
+		}
+
+		if _, err := w.Write([]byte(exchanges[n][1])); err != nil {
+			t.Fatal(err)
+		}
+		n++
+	}))
+
+	cl, err := client.Dial(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := New(cl, tokenAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token, ts
+}
+
+func word(hex string) string {
+	return strings.Repeat("0", 64-len(hex)) + hex
+}
+
+func TestName(t *testing.T) {
+	var tests = []struct {
+		result   string
+		expected string
+	}{
+		// bytes32, as returned by MKR.
+		{"0x4d616b6572" + strings.Repeat("0", 54), "Maker"},
+		{"0x" + word("20") + word("5") + "4d616b6572" + strings.Repeat("0", 54), "Maker"},
+	}
+
+	for _, test := range tests {
+		token, ts := newTestToken(t, [2]string{
+			`{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{"data":"0x06fdde03","to":"` + tokenAddress +
+				`"},"latest"]}`,
+			`{"jsonrpc":"2.0","id":1,"result":"` + test.result + `"}`,
+		})
+
+		name, err := token.Name()
+		ts.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != test.expected {
+			t.Fatalf("Expected: %s, received: %s", test.expected, name)
+		}
+	}
+}
+
+func TestCheckTransfer(t *testing.T) {
+	from, _ := accnt.NewAddress("0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a")
+	to, _ := accnt.NewAddress("0x73b647cba2fe75ba05b8e12ef8f8d6327d6367bf")
+	request := `{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{"from":"0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a",` +
+		`"data":"0xa9059cbb` + word("73b647cba2fe75ba05b8e12ef8f8d6327d6367bf") + word("3e8") + `","to":"` +
+		tokenAddress + `"},"latest"]}`
+
+	codeRequest := `{"jsonrpc":"2.0","id":2,"method":"eth_getCode","params":["` + tokenAddress + `","latest"]}`
+
+	var tests = []struct {
+		rpcResponse string
+		code        string // the code at the token's address, if asked for
+		expected    string
+	}{
+		// Tokens such as USDT return nothing.
+		{`{"jsonrpc":"2.0","id":1,"result":"0x"}`, "0x6080", ""},
+		// As does an address without code.
+		{`{"jsonrpc":"2.0","id":1,"result":"0x"}`, "0x", ErrNoCode.Error()},
+		{`{"jsonrpc":"2.0","id":1,"result":"0x` + word("1") + `"}`, "", ""},
+		{`{"jsonrpc":"2.0","id":1,"result":"0x` + word("0") + `"}`, "", ErrFalse.Error()},
+		{
+			`{"jsonrpc":"2.0","id":1,"error":{"code":3,"message":"execution reverted: balance","data":"0x08c379a0` +
+				word("20") + word("7") + "62616c616e6365" + strings.Repeat("0", 50) + `"}}`,
+			"",
+			"execution reverted: balance",
+		},
+	}
+
+	for _, test := range tests {
+		exchanges := [][2]string{{request, test.rpcResponse}}
+		if test.code != "" {
+			exchanges = append(exchanges, [2]string{codeRequest, `{"jsonrpc":"2.0","id":2,"result":"` + test.code + `"}`})
+		}
+		token, ts := newTestToken(t, exchanges...)
+		err := token.CheckTransfer(from, to, big.NewInt(1000))
+		ts.Close()
+
+		if received := fmt.Sprint(err); test.expected == "" && err != nil || test.expected != "" && received != test.expected {
+			t.Fatalf("Expected: %q, received: %v", test.expected, err)
+		}
+		if _, ok := err.(*contract.RevertError); strings.HasPrefix(test.expected, "execution") && !ok {
+			t.Fatalf("Expected: *contract.RevertError, received: %T", err)
+		}
+	}
+}
+
+func TestFilterTransfer(t *testing.T) {
+	to, _ := accnt.NewAddress("0x73b647cba2fe75ba05b8e12ef8f8d6327d6367bf")
+	log := `{"address":"` + tokenAddress + `","topics":["0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df5` +
+		`23b3ef","0x` + word("19e7e376e7c213b7e7e7e46cc70a5dd086daff2a") + `","0x` +
+		word("73b647cba2fe75ba05b8e12ef8f8d6327d6367bf") + `"],"data":"0x` + word("3e8") + `","blockNumber":"0x12ca",` +
+		`"blockHash":"0x2c4b4c9b5c0ab0ef6ec8ba1e7e14f8b3c1e6fae62ef9e1dfe6d6f3b0b5f4e0d6","transactionHash":"0xd866f3` +
+		`672a3cef05f66dec56d30562bbffcc42aa11b54450e6973d52c89d1719","transactionIndex":"0x1","logIndex":"0x3",` +
+		`"removed":false}`
+
+	token, ts := newTestToken(t, [2]string{
+		`{"jsonrpc":"2.0","id":1,"method":"eth_getLogs","params":[{"fromBlock":"0x12c0","toBlock":"latest","address":["` +
+			tokenAddress + `"],"topics":[["0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"],null,` +
+			`["0x` + word("73b647cba2fe75ba05b8e12ef8f8d6327d6367bf") + `"]]}]}`,
+		`{"jsonrpc":"2.0","id":1,"result":[` + log + `]}`,
+	})
+	defer ts.Close()
+
+	it, err := token.FilterTransfer(big.NewInt(4800), nil, nil, []accnt.Address{to})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events []Transfer
+	for it.Next() {
+		events = append(events, it.Event)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("Expected: 1 event, received: %d", len(events))
+	}
+	e := events[0]
+	if e.From.String() != "0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a" || e.To.String() != to.String() ||
+		e.Value.Int64() != 1000 || e.Raw.LogIndex != 3 {
+		t.Fatalf("Unexpected event: %+v", e)
+	}
+}
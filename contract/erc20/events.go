@@ -0,0 +1,141 @@
+package erc20
+
+import (
+	"ethereum/accnt"
+	"ethereum/client"
+	"ethereum/contract"
+	"ethereum/txn"
+	"math/big"
+)
+
+// Transfer is a Transfer event, of value moving between accounts.  Tokens are
+// minted from and burnt to the zero address.
+type Transfer struct {
+	From  accnt.Address
+	To    accnt.Address
+	Value *big.Int
+
+	Raw txn.Log // the log the event was decoded from
+}
+
+// Approval is an Approval event, of an owner allowing a spender to transfer
+// value from its account.
+type Approval struct {
+	Owner   accnt.Address
+	Spender accnt.Address
+	Value   *big.Int
+
+	Raw txn.Log // the log the event was decoded from
+}
+
+// TransferIterator iterates over the Transfer events returned by
+// FilterTransfer.
+type TransferIterator struct {
+	Event Transfer // the current event, after Next returns true
+
+	logs logIterator
+}
+
+// Next advances to the next event, returning false when there are no more or
+// on error.
+func (it *TransferIterator) Next() bool {
+	it.Event = Transfer{}
+	return it.logs.next(&it.Event, &it.Event.Raw)
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *TransferIterator) Err() error {
+	return it.logs.err
+}
+
+// ApprovalIterator iterates over the Approval events returned by
+// FilterApproval.
+type ApprovalIterator struct {
+	Event Approval // the current event, after Next returns true
+
+	logs logIterator
+}
+
+// Next advances to the next event, returning false when there are no more or
+// on error.
+func (it *ApprovalIterator) Next() bool {
+	it.Event = Approval{}
+	return it.logs.next(&it.Event, &it.Event.Raw)
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *ApprovalIterator) Err() error {
+	return it.logs.err
+}
+
+// FilterTransfer returns the Transfer events of the token between blocks
+// fromBlock and toBlock, nil meaning the first and latest block.  from and
+// to restrict the events to those accounts, if given.
+func (t *Token) FilterTransfer(fromBlock, toBlock *big.Int, from, to []accnt.Address) (*TransferIterator, error) {
+	it, err := t.filter("Transfer", fromBlock, toBlock, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return &TransferIterator{logs: it}, nil
+}
+
+// FilterApproval returns the Approval events of the token, as
+// FilterTransfer.
+func (t *Token) FilterApproval(fromBlock, toBlock *big.Int, owner, spender []accnt.Address) (*ApprovalIterator, error) {
+	it, err := t.filter("Approval", fromBlock, toBlock, owner, spender)
+	if err != nil {
+		return nil, err
+	}
+	return &ApprovalIterator{logs: it}, nil
+}
+
+func (t *Token) filter(name string, fromBlock, toBlock *big.Int, indexed ...[]accnt.Address) (logIterator, error) {
+	ev, err := t.Abi.Event(name)
+	if err != nil {
+		return logIterator{}, err
+	}
+
+	sets := make([][]interface{}, len(indexed))
+	for i, addrs := range indexed {
+		for _, a := range addrs {
+			sets[i] = append(sets[i], a)
+		}
+	}
+	topics, err := ev.TopicSets(sets...)
+	if err != nil {
+		return logIterator{}, err
+	}
+
+	logs, err := t.client.FilterLogs(client.FilterQuery{
+		FromBlock: fromBlock,
+		ToBlock:   toBlock,
+		Addresses: []string{t.Address},
+		Topics:    topics,
+	})
+	if err != nil {
+		return logIterator{}, err
+	}
+	return logIterator{event: ev, logs: logs}, nil
+}
+
+// logIterator decodes logs of an event one at a time.
+type logIterator struct {
+	event contract.Event
+	logs  []txn.Log
+	err   error
+}
+
+// next decodes the next log into v and stores the log into raw.
+func (it *logIterator) next(v interface{}, raw *txn.Log) bool {
+	if it.err != nil || len(it.logs) == 0 {
+		return false
+	}
+
+	log := it.logs[0]
+	it.logs = it.logs[1:]
+	if it.err = it.event.Decode(log, v); it.err != nil {
+		return false
+	}
+	*raw = log
+	return true
+}
@@ -0,0 +1,167 @@
+// Package erc721 is a client for ERC-721 non-fungible tokens.
+package erc721
+
+import (
+	"ethereum/accnt"
+	"ethereum/client"
+	"ethereum/contract"
+	"ethereum/txn"
+	"math/big"
+)
+
+// ABI is the standard ERC-721 ABI, including the optional metadata
+// extension.
+const ABI = `[
+	{"inputs":[],"name":"name","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"symbol","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"tokenId","type":"uint256"}],"name":"tokenURI","outputs":[{"name":"","type":"string"}],
+	 "stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],
+	 "stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"tokenId","type":"uint256"}],"name":"ownerOf","outputs":[{"name":"","type":"address"}],
+	 "stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"tokenId","type":"uint256"}],"name":"getApproved","outputs":[{"name":"","type":"address"}],
+	 "stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"owner","type":"address"},{"name":"operator","type":"address"}],"name":"isApprovedForAll",
+	 "outputs":[{"name":"","type":"bool"}],"stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"interfaceId","type":"bytes4"}],"name":"supportsInterface","outputs":[{"name":"","type":"bool"}],
+	 "stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"to","type":"address"},{"name":"tokenId","type":"uint256"}],"name":"approve","outputs":[],
+	 "stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"operator","type":"address"},{"name":"approved","type":"bool"}],"name":"setApprovalForAll",
+	 "outputs":[],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"tokenId","type":"uint256"}],
+	 "name":"transferFrom","outputs":[],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"tokenId","type":"uint256"}],
+	 "name":"safeTransferFrom","outputs":[],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"tokenId","type":"uint256"},
+	 {"name":"data","type":"bytes"}],"name":"safeTransferFrom","outputs":[],"stateMutability":"nonpayable",
+	 "type":"function"},
+	{"anonymous":false,"inputs":[{"indexed":true,"name":"from","type":"address"},
+	 {"indexed":true,"name":"to","type":"address"},{"indexed":true,"name":"tokenId","type":"uint256"}],
+	 "name":"Transfer","type":"event"},
+	{"anonymous":false,"inputs":[{"indexed":true,"name":"owner","type":"address"},
+	 {"indexed":true,"name":"approved","type":"address"},{"indexed":true,"name":"tokenId","type":"uint256"}],
+	 "name":"Approval","type":"event"},
+	{"anonymous":false,"inputs":[{"indexed":true,"name":"owner","type":"address"},
+	 {"indexed":true,"name":"operator","type":"address"},{"indexed":false,"name":"approved","type":"bool"}],
+	 "name":"ApprovalForAll","type":"event"}
+]`
+
+// InterfaceId is the ERC-165 identifier of ERC-721.
+var InterfaceId = [4]byte{0x80, 0xac, 0x58, 0xcd}
+
+// Token is an ERC-721 token contract.
+type Token struct {
+	contract.Contract
+
+	client client.Client
+}
+
+// New returns the token contract at address, using cl to call it.
+func New(cl client.Client, address string) (*Token, error) {
+	c, err := contract.New(ABI, address)
+	if err != nil {
+		return nil, err
+	}
+	return &Token{Contract: c, client: cl}, nil
+}
+
+func (t *Token) Name() (string, error) {
+	var s string
+	err := t.client.CallContract(t.Contract, "name", nil, &s)
+	return s, err
+}
+
+func (t *Token) Symbol() (string, error) {
+	var s string
+	err := t.client.CallContract(t.Contract, "symbol", nil, &s)
+	return s, err
+}
+
+func (t *Token) TokenURI(tokenId *big.Int) (string, error) {
+	var s string
+	err := t.client.CallContract(t.Contract, "tokenURI", []interface{}{tokenId}, &s)
+	return s, err
+}
+
+func (t *Token) BalanceOf(owner accnt.Address) (*big.Int, error) {
+	var b *big.Int
+	err := t.client.CallContract(t.Contract, "balanceOf", []interface{}{owner}, &b)
+	return b, err
+}
+
+// OwnerOf returns the owner of a token.  Tokens which don't exist revert.
+func (t *Token) OwnerOf(tokenId *big.Int) (accnt.Address, error) {
+	var a accnt.Address
+	err := t.client.CallContract(t.Contract, "ownerOf", []interface{}{tokenId}, &a)
+	return a, err
+}
+
+// GetApproved returns the account approved to transfer a token, which is
+// the zero address if none is.
+func (t *Token) GetApproved(tokenId *big.Int) (accnt.Address, error) {
+	var a accnt.Address
+	err := t.client.CallContract(t.Contract, "getApproved", []interface{}{tokenId}, &a)
+	return a, err
+}
+
+func (t *Token) IsApprovedForAll(owner, operator accnt.Address) (bool, error) {
+	var ok bool
+	err := t.client.CallContract(t.Contract, "isApprovedForAll", []interface{}{owner, operator}, &ok)
+	return ok, err
+}
+
+// SupportsInterface reports whether the contract implements the interface
+// with the given ERC-165 identifier.  Contracts without ERC-165 support
+// revert, or return nothing, and are reported not to.
+func (t *Token) SupportsInterface(interfaceId [4]byte) (bool, error) {
+	f, err := t.Abi.Function("supportsInterface")
+	if err != nil {
+		return false, err
+	}
+	data, err := f.Encode(interfaceId)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := t.client.CallData(contract.CallOpts{}, t.Address, data)
+	if _, ok := err.(*contract.RevertError); ok {
+		return false, nil
+	}
+	if err != nil || len(result) == 0 {
+		return false, err
+	}
+
+	var ok bool
+	err = f.Decode(result, &ok)
+	return ok, err
+}
+
+// Approve sets up tx to approve an account to transfer a token, or to clear
+// the approval if to is the zero address.
+func (t *Token) Approve(tx *txn.Transaction, to accnt.Address, tokenId *big.Int) error {
+	return t.Call("approve", tx, to, tokenId)
+}
+
+// SetApprovalForAll sets up tx to approve or disapprove an operator to
+// transfer all the sender's tokens.
+func (t *Token) SetApprovalForAll(tx *txn.Transaction, operator accnt.Address, approved bool) error {
+	return t.Call("setApprovalForAll", tx, operator, approved)
+}
+
+// TransferFrom sets up tx to transfer a token without checking that the
+// recipient can receive it.
+func (t *Token) TransferFrom(tx *txn.Transaction, from, to accnt.Address, tokenId *big.Int) error {
+	return t.Call("transferFrom", tx, from, to, tokenId)
+}
+
+// SafeTransferFrom sets up tx to transfer a token, reverting if the
+// recipient is a contract which doesn't accept it.  data, if given, is passed
+// to the recipient's onERC721Received.
+func (t *Token) SafeTransferFrom(tx *txn.Transaction, from, to accnt.Address, tokenId *big.Int, data ...[]byte) error {
+	if len(data) == 0 {
+		return t.Call("safeTransferFrom(address,address,uint256)", tx, from, to, tokenId)
+	}
+	return t.Call("safeTransferFrom(address,address,uint256,bytes)", tx, from, to, tokenId, data[0])
+}
@@ -0,0 +1,89 @@
+package erc721
+
+import (
+	"encoding/hex"
+	"ethereum/accnt"
+	"ethereum/client"
+	"ethereum/txn"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const tokenAddress = "0xa10a3b175f0f2641cf41912b887f77d8ef34fae8"
+
+func word(hex string) string {
+	return strings.Repeat("0", 64-len(hex)) + hex
+}
+
+func TestOwnerOf(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{"data":"0x6352211e` + word("2a") +
+			`","to":"` + tokenAddress + `"},"latest"]}`
+		if d := string(data); d != expected {
+			t.Fatalf("Expected: %s, received: %s", expected, d)
+		}
+
+		resp := `{"jsonrpc":"2.0","id":1,"result":"0x` + word("19e7e376e7c213b7e7e7e46cc70a5dd086daff2a") + `"}`
+		if _, err := w.Write([]byte(resp)); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	defer ts.Close()
+
+	cl, err := client.Dial(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := New(cl, tokenAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	owner, err := token.OwnerOf(big.NewInt(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner.String() != "0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a" {
+		t.Fatalf("Expected: 0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a, received: %s", owner)
+	}
+}
+
+func TestSafeTransferFrom(t *testing.T) {
+	token, err := New(client.Client{}, tokenAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	from, _ := accnt.NewAddress("0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a")
+	to, _ := accnt.NewAddress("0x73b647cba2fe75ba05b8e12ef8f8d6327d6367bf")
+	args := word("19e7e376e7c213b7e7e7e46cc70a5dd086daff2a") + word("73b647cba2fe75ba05b8e12ef8f8d6327d6367bf") +
+		word("2a")
+
+	var tests = []struct {
+		data     [][]byte
+		expected string
+	}{
+		{nil, "42842e0e" + args},
+		{[][]byte{{0xca, 0xfe}}, "b88d4fde" + args + word("80") + word("2") + "cafe" + strings.Repeat("0", 60)},
+	}
+
+	for _, test := range tests {
+		var tx txn.Transaction
+		if err := token.SafeTransferFrom(&tx, from, to, big.NewInt(42), test.data...); err != nil {
+			t.Fatal(err)
+		}
+		if d := hex.EncodeToString(tx.Data); d != test.expected {
+			t.Fatalf("Expected: %s, received: %s", test.expected, d)
+		}
+		if tx.To != tokenAddress {
+			t.Fatalf("Expected: %s, received: %s", tokenAddress, tx.To)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package erc721
+
+import (
+	"ethereum/accnt"
+	"ethereum/client"
+	"ethereum/contract"
+	"ethereum/txn"
+	"math/big"
+)
+
+// Transfer is a Transfer event, of a token changing owner.  Tokens are minted
+// from and burnt to the zero address.
+type Transfer struct {
+	From    accnt.Address
+	To      accnt.Address
+	TokenId *big.Int
+
+	Raw txn.Log // the log the event was decoded from
+}
+
+// Approval is an Approval event, of an owner approving an account to transfer
+// one of its tokens.
+type Approval struct {
+	Owner    accnt.Address
+	Approved accnt.Address
+	TokenId  *big.Int
+
+	Raw txn.Log // the log the event was decoded from
+}
+
+// ApprovalForAll is an ApprovalForAll event, of an owner approving or
+// disapproving an operator to transfer all its tokens.
+type ApprovalForAll struct {
+	Owner    accnt.Address
+	Operator accnt.Address
+	Approved bool
+
+	Raw txn.Log // the log the event was decoded from
+}
+
+// TransferIterator iterates over the Transfer events returned by
+// FilterTransfer.
+type TransferIterator struct {
+	Event Transfer // the current event, after Next returns true
+
+	logs logIterator
+}
+
+// Next advances to the next event, returning false when there are no more or
+// on error.
+func (it *TransferIterator) Next() bool {
+	it.Event = Transfer{}
+	return it.logs.next(&it.Event, &it.Event.Raw)
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *TransferIterator) Err() error {
+	return it.logs.err
+}
+
+// ApprovalIterator iterates over the Approval events returned by
+// FilterApproval.
+type ApprovalIterator struct {
+	Event Approval // the current event, after Next returns true
+
+	logs logIterator
+}
+
+// Next advances to the next event, returning false when there are no more or
+// on error.
+func (it *ApprovalIterator) Next() bool {
+	it.Event = Approval{}
+	return it.logs.next(&it.Event, &it.Event.Raw)
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *ApprovalIterator) Err() error {
+	return it.logs.err
+}
+
+// ApprovalForAllIterator iterates over the ApprovalForAll events returned by
+// FilterApprovalForAll.
+type ApprovalForAllIterator struct {
+	Event ApprovalForAll // the current event, after Next returns true
+
+	logs logIterator
+}
+
+// Next advances to the next event, returning false when there are no more or
+// on error.
+func (it *ApprovalForAllIterator) Next() bool {
+	it.Event = ApprovalForAll{}
+	return it.logs.next(&it.Event, &it.Event.Raw)
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *ApprovalForAllIterator) Err() error {
+	return it.logs.err
+}
+
+// FilterTransfer returns the Transfer events of the token between blocks
+// fromBlock and toBlock, nil meaning the first and latest block.  from, to
+// and tokenId restrict the events to those accounts and tokens, if given.
+func (t *Token) FilterTransfer(fromBlock, toBlock *big.Int, from, to []accnt.Address, tokenId []*big.Int) (*TransferIterator, error) {
+	it, err := t.filter("Transfer", fromBlock, toBlock, addresses(from), addresses(to), ids(tokenId))
+	if err != nil {
+		return nil, err
+	}
+	return &TransferIterator{logs: it}, nil
+}
+
+// FilterApproval returns the Approval events of the token, as
+// FilterTransfer.
+func (t *Token) FilterApproval(fromBlock, toBlock *big.Int, owner, approved []accnt.Address, tokenId []*big.Int) (*ApprovalIterator, error) {
+	it, err := t.filter("Approval", fromBlock, toBlock, addresses(owner), addresses(approved), ids(tokenId))
+	if err != nil {
+		return nil, err
+	}
+	return &ApprovalIterator{logs: it}, nil
+}
+
+// FilterApprovalForAll returns the ApprovalForAll events of the token, as
+// FilterTransfer.
+func (t *Token) FilterApprovalForAll(fromBlock, toBlock *big.Int, owner, operator []accnt.Address) (*ApprovalForAllIterator, error) {
+	it, err := t.filter("ApprovalForAll", fromBlock, toBlock, addresses(owner), addresses(operator))
+	if err != nil {
+		return nil, err
+	}
+	return &ApprovalForAllIterator{logs: it}, nil
+}
+
+func addresses(addrs []accnt.Address) []interface{} {
+	var s []interface{}
+	for _, a := range addrs {
+		s = append(s, a)
+	}
+	return s
+}
+
+func ids(ids []*big.Int) []interface{} {
+	var s []interface{}
+	for _, id := range ids {
+		s = append(s, id)
+	}
+	return s
+}
+
+func (t *Token) filter(name string, fromBlock, toBlock *big.Int, sets ...[]interface{}) (logIterator, error) {
+	ev, err := t.Abi.Event(name)
+	if err != nil {
+		return logIterator{}, err
+	}
+	topics, err := ev.TopicSets(sets...)
+	if err != nil {
+		return logIterator{}, err
+	}
+
+	logs, err := t.client.FilterLogs(client.FilterQuery{
+		FromBlock: fromBlock,
+		ToBlock:   toBlock,
+		Addresses: []string{t.Address},
+		Topics:    topics,
+	})
+	if err != nil {
+		return logIterator{}, err
+	}
+	return logIterator{event: ev, logs: logs}, nil
+}
+
+// logIterator decodes logs of an event one at a time.
+type logIterator struct {
+	event contract.Event
+	logs  []txn.Log
+	err   error
+}
+
+// next decodes the next log into v and stores the log into raw.
+func (it *logIterator) next(v interface{}, raw *txn.Log) bool {
+	if it.err != nil || len(it.logs) == 0 {
+		return false
+	}
+
+	log := it.logs[0]
+	it.logs = it.logs[1:]
+	if it.err = it.event.Decode(log, v); it.err != nil {
+		return false
+	}
+	*raw = log
+	return true
+}
@@ -0,0 +1,294 @@
+package contract
+
+import (
+	"encoding/hex"
+	"errors"
+	"ethereum/txn"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var errNoTopics = errors.New("abi: log has no topics")
+
+// Id returns topic0 of the event: the hash of its signature.  Anonymous events
+// don't include it in their logs.
+func (e Event) Id() []byte {
+	sig, err := e.Signature()
+	if err != nil {
+		return nil
+	}
+	return crypto.Keccak256([]byte(sig))
+}
+
+// Topic returns the hex encoded topic0 of the event.
+func (e Event) Topic() string {
+	return "0x" + hex.EncodeToString(e.Id())
+}
+
+// Topics returns the topics to filter logs of the event by: topic0, unless
+// the event is anonymous, followed by one topic per indexed input.  args
+// holds a value for each indexed input, nil matching any value.  A nil topic
+// in the result matches any topic.
+func (e Event) Topics(args ...interface{}) ([][]string, error) {
+	sets := make([][]interface{}, len(args))
+	for i, a := range args {
+		if a != nil {
+			sets[i] = []interface{}{a}
+		}
+	}
+	return e.TopicSets(sets...)
+}
+
+// TopicSets is like Topics, but takes a set of values for each indexed input,
+// any of which matches.  An empty set matches any value.
+func (e Event) TopicSets(sets ...[]interface{}) ([][]string, error) {
+	indexed := e.indexed()
+	if len(sets) > len(indexed) {
+		return nil, fmt.Errorf("%s has %d indexed inputs, received %d args", e.Name, len(indexed), len(sets))
+	}
+
+	var topics [][]string
+	if !e.Anonymous {
+		topics = append(topics, []string{e.Topic()})
+	}
+
+	for i, set := range sets {
+		t, err := NewType(indexed[i].Type, indexed[i].Components)
+		if err != nil {
+			return nil, err
+		}
+
+		var values []string
+		for _, a := range set {
+			topic, err := t.encodeTopic(reflect.ValueOf(a))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", indexed[i].Name, err)
+			}
+			values = append(values, "0x"+hex.EncodeToString(topic))
+		}
+		topics = append(topics, values)
+	}
+
+	// Trailing wildcards are implied.
+	for len(topics) > 0 && topics[len(topics)-1] == nil {
+		topics = topics[:len(topics)-1]
+	}
+	return topics, nil
+}
+
+// Decode decodes the inputs of the event from a log into v, as DecodeArgs
+// does for function outputs.  Indexed inputs are read from the log's topics
+// and the others from its data.  Indexed inputs of dynamic or composite types
+// are only logged as the hash of their value, so they decode as bytes32.
+func (e Event) Decode(log txn.Log, v interface{}) error {
+	topics := log.Topics
+	if !e.Anonymous {
+		if len(topics) == 0 {
+			return errNoTopics
+		}
+		if !strings.EqualFold(topics[0], e.Topic()) {
+			return fmt.Errorf("abi: log is not a %s event", e.Name)
+		}
+		topics = topics[1:]
+	}
+
+	var (
+		types    = make([]Type, len(e.Inputs))
+		names    = make([]string, len(e.Inputs))
+		vals     = make([]interface{}, len(e.Inputs))
+		data     []Param
+		dataPos  []int
+		topicPos int
+	)
+	for i, in := range e.Inputs {
+		t, err := NewType(in.Type, in.Components)
+		if err != nil {
+			return err
+		}
+		types[i], names[i] = t, in.Name
+
+		if !in.Indexed {
+			data = append(data, in)
+			dataPos = append(dataPos, i)
+			continue
+		}
+
+		if topicPos >= len(topics) {
+			return fmt.Errorf("abi: log has too few topics for %s", e.Name)
+		}
+		topic, err := decodeHex(topics[topicPos])
+		if err != nil {
+			return err
+		}
+		topicPos++
+
+		if t.hashedTopic() {
+			types[i] = Type{Kind: FixedBytesKind, Size: 32}
+		}
+		if vals[i], err = types[i].decode(topic); err != nil {
+			return err
+		}
+	}
+
+	var dataVals []interface{}
+	if err := decodeParams(data, log.Data, &dataVals); err != nil {
+		return err
+	}
+	for i, pos := range dataPos {
+		vals[pos] = dataVals[i]
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("abi: decode target must be a non-nil pointer")
+	}
+	return setValue(rv.Elem(), Type{Kind: TupleKind, Components: types, ComponentNames: names}, vals)
+}
+
+func (e Event) indexed() []Param {
+	var ps []Param
+	for _, in := range e.Inputs {
+		if in.Indexed {
+			ps = append(ps, in)
+		}
+	}
+	return ps
+}
+
+// Event returns the event with the given name or signature.
+func (a ABI) Event(name string) (Event, error) {
+	if ev, ok := a.Events[name]; ok {
+		return ev, nil
+	}
+
+	var matches []Event
+	for _, ev := range a.Events {
+		if ev.Name == name {
+			matches = append(matches, ev)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return Event{}, fmt.Errorf("no event %q in abi", name)
+	case 1:
+		return matches[0], nil
+	default:
+		return Event{}, fmt.Errorf("event %s is overloaded, use its signature", name)
+	}
+}
+
+// EventByTopic returns the non-anonymous event with the given topic0.
+func (a ABI) EventByTopic(topic string) (Event, bool) {
+	for _, ev := range a.Events {
+		if !ev.Anonymous && strings.EqualFold(ev.Topic(), topic) {
+			return ev, true
+		}
+	}
+	return Event{}, false
+}
+
+// DecodeLog finds the event which emitted log by its topic0, and decodes the
+// log into v.
+func (a ABI) DecodeLog(log txn.Log, v interface{}) (Event, error) {
+	if len(log.Topics) == 0 {
+		return Event{}, errNoTopics
+	}
+
+	ev, ok := a.EventByTopic(log.Topics[0])
+	if !ok {
+		return Event{}, fmt.Errorf("abi: no event with topic %s", log.Topics[0])
+	}
+	return ev, ev.Decode(log, v)
+}
+
+// hashedTopic reports whether an indexed value of type t is logged as the
+// hash of its encoding rather than as the value itself.
+func (t Type) hashedTopic() bool {
+	switch t.Kind {
+	case BytesKind, StringKind, SliceKind, ArrayKind, TupleKind:
+		return true
+	}
+	return false
+}
+
+// encodeTopic returns the topic for an indexed value of type t.
+func (t Type) encodeTopic(v reflect.Value) ([]byte, error) {
+	if !t.hashedTopic() {
+		return t.encode(v)
+	}
+
+	enc, err := t.encodeInPlace(v, false)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256(enc), nil
+}
+
+// encodeInPlace encodes a value as hashed for an indexed topic: elements of
+// arrays and tuples are concatenated without offsets or lengths, and bytes and
+// strings are only padded when nested.
+func (t Type) encodeInPlace(v reflect.Value, nested bool) ([]byte, error) {
+	v = indirect(v)
+	if !v.IsValid() {
+		return nil, fmt.Errorf("cannot encode nil as %s", t)
+	}
+
+	switch t.Kind {
+	case BytesKind, StringKind:
+		enc, err := t.encode(v)
+		if err != nil {
+			return nil, err
+		}
+		// Strip the length word, and the padding if not nested.
+		if !nested {
+			return enc[32 : 32+v.Len()], nil
+		}
+		return enc[32:], nil
+	case SliceKind, ArrayKind:
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return nil, fmt.Errorf("cannot encode %s as %s", v.Type(), t)
+		}
+		if t.Kind == ArrayKind && v.Len() != t.Size {
+			return nil, fmt.Errorf("cannot encode %d elements as %s", v.Len(), t)
+		}
+
+		var out []byte
+		for i := 0; i < v.Len(); i++ {
+			enc, err := t.Elem.encodeInPlace(v.Index(i), true)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, enc...)
+		}
+		return out, nil
+	case TupleKind:
+		vals, err := t.tupleValues(v)
+		if err != nil {
+			return nil, err
+		}
+
+		var out []byte
+		for i, c := range t.Components {
+			enc, err := c.encodeInPlace(vals[i], true)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, enc...)
+		}
+		return out, nil
+	default:
+		return t.encode(v)
+	}
+}
+
+func decodeHex(s string) ([]byte, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("abi: invalid topic %q: %s", s, err)
+	}
+	return b, nil
+}
@@ -0,0 +1,174 @@
+package contract
+
+import (
+	"ethereum/accnt"
+	"ethereum/txn"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+const eventsAbi = `[
+	{"anonymous":false,"inputs":[{"indexed":true,"name":"from","type":"address"},
+	 {"indexed":true,"name":"to","type":"address"},{"indexed":false,"name":"value","type":"uint256"}],
+	 "name":"Transfer","type":"event"},
+	{"anonymous":false,"inputs":[{"indexed":true,"name":"key","type":"string"},
+	 {"indexed":false,"name":"value","type":"string"},{"indexed":true,"name":"id","type":"uint64"}],
+	 "name":"Stored","type":"event"},
+	{"anonymous":true,"inputs":[{"indexed":true,"name":"who","type":"address"},
+	 {"indexed":false,"name":"amount","type":"uint256"}],"name":"Paid","type":"event"}
+]`
+
+func TestEventDecode(t *testing.T) {
+	a, err := NewABI(eventsAbi)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transfer, err := a.Event("Transfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if topic := transfer.Topic(); topic != "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef" {
+		t.Fatalf("Expected: 0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef, received: %s", topic)
+	}
+
+	log := txn.Log{
+		Topics: []string{
+			"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+			"0x" + word("19e7e376e7c213b7e7e7e46cc70a5dd086daff2a"),
+			"0x" + word("73b647cba2fe75ba05b8e12ef8f8d6327d6367bf"),
+		},
+		Data: mustDecodeHex(t, word("3e8")),
+	}
+
+	var out struct {
+		From  accnt.Address
+		To    string
+		Value *big.Int
+	}
+	ev, err := a.DecodeLog(log, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Name != "Transfer" {
+		t.Fatalf("Expected: Transfer, received: %s", ev.Name)
+	}
+	from, _ := accnt.NewAddress("0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a")
+	if !reflect.DeepEqual(out.From, from) || out.To != "0x73b647cba2fe75ba05b8e12ef8f8d6327d6367bf" ||
+		out.Value.Int64() != 1000 {
+		t.Fatalf("Unexpected result: %+v", out)
+	}
+
+	// Indexed strings are logged as their hash.
+	stored, _ := a.Event("Stored")
+	log = txn.Log{
+		Topics: []string{
+			stored.Topic(),
+			"0x1c8aff950685c2ed4bc3174f3472287b56d9517b9c948127319a09a7a36deac8",
+			"0x" + word("7"),
+		},
+		Data: mustDecodeHex(t, word("20")+word("5")+text("world")),
+	}
+	var vals []interface{}
+	if err := stored.Decode(log, &vals); err != nil {
+		t.Fatal(err)
+	}
+	expected := []interface{}{
+		mustDecodeHex(t, "1c8aff950685c2ed4bc3174f3472287b56d9517b9c948127319a09a7a36deac8"),
+		"world",
+		big.NewInt(7),
+	}
+	if !reflect.DeepEqual(vals, expected) {
+		t.Fatalf("Expected: %#v, received: %#v", expected, vals)
+	}
+
+	// Anonymous events have no topic0.
+	paid, _ := a.Event("Paid")
+	log = txn.Log{
+		Topics: []string{"0x" + word("19e7e376e7c213b7e7e7e46cc70a5dd086daff2a")},
+		Data:   mustDecodeHex(t, word("2")),
+	}
+	var p struct {
+		Who    string
+		Amount uint64
+	}
+	if err := paid.Decode(log, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Who != "0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a" || p.Amount != 2 {
+		t.Fatalf("Unexpected result: %+v", p)
+	}
+
+	if err := transfer.Decode(txn.Log{Topics: []string{stored.Topic()}}, &out); err == nil {
+		t.Fatal("Expected error for log of another event")
+	}
+	if err := transfer.Decode(txn.Log{Topics: []string{transfer.Topic()}}, &out); err == nil {
+		t.Fatal("Expected error for missing topics")
+	}
+	if _, err := a.DecodeLog(txn.Log{}, &out); err == nil {
+		t.Fatal("Expected error for log without topics")
+	}
+}
+
+func TestEventTopics(t *testing.T) {
+	a, err := NewABI(eventsAbi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transfer, _ := a.Event("Transfer")
+	stored, _ := a.Event("Stored")
+	paid, _ := a.Event("Paid")
+
+	to, _ := accnt.NewAddress("0x73b647cba2fe75ba05b8e12ef8f8d6327d6367bf")
+
+	var tests = []struct {
+		event    Event
+		args     []interface{}
+		expected [][]string
+	}{
+		{transfer, nil, [][]string{{transfer.Topic()}}},
+		{transfer, []interface{}{nil, to}, [][]string{{transfer.Topic()}, nil,
+			{"0x" + word("73b647cba2fe75ba05b8e12ef8f8d6327d6367bf")}}},
+		{transfer, []interface{}{to, nil}, [][]string{{transfer.Topic()},
+			{"0x" + word("73b647cba2fe75ba05b8e12ef8f8d6327d6367bf")}}},
+		{stored, []interface{}{"hello"}, [][]string{{stored.Topic()},
+			{"0x1c8aff950685c2ed4bc3174f3472287b56d9517b9c948127319a09a7a36deac8"}}},
+		{paid, []interface{}{to}, [][]string{{"0x" + word("73b647cba2fe75ba05b8e12ef8f8d6327d6367bf")}}},
+	}
+
+	for _, test := range tests {
+		topics, err := test.event.Topics(test.args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(topics, test.expected) {
+			t.Fatalf("%s: Expected: %v, received: %v", test.event.Name, test.expected, topics)
+		}
+	}
+
+	if _, err := transfer.Topics(to, to, to); err == nil {
+		t.Fatal("Expected error for too many args")
+	}
+}
+
+func TestEventTopicSets(t *testing.T) {
+	a, err := NewABI(eventsAbi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transfer, _ := a.Event("Transfer")
+
+	from, _ := accnt.NewAddress("0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a")
+	to, _ := accnt.NewAddress("0x73b647cba2fe75ba05b8e12ef8f8d6327d6367bf")
+
+	topics, err := transfer.TopicSets(nil, []interface{}{from, to})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{{transfer.Topic()}, nil, {"0x" + word("19e7e376e7c213b7e7e7e46cc70a5dd086daff2a"),
+		"0x" + word("73b647cba2fe75ba05b8e12ef8f8d6327d6367bf")}}
+	if !reflect.DeepEqual(topics, expected) {
+		t.Fatalf("Expected: %v, received: %v", expected, topics)
+	}
+}
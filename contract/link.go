@@ -0,0 +1,315 @@
+package contract
+
+import (
+	"encoding/hex"
+	"errors"
+	"ethereum/accnt"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// placeholderLen is the length of a library placeholder in hex encoded code:
+// the 20 byte address it stands for.
+const placeholderLen = 40
+
+// LinkRef is a placeholder in a contract's code for the address of a library.
+type LinkRef struct {
+	// Library is the fully qualified name of the library, file:Name.  Code
+	// from older compilers only gives it as a placeholder, truncated to 36
+	// characters, and newer ones as the hash of the name between $'s.
+	Library string
+	Offset  int // byte offset of the address in the code
+}
+
+// decodeCode decodes hex encoded code, which may hold library placeholders,
+// returning their positions with the placeholders zeroed.  refs holds the
+// library of each placeholder by file, name and byte offset, as solc's
+// linkReferences output.  Placeholders missing from refs are named after
+// their text.
+func decodeCode(code string, refs map[string]map[string][]struct{ Start, Length int }) ([]byte, []LinkRef, error) {
+	code = strings.TrimPrefix(strings.TrimSpace(code), "0x")
+
+	names := make(map[int]string)
+	for file, libs := range refs {
+		for name, rs := range libs {
+			for _, r := range rs {
+				names[r.Start] = file + ":" + name
+			}
+		}
+	}
+
+	var (
+		links []LinkRef
+		buf   = []byte(code)
+	)
+	for i := 0; i+1 < len(buf); i += 2 {
+		if buf[i] != '_' || buf[i+1] != '_' {
+			continue
+		}
+		if i+placeholderLen > len(buf) {
+			return nil, nil, fmt.Errorf("truncated library placeholder at %d", i/2)
+		}
+
+		name, ok := names[i/2]
+		if !ok {
+			name = strings.Trim(code[i:i+placeholderLen], "_")
+		}
+		links = append(links, LinkRef{Library: name, Offset: i / 2})
+
+		for j := i; j < i+placeholderLen; j++ {
+			buf[j] = '0'
+		}
+		i += placeholderLen - 2
+	}
+
+	bin, err := hex.DecodeString(string(buf))
+	if err != nil {
+		return nil, nil, err
+	}
+	return bin, links, nil
+}
+
+// Libraries returns the fully qualified names of the libraries which c must
+// be linked with before it can be deployed.
+func (c Contract) Libraries() []string {
+	return libraries(c.LinkRefs)
+}
+
+// Link returns a copy of c with the addresses of libraries filled into its
+// code.  libs is keyed by fully qualified library names, or just their names
+// if unambiguous.  Libraries missing from libs are left unlinked.
+func (c Contract) Link(libs map[string]accnt.Address) (Contract, error) {
+	bin, refs, err := link(c.Bin, c.LinkRefs, libs)
+	if err != nil {
+		return Contract{}, err
+	}
+	c.Bin, c.LinkRefs = bin, refs
+	return c, nil
+}
+
+// Link returns a copy of c with its code and deployed code linked, as by
+// Contract.Link.
+func (c Compiled) Link(libs map[string]accnt.Address) (Compiled, error) {
+	contract, err := c.Contract.Link(libs)
+	if err != nil {
+		return Compiled{}, err
+	}
+	c.Contract = contract
+
+	if c.DeployedBin, c.DeployedLinkRefs, err = link(c.DeployedBin, c.DeployedLinkRefs, libs); err != nil {
+		return Compiled{}, err
+	}
+	return c, nil
+}
+
+func link(code []byte, refs []LinkRef, libs map[string]accnt.Address) ([]byte, []LinkRef, error) {
+	code = append([]byte{}, code...)
+
+	var unlinked []LinkRef
+	for _, r := range refs {
+		addr, ok, err := lookupLibrary(r.Library, libs)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			unlinked = append(unlinked, r)
+			continue
+		}
+
+		if len(addr) != 20 {
+			return nil, nil, fmt.Errorf("invalid address %s for library %s", addr, r.Library)
+		}
+		if r.Offset+len(addr) > len(code) {
+			return nil, nil, fmt.Errorf("library %s is linked past the end of the code", r.Library)
+		}
+		copy(code[r.Offset:], addr)
+	}
+	return code, unlinked, nil
+}
+
+// lookupLibrary returns the address of a library given a placeholder's name
+// for it.
+func lookupLibrary(library string, libs map[string]accnt.Address) (accnt.Address, bool, error) {
+	var (
+		addr  accnt.Address
+		found string
+	)
+	for name, a := range libs {
+		if !libraryMatches(library, name) {
+			continue
+		}
+		if found != "" && !strings.EqualFold(a.String(), addr.String()) {
+			return nil, false, fmt.Errorf("library %s is ambiguous between %s and %s", library, found, name)
+		}
+		addr, found = a, name
+	}
+	return addr, found != "", nil
+}
+
+// libraryMatches reports whether a placeholder's name for a library refers to
+// the library with the given name.
+func libraryMatches(library, name string) bool {
+	if library == name {
+		return true
+	}
+
+	// Name given as its hash.
+	if strings.HasPrefix(library, "$") {
+		return library == libraryHash(name)
+	}
+
+	// Name truncated by an older compiler.
+	if len(library) == placeholderLen-4 && strings.HasPrefix(name, library) {
+		return true
+	}
+
+	// Either name without its file.
+	if !strings.Contains(library, ":") || !strings.Contains(name, ":") {
+		return library[strings.LastIndex(library, ":")+1:] == name[strings.LastIndex(name, ":")+1:]
+	}
+	return false
+}
+
+// libraryHash returns the name solc gives a library in its placeholders: the
+// hash of its fully qualified name between $'s.
+func libraryHash(name string) string {
+	return "$" + hex.EncodeToString(crypto.Keccak256([]byte(name)))[:placeholderLen-6] + "$"
+}
+
+func libraries(refs []LinkRef) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, r := range refs {
+		if !seen[r.Library] {
+			seen[r.Library] = true
+			names = append(names, r.Library)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DeployPlan is the order to deploy a contract and the libraries it uses in,
+// so that every contract is linked with libraries deployed before it.
+type DeployPlan struct {
+	// Steps holds the keys of the contracts to deploy, libraries first and
+	// the contract planned for last.
+	Steps []string
+
+	contracts map[string]Compiled
+	deployed  map[string]accnt.Address
+}
+
+// PlanDeploy plans deploying the contract keyed by target in contracts,
+// along with the libraries it uses, directly or through other libraries.
+// Libraries in deployed are linked without being deployed again.
+func PlanDeploy(contracts map[string]Compiled, target string, deployed map[string]accnt.Address) (DeployPlan, error) {
+	p := DeployPlan{
+		contracts: contracts,
+		deployed:  make(map[string]accnt.Address),
+	}
+	for name, addr := range deployed {
+		p.deployed[name] = addr
+	}
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+
+	var visit func(key string, path []string) error
+	visit = func(key string, path []string) error {
+		switch state[key] {
+		case visiting:
+			return fmt.Errorf("libraries link each other: %s", strings.Join(append(path, key), " -> "))
+		case done:
+			return nil
+		}
+		state[key] = visiting
+
+		c := contracts[key]
+		for _, lib := range libraries(append(append([]LinkRef{}, c.LinkRefs...), c.DeployedLinkRefs...)) {
+			if _, ok, err := lookupLibrary(lib, p.deployed); err != nil {
+				return err
+			} else if ok {
+				continue
+			}
+
+			libKey, err := p.libraryKey(lib)
+			if err != nil {
+				return fmt.Errorf("%s: %s", key, err)
+			}
+			if err := visit(libKey, append(path, key)); err != nil {
+				return err
+			}
+		}
+
+		state[key] = done
+		p.Steps = append(p.Steps, key)
+		return nil
+	}
+
+	if _, ok := contracts[target]; !ok {
+		return DeployPlan{}, fmt.Errorf("no contract %s", target)
+	}
+	if err := visit(target, nil); err != nil {
+		return DeployPlan{}, err
+	}
+	return p, nil
+}
+
+// libraryKey returns the key of the contract which a placeholder's name for
+// a library refers to.
+func (p DeployPlan) libraryKey(library string) (string, error) {
+	var matches []string
+	for _, key := range Names(p.contracts) {
+		if libraryMatches(library, key) {
+			matches = append(matches, key)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no library %s", library)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("library %s is ambiguous: %s", library, strings.Join(matches, ", "))
+	}
+}
+
+// Execute deploys the contracts of the plan in order, linking each with the
+// libraries deployed before it.  deploy is called to deploy each linked
+// contract and returns its address.  The addresses of all libraries and the
+// contract planned for are returned, keyed as in Steps.
+func (p DeployPlan) Execute(deploy func(key string, c Contract) (accnt.Address, error)) (map[string]accnt.Address, error) {
+	if deploy == nil {
+		return nil, errors.New("no deploy func")
+	}
+
+	addrs := make(map[string]accnt.Address)
+	for name, addr := range p.deployed {
+		addrs[name] = addr
+	}
+
+	for _, key := range p.Steps {
+		c, err := p.contracts[key].Contract.Link(addrs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", key, err)
+		}
+		if libs := c.Libraries(); len(libs) > 0 {
+			return nil, fmt.Errorf("%s: unlinked libraries %s", key, strings.Join(libs, ", "))
+		}
+
+		addr, err := deploy(key, c)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", key, err)
+		}
+		addrs[key] = addr
+	}
+	return addrs, nil
+}
@@ -0,0 +1,188 @@
+package contract
+
+import (
+	"ethereum/accnt"
+	"ethereum/txn"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeCode(t *testing.T) {
+	libHash := libraryHash("lib.sol:Math")
+	if len(libHash) != 36 {
+		t.Fatalf("Expected: 36 characters, received: %s", libHash)
+	}
+
+	var tests = []struct {
+		code     string
+		refs     map[string]map[string][]struct{ Start, Length int }
+		expected []LinkRef
+	}{
+		// Placeholders named by solc's link references.
+		{
+			code: "73__" + libHash + "__6000" + "73__" + libHash + "__",
+			refs: map[string]map[string][]struct{ Start, Length int }{
+				"lib.sol": {"Math": {{1, 20}, {24, 20}}},
+			},
+			expected: []LinkRef{{"lib.sol:Math", 1}, {"lib.sol:Math", 24}},
+		},
+		// Placeholders of older compilers.
+		{
+			code:     "6000" + "73" + placeholder("lib.sol:Math") + "00",
+			expected: []LinkRef{{"lib.sol:Math", 3}},
+		},
+		{
+			code:     "0x73__" + libHash + "__",
+			expected: []LinkRef{{libHash, 1}},
+		},
+		{
+			code: "6060",
+		},
+	}
+
+	for _, test := range tests {
+		bin, refs, err := decodeCode(test.code, test.refs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(refs, test.expected) {
+			t.Fatalf("Expected: %v, received: %v", test.expected, refs)
+		}
+		if len(bin) != (len(strings.TrimPrefix(test.code, "0x")))/2 {
+			t.Fatalf("Expected: %d bytes, received: %d", len(test.code)/2, len(bin))
+		}
+		for _, r := range refs {
+			if !reflect.DeepEqual(bin[r.Offset:r.Offset+20], make([]byte, 20)) {
+				t.Fatalf("Expected placeholder zeroed, received: %x", bin)
+			}
+		}
+	}
+
+	if _, _, err := decodeCode("73__lib.sol:Math__", nil); err == nil {
+		t.Fatal("Expected error for truncated placeholder")
+	}
+}
+
+func TestLink(t *testing.T) {
+	lib, _ := accnt.NewAddress("0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a")
+
+	bin, refs, err := decodeCode("73__"+libraryHash("lib.sol:Math")+"__73"+placeholder("lib.sol:Strings"),
+		map[string]map[string][]struct{ Start, Length int }{"lib.sol": {"Math": {{1, 20}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := Contract{Bin: bin, LinkRefs: refs}
+
+	if libs := c.Libraries(); !reflect.DeepEqual(libs, []string{"lib.sol:Math", "lib.sol:Strings"}) {
+		t.Fatalf("Expected: [lib.sol:Math lib.sol:Strings], received: %v", libs)
+	}
+
+	var tx txn.Transaction
+	if err := c.Deploy(&tx); err == nil {
+		t.Fatal("Expected error deploying unlinked contract")
+	}
+
+	// Libraries are matched by name when unambiguous.
+	linked, err := c.Link(map[string]accnt.Address{"Math": lib})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(linked.LinkRefs, []LinkRef{{"lib.sol:Strings", 22}}) {
+		t.Fatalf("Expected: [{lib.sol:Strings 22}], received: %v", linked.LinkRefs)
+	}
+	if !reflect.DeepEqual(linked.Bin[1:21], []byte(lib)) {
+		t.Fatalf("Expected: %x, received: %x", []byte(lib), linked.Bin[1:21])
+	}
+	if !reflect.DeepEqual(c.Bin[1:21], make([]byte, 20)) {
+		t.Fatal("Expected Link to leave the original contract unlinked")
+	}
+
+	linked, err = linked.Link(map[string]accnt.Address{"lib.sol:Strings": lib})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := linked.Deploy(&tx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Link(map[string]accnt.Address{"Math": lib[:4]}); err == nil {
+		t.Fatal("Expected error for invalid address")
+	}
+}
+
+func TestPlanDeploy(t *testing.T) {
+	code := func(libs ...string) Compiled {
+		var s string
+		for _, l := range libs {
+			s += "73" + placeholder(l)
+		}
+		bin, refs, err := decodeCode(s+"00", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return Compiled{Contract: Contract{Bin: bin, LinkRefs: refs}}
+	}
+
+	contracts := map[string]Compiled{
+		"main.sol:Main":  code("lib.sol:A", "lib.sol:B"),
+		"lib.sol:A":      code("lib.sol:B", "lib.sol:C"),
+		"lib.sol:B":      code("lib.sol:C"),
+		"lib.sol:C":      code(),
+		"cycle.sol:X":    code("cycle.sol:Y"),
+		"cycle.sol:Y":    code("cycle.sol:X"),
+		"missing.sol:Me": code("lib.sol:Nope"),
+	}
+
+	p, err := PlanDeploy(contracts, "main.sol:Main", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"lib.sol:C", "lib.sol:B", "lib.sol:A", "main.sol:Main"}
+	if !reflect.DeepEqual(p.Steps, expected) {
+		t.Fatalf("Expected: %v, received: %v", expected, p.Steps)
+	}
+
+	var n byte
+	addrs, err := p.Execute(func(key string, c Contract) (accnt.Address, error) {
+		for _, r := range contracts[key].LinkRefs {
+			if reflect.DeepEqual(c.Bin[r.Offset:r.Offset+20], make([]byte, 20)) {
+				t.Fatalf("%s deployed without %s", key, r.Library)
+			}
+		}
+		n++
+		return accnt.Address(append(make([]byte, 19), n)), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 4 || addrs["main.sol:Main"][19] != 4 {
+		t.Fatalf("Unexpected addresses: %v", addrs)
+	}
+
+	// Deployed libraries aren't deployed again.
+	c, _ := accnt.NewAddress("0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a")
+	p, err = PlanDeploy(contracts, "main.sol:Main", map[string]accnt.Address{"C": c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{"lib.sol:B", "lib.sol:A", "main.sol:Main"}
+	if !reflect.DeepEqual(p.Steps, expected) {
+		t.Fatalf("Expected: %v, received: %v", expected, p.Steps)
+	}
+
+	if _, err := PlanDeploy(contracts, "cycle.sol:X", nil); err == nil {
+		t.Fatal("Expected error for libraries linking each other")
+	}
+	if _, err := PlanDeploy(contracts, "missing.sol:Me", nil); err == nil {
+		t.Fatal("Expected error for missing library")
+	}
+	if _, err := PlanDeploy(contracts, "none.sol:None", nil); err == nil {
+		t.Fatal("Expected error for missing contract")
+	}
+}
+
+// placeholder returns an older compiler's placeholder for a library.
+func placeholder(name string) string {
+	return "__" + name + strings.Repeat("_", placeholderLen-2-len(name))
+}
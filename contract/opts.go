@@ -0,0 +1,106 @@
+package contract
+
+import (
+	"errors"
+	"ethereum/accnt"
+	"ethereum/txn"
+	"fmt"
+	"math/big"
+)
+
+// CallOpts are overrides for calling a contract function with eth_call or
+// in a transaction.  All are optional.
+type CallOpts struct {
+	From     accnt.Address // sender of eth_call; transactions are sent by their signer
+	Value    *big.Int
+	GasLimit *big.Int
+	GasPrice *big.Int
+	Nonce    *uint64 // transactions only
+
+	// Fee market fields, for eth_call only, as transactions are legacy.
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+
+	// Block to call at, by number or tag, e.g. "pending".  The latest block
+	// if neither is set.  For eth_call only.
+	BlockNumber *big.Int
+	BlockTag    string
+}
+
+// CheckCall returns an error if opts can't be used to call f with eth_call.
+func (opts CallOpts) CheckCall(f Function) error {
+	if opts.BlockNumber != nil && opts.BlockTag != "" {
+		return errors.New("call opts: both block number and tag given")
+	}
+	if opts.GasPrice != nil && (opts.MaxFeePerGas != nil || opts.MaxPriorityFeePerGas != nil) {
+		return errors.New("call opts: both gas price and fee market fields given")
+	}
+	if opts.Nonce != nil {
+		return errors.New("call opts: nonce given for a call")
+	}
+	return checkValue(f, opts.Value)
+}
+
+// CheckTransact returns an error if opts can't be used to call f in a
+// transaction.
+func (opts CallOpts) CheckTransact(f Function) error {
+	if opts.BlockNumber != nil || opts.BlockTag != "" {
+		return errors.New("call opts: block given for a transaction")
+	}
+	if opts.MaxFeePerGas != nil || opts.MaxPriorityFeePerGas != nil {
+		return errors.New("call opts: fee market fields given for a legacy transaction")
+	}
+	if f.Constant {
+		return fmt.Errorf("%s is %s, call it instead of transacting", f.Name, f.StateMutability)
+	}
+	return checkValue(f, opts.Value)
+}
+
+func checkValue(f Function, value *big.Int) error {
+	if value != nil && value.Sign() != 0 && !f.Payable {
+		name := f.Name
+		if f.Type == "constructor" {
+			name = "constructor"
+		}
+		return fmt.Errorf("%s is not payable, but value %s given", name, value)
+	}
+	return nil
+}
+
+// Transact sets the transaction's data to call the named function with args,
+// and its recipient to the contract if not set.  Fields given in opts
+// override those of the transaction.
+func (c Contract) Transact(opts CallOpts, funcName string, t *txn.Transaction, args ...interface{}) error {
+	f, err := c.Abi.Function(funcName, args...)
+	if err != nil {
+		return err
+	}
+
+	if opts.Value == nil {
+		opts.Value = t.Value
+	}
+	if err := opts.CheckTransact(f); err != nil {
+		return err
+	}
+
+	data, err := f.Encode(args...)
+	if err != nil {
+		return err
+	}
+
+	t.Data = data
+	if t.To == "" {
+		t.To = c.Address
+	}
+	t.Value = opts.Value
+	if opts.GasLimit != nil {
+		t.GasLimit = opts.GasLimit
+	}
+	if opts.GasPrice != nil {
+		t.GasPrice = opts.GasPrice
+	}
+	if opts.Nonce != nil {
+		t.Nonce = *opts.Nonce
+	}
+	return nil
+}
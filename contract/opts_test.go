@@ -0,0 +1,103 @@
+package contract
+
+import (
+	"ethereum/accnt"
+	"ethereum/txn"
+	"math/big"
+	"testing"
+)
+
+const payableAbi = `[
+	{"inputs":[],"name":"deposit","outputs":[],"stateMutability":"payable","type":"function"},
+	{"inputs":[{"name":"to","type":"address"}],"name":"withdraw","outputs":[],"stateMutability":"nonpayable",
+	 "type":"function"},
+	{"inputs":[],"name":"balance","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"}
+]`
+
+func TestTransact(t *testing.T) {
+	c, err := New(payableAbi, "0x73b647cba2fe75ba05b8e12ef8f8d6327d6367bf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := uint64(5)
+	opts := CallOpts{
+		Value:    big.NewInt(1000),
+		GasLimit: big.NewInt(50000),
+		GasPrice: big.NewInt(2e9),
+		Nonce:    &nonce,
+	}
+
+	tx := txn.Transaction{GasLimit: big.NewInt(21000)}
+	if err := c.Transact(opts, "deposit", &tx); err != nil {
+		t.Fatal(err)
+	}
+	if tx.To != c.Address || tx.Value.Int64() != 1000 || tx.GasLimit.Int64() != 50000 ||
+		tx.GasPrice.Int64() != 2e9 || tx.Nonce != 5 || len(tx.Data) != 4 {
+		t.Fatalf("Unexpected transaction: %+v", tx)
+	}
+
+	// The transaction's own value is checked too.
+	addr, _ := accnt.NewAddress("0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a")
+	tx = txn.Transaction{To: "0x1563915e194d8cfba1943570603f7606a3115508", Value: big.NewInt(1)}
+	if err := c.Call("withdraw", &tx, addr); err == nil {
+		t.Fatal("Expected error for value sent to non-payable function")
+	}
+	tx.Value = nil
+	if err := c.Call("withdraw", &tx, addr); err != nil {
+		t.Fatal(err)
+	}
+	if tx.To != "0x1563915e194d8cfba1943570603f7606a3115508" {
+		t.Fatalf("Expected: 0x1563915e194d8cfba1943570603f7606a3115508, received: %s", tx.To)
+	}
+
+	var tests = []struct {
+		opts     CallOpts
+		funcName string
+	}{
+		{CallOpts{}, "balance"},
+		{CallOpts{Value: big.NewInt(1)}, "withdraw"},
+		{CallOpts{BlockTag: "pending"}, "deposit"},
+		{CallOpts{MaxFeePerGas: big.NewInt(1)}, "deposit"},
+	}
+	for _, test := range tests {
+		args := []interface{}{}
+		if test.funcName == "withdraw" {
+			args = append(args, addr)
+		}
+		if err := c.Transact(test.opts, test.funcName, &txn.Transaction{}, args...); err == nil {
+			t.Fatalf("Expected error transacting %s with %+v", test.funcName, test.opts)
+		}
+	}
+}
+
+func TestCheckCall(t *testing.T) {
+	a, err := NewABI(payableAbi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deposit, _ := a.Function("deposit")
+	balance, _ := a.Function("balance")
+
+	nonce := uint64(1)
+	var tests = []struct {
+		opts  CallOpts
+		f     Function
+		valid bool
+	}{
+		{CallOpts{}, balance, true},
+		{CallOpts{BlockNumber: big.NewInt(10), From: accnt.Address(make([]byte, 20))}, balance, true},
+		{CallOpts{Value: big.NewInt(1)}, deposit, true},
+		{CallOpts{MaxFeePerGas: big.NewInt(2), MaxPriorityFeePerGas: big.NewInt(1)}, balance, true},
+		{CallOpts{Value: big.NewInt(1)}, balance, false},
+		{CallOpts{BlockNumber: big.NewInt(10), BlockTag: "pending"}, balance, false},
+		{CallOpts{GasPrice: big.NewInt(1), MaxFeePerGas: big.NewInt(2)}, balance, false},
+		{CallOpts{Nonce: &nonce}, balance, false},
+	}
+
+	for i, test := range tests {
+		if err := test.opts.CheckCall(test.f); (err == nil) != test.valid {
+			t.Fatalf("%d: Expected valid: %v, received: %v", i, test.valid, err)
+		}
+	}
+}
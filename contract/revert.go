@@ -0,0 +1,142 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Selectors of the errors which Solidity reverts with for require, revert
+// and assert failures.
+var (
+	errorSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+	panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+)
+
+// panicReasons describes the codes which Solidity panics with.
+var panicReasons = map[uint64]string{
+	0x00: "generic compiler panic",
+	0x01: "assert(false)",
+	0x11: "arithmetic overflow or underflow",
+	0x12: "division or modulo by zero",
+	0x21: "conversion of invalid value to enum",
+	0x22: "incorrectly encoded storage byte array",
+	0x31: "pop of empty array",
+	0x32: "array index out of bounds",
+	0x41: "out of memory",
+	0x51: "call to zero-initialized internal function",
+}
+
+// RevertError is a call or transaction reverting.  At most one of Reason,
+// Panic and Custom is set, depending on what the revert data holds.
+type RevertError struct {
+	Data []byte // raw revert data, empty if unknown
+
+	// Reason is the message of a require or revert, i.e. of Error(string).
+	Reason string
+
+	// Panic is the code of a Panic(uint256), such as a failing assert or an
+	// arithmetic overflow.
+	Panic *big.Int
+
+	// Custom is the custom error of the contract's ABI reverted with, whose
+	// arguments are in Args.
+	Custom *Error
+	Args   []interface{}
+}
+
+func (e *RevertError) Error() string {
+	switch {
+	case e.Reason != "":
+		return "execution reverted: " + e.Reason
+	case e.Panic != nil:
+		return fmt.Sprintf("execution reverted: panic 0x%x (%s)", e.Panic, PanicReason(e.Panic))
+	case e.Custom != nil:
+		args := make([]string, len(e.Args))
+		for i, a := range e.Args {
+			args[i] = formatArg(a)
+		}
+		return fmt.Sprintf("execution reverted: %s(%s)", e.Custom.Name, strings.Join(args, ", "))
+	case len(e.Data) > 0:
+		return "execution reverted: 0x" + hex.EncodeToString(e.Data)
+	}
+	return "execution reverted"
+}
+
+// PanicReason describes a panic code.
+func PanicReason(code *big.Int) string {
+	if code.IsUint64() {
+		if r, ok := panicReasons[code.Uint64()]; ok {
+			return r
+		}
+	}
+	return "unknown panic code"
+}
+
+// DecodeRevert decodes revert data holding an Error(string) or a
+// Panic(uint256).  Other data, such as custom errors, is only kept in Data;
+// see ABI.DecodeRevert.
+func DecodeRevert(data []byte) *RevertError {
+	return ABI{}.DecodeRevert(data)
+}
+
+// DecodeRevert decodes revert data holding an Error(string), a
+// Panic(uint256) or one of the custom errors in the ABI.  Data which can't be
+// decoded is kept in Data.
+func (a ABI) DecodeRevert(data []byte) *RevertError {
+	e := &RevertError{Data: data}
+	if len(data) < 4 {
+		return e
+	}
+	sel, args := data[:4], data[4:]
+
+	switch {
+	case bytes.Equal(sel, errorSelector):
+		var reason string
+		if err := DecodeArgs([]Type{{Kind: StringKind}}, nil, args, &reason); err == nil {
+			e.Reason = reason
+		}
+	case bytes.Equal(sel, panicSelector):
+		var code *big.Int
+		if err := DecodeArgs([]Type{{Kind: UintKind, Size: 256}}, nil, args, &code); err == nil {
+			e.Panic = code
+		}
+	default:
+		for _, ce := range a.Errors {
+			if !bytes.Equal(ce.Id(), sel) {
+				continue
+			}
+
+			var vals []interface{}
+			if err := decodeParams(ce.Inputs, args, &vals); err == nil {
+				ce := ce
+				e.Custom, e.Args = &ce, vals
+			}
+			break
+		}
+	}
+	return e
+}
+
+// Id returns the 4 byte selector of the error.
+func (e Error) Id() []byte {
+	sig, err := e.Signature()
+	if err != nil {
+		return nil
+	}
+	return crypto.Keccak256([]byte(sig))[:4]
+}
+
+func formatArg(a interface{}) string {
+	switch a := a.(type) {
+	case string:
+		return fmt.Sprintf("%q", a)
+	case []byte:
+		return "0x" + hex.EncodeToString(a)
+	}
+	return fmt.Sprint(a)
+}
@@ -0,0 +1,55 @@
+package contract
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeRevert(t *testing.T) {
+	a, err := NewABI(`[{"inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}],` +
+		`"name":"InsufficientBalance","type":"error"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		data     string
+		expected string
+	}{
+		{"08c379a0" + word("20") + word("11") + text("Not enough Ether."), "execution reverted: Not enough Ether."},
+		{"4e487b71" + word("11"), "execution reverted: panic 0x11 (arithmetic overflow or underflow)"},
+		{"4e487b71" + word("99"), "execution reverted: panic 0x99 (unknown panic code)"},
+		{"cf479181" + word("a") + word("14"), "execution reverted: InsufficientBalance(10, 20)"},
+		{"deadbeef", "execution reverted: 0xdeadbeef"},
+		{"", "execution reverted"},
+	}
+
+	for _, test := range tests {
+		e := a.DecodeRevert(mustDecodeHex(t, test.data))
+		if msg := e.Error(); msg != test.expected {
+			t.Fatalf("Expected: %s, received: %s", test.expected, msg)
+		}
+	}
+
+	e := a.DecodeRevert(mustDecodeHex(t, "cf479181"+word("a")+word("14")))
+	if e.Custom == nil || e.Custom.Name != "InsufficientBalance" {
+		t.Fatalf("Unexpected custom error: %+v", e.Custom)
+	}
+	if expected := []interface{}{big.NewInt(10), big.NewInt(20)}; !reflect.DeepEqual(e.Args, expected) {
+		t.Fatalf("Expected: %v, received: %v", expected, e.Args)
+	}
+
+	// Custom errors are only known from an ABI.
+	if e := DecodeRevert(mustDecodeHex(t, "cf479181"+word("a")+word("14"))); e.Custom != nil {
+		t.Fatalf("Unexpected custom error: %+v", e.Custom)
+	}
+	if e := DecodeRevert(mustDecodeHex(t, "4e487b71"+word("1"))); e.Panic.Int64() != 1 {
+		t.Fatalf("Expected: panic 1, received: %v", e.Panic)
+	}
+
+	// Malformed data is kept undecoded.
+	if e := DecodeRevert(mustDecodeHex(t, "08c379a0"+word("20"))); e.Reason != "" || len(e.Data) != 36 {
+		t.Fatalf("Unexpected result: %+v", e)
+	}
+}
@@ -0,0 +1,398 @@
+package contract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// solcFileRegexp matches the names of solc binaries, e.g. solc-v0.8.4 or
+// solc-linux-amd64-v0.8.4+commit.c7e474f2, capturing the version.
+var solcFileRegexp = regexp.MustCompile(`^solc.*?-v?(\d+\.\d+\.\d+)(\+commit\.[0-9a-f]+)?(\.exe)?$`)
+
+// solcList is the list.json published with solc binaries, giving their
+// checksums.
+type solcList struct {
+	Builds []struct {
+		Path    string
+		Version string
+		Sha256  string
+	}
+}
+
+// SolcVersions returns the solc binaries in dir by version.  A binary is
+// named with its version, as in solc-v0.8.4 or
+// solc-linux-amd64-v0.8.4+commit.c7e474f2, and must be listed in the
+// list.json in dir, as published with the binaries.  If several binaries
+// have the same version, the first by name is used.
+func SolcVersions(dir string) (map[Version]string, error) {
+	list, err := readSolcList(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[Version]string)
+	for _, f := range files {
+		m := solcFileRegexp.FindStringSubmatch(f.Name())
+		if m == nil || f.IsDir() {
+			continue
+		}
+		if _, ok := list[f.Name()]; !ok {
+			continue
+		}
+
+		v, err := ParseVersion(m[1])
+		if err != nil {
+			return nil, err
+		}
+		// ReadDir sorts files by name.
+		if _, ok := versions[v]; ok {
+			continue
+		}
+		versions[v] = filepath.Join(dir, f.Name())
+	}
+	return versions, nil
+}
+
+// SelectSolc returns the path and version of the latest solc binary in dir
+// in the range r, after verifying its checksum.  See SolcVersions for the
+// layout of dir.
+func SelectSolc(dir string, r VersionRange) (string, Version, error) {
+	versions, err := SolcVersions(dir)
+	if err != nil {
+		return "", Version{}, err
+	}
+
+	var (
+		best  Version
+		found bool
+	)
+	for v := range versions {
+		if r.Matches(v) && (!found || best.Less(v)) {
+			best, found = v, true
+		}
+	}
+	if !found {
+		return "", Version{}, fmt.Errorf("no solc %s in %s", r, dir)
+	}
+
+	path := versions[best]
+	if err := verifySolc(dir, path); err != nil {
+		return "", Version{}, err
+	}
+	return path, best, nil
+}
+
+func readSolcList(dir string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, "list.json"))
+	if err != nil {
+		return nil, fmt.Errorf("solc checksums: %s", err)
+	}
+
+	var list solcList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("solc checksums: %s", err)
+	}
+
+	sums := make(map[string]string)
+	for _, b := range list.Builds {
+		sums[b.Path] = strings.ToLower(strings.TrimPrefix(b.Sha256, "0x"))
+	}
+	return sums, nil
+}
+
+// verified caches the binaries whose checksum has been verified, by path,
+// size and modification time.
+var verified = struct {
+	sync.Mutex
+	m map[string]string
+}{m: make(map[string]string)}
+
+// verifySolc checks the sha256 of the binary at path against the list.json
+// in dir.
+func verifySolc(dir, path string) error {
+	list, err := readSolcList(dir)
+	if err != nil {
+		return err
+	}
+	expected, ok := list[filepath.Base(path)]
+	if !ok {
+		return fmt.Errorf("%s is not in the solc checksum list", path)
+	}
+
+	sum, err := fileHash(path)
+	if err != nil {
+		return err
+	}
+	if sum != expected {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", path, expected, sum)
+	}
+	return nil
+}
+
+// fileHash returns the hex encoded sha256 of the file at path.
+func fileHash(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("%s:%d:%d", path, info.Size(), info.ModTime().UnixNano())
+
+	verified.Lock()
+	sum, ok := verified.m[key]
+	verified.Unlock()
+	if ok {
+		return sum, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	sum = hex.EncodeToString(h.Sum(nil))
+
+	verified.Lock()
+	verified.m[key] = sum
+	verified.Unlock()
+	return sum, nil
+}
+
+// compile compiles in, selecting solc versions for its sources if the
+// compiler has a SolcDir.  Sources which no single version can compile
+// together are compiled separately.
+func (c Compiler) compile(in StandardInput, args ...string) (CompileOutput, error) {
+	if c.Solc != "" || c.SolcDir == "" {
+		solc := c.Solc
+		if solc == "" {
+			solc = "solc"
+		}
+		return c.run(solc, in, args...)
+	}
+
+	ranges := make(map[string]VersionRange)
+	for name, src := range in.Sources {
+		r, err := PragmaRange(src.Content)
+		if err != nil {
+			return CompileOutput{}, fmt.Errorf("%s: %s", name, err)
+		}
+		ranges[name] = r
+	}
+
+	all := VersionRange{sets: [][]comparator{nil}}
+	for _, name := range sortedKeys(ranges) {
+		all = all.intersect(ranges[name])
+	}
+	if solc, _, err := SelectSolc(c.SolcDir, all); err == nil {
+		return c.run(solc, in, args...)
+	} else if len(in.Sources) == 1 {
+		return CompileOutput{}, err
+	}
+
+	res := CompileOutput{Contracts: make(map[string]Compiled)}
+	for _, name := range sortedKeys(ranges) {
+		solc, _, err := SelectSolc(c.SolcDir, ranges[name])
+		if err != nil {
+			return CompileOutput{}, fmt.Errorf("%s: %s", name, err)
+		}
+
+		single := in
+		single.Sources = map[string]StandardSource{name: in.Sources[name]}
+		out, err := c.run(solc, single, args...)
+		if err != nil {
+			return CompileOutput{}, err
+		}
+
+		for key, cont := range out.Contracts {
+			if strings.HasPrefix(key, name+":") {
+				res.Contracts[key] = cont
+			}
+		}
+		res.Diagnostics = append(res.Diagnostics, out.Diagnostics...)
+	}
+	return res, nil
+}
+
+// run runs solc on in, or reads its output from the cache.
+func (c Compiler) run(solc string, in StandardInput, args ...string) (CompileOutput, error) {
+	input, err := json.Marshal(in)
+	if err != nil {
+		return CompileOutput{}, err
+	}
+
+	// Output can't be cached if the key doesn't cover all the files solc
+	// reads.
+	var cacheFile string
+	if imports, ok := importedSources(in); c.CacheDir != "" && ok {
+		key, err := cacheKey(solc, input, imports, args)
+		if err != nil {
+			return CompileOutput{}, err
+		}
+		cacheFile = filepath.Join(c.CacheDir, key+".json")
+
+		if output, err := ioutil.ReadFile(cacheFile); err == nil {
+			return parseStandardOutput(output)
+		}
+	}
+
+	output, err := runSolc(solc, input, args...)
+	if err != nil {
+		return CompileOutput{}, err
+	}
+
+	res, err := parseStandardOutput(output)
+	if err != nil {
+		return CompileOutput{}, err
+	}
+
+	// Only output which compiled is cached, so that errors are reported by
+	// solc afresh.
+	if cacheFile != "" {
+		if err := writeFileAtomic(cacheFile, output); err != nil {
+			return CompileOutput{}, err
+		}
+	}
+	return res, nil
+}
+
+// cacheKey returns the key to cache the output of solc for input under: the
+// hash of the binary, its args, the input and the files it imports.
+func cacheKey(solc string, input []byte, imports map[string][]byte, args []string) (string, error) {
+	path, err := exec.LookPath(solc)
+	if err != nil {
+		return "", err
+	}
+	sum, err := fileHash(path)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n", sum, strings.Join(args, " "))
+	h.Write(input)
+	for _, name := range sortedKeys(imports) {
+		fmt.Fprintf(h, "\n%s\n%d\n", name, len(imports[name]))
+		h.Write(imports[name])
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+var importRegexp = regexp.MustCompile(`import\s+(?:[^"';]*\sfrom\s+)?["']([^"']+)["']`)
+
+// importedSources returns the files which the sources of in import,
+// directly or indirectly, as solc reads them from disk.  Imports are resolved
+// relative to the importing file, then through the remappings of in.  It
+// returns false if a file can't be read, or a source is only given by URLs.
+func importedSources(in StandardInput) (map[string][]byte, bool) {
+	files := make(map[string][]byte)
+
+	var visit func(name, src string) bool
+	visit = func(name, src string) bool {
+		for _, m := range importRegexp.FindAllStringSubmatch(stripComments(src), -1) {
+			imp := m[1]
+			if strings.HasPrefix(imp, "./") || strings.HasPrefix(imp, "../") {
+				imp = path.Join(path.Dir(name), imp)
+			}
+			imp = remap(in.Settings.Remappings, name, imp)
+
+			if _, ok := in.Sources[imp]; ok {
+				continue
+			}
+			if _, ok := files[imp]; ok {
+				continue
+			}
+
+			data, err := ioutil.ReadFile(filepath.FromSlash(imp))
+			if err != nil {
+				return false
+			}
+			files[imp] = data
+			if !visit(imp, string(data)) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for name, src := range in.Sources {
+		if src.Content == "" && len(src.URLs) > 0 {
+			return nil, false
+		}
+		if !visit(name, src.Content) {
+			return nil, false
+		}
+	}
+	return files, true
+}
+
+// remap applies the remapping of solc for an import of file, a
+// context:prefix=target entry of remappings, with the longest context, then
+// the longest prefix, which matches.
+func remap(remappings []string, file, imp string) string {
+	var best struct{ context, prefix, target string }
+	found := false
+	for _, r := range remappings {
+		var context string
+		if i := strings.Index(r, ":"); i >= 0 && i < strings.Index(r, "=") {
+			context, r = r[:i], r[i+1:]
+		}
+		i := strings.Index(r, "=")
+		if i < 0 {
+			continue
+		}
+		prefix, target := r[:i], r[i+1:]
+		if !strings.HasPrefix(file, context) || !strings.HasPrefix(imp, prefix) {
+			continue
+		}
+		if !found || len(context) > len(best.context) ||
+			(len(context) == len(best.context) && len(prefix) > len(best.prefix)) {
+			best.context, best.prefix, best.target = context, prefix, target
+			found = true
+		}
+	}
+	if !found {
+		return imp
+	}
+	return best.target + imp[len(best.prefix):]
+}
+
+func writeFileAtomic(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(name), filepath.Base(name)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
@@ -0,0 +1,232 @@
+package contract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeSolcDir creates a directory of fake solc binaries of the given versions
+// with their list.json.  Each binary logs its name to the calls file and
+// prints standardOutputJSON.
+func fakeSolcDir(t *testing.T, versions ...string) string {
+	dir, err := ioutil.TempDir("", "solc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "out.json")
+	if err := ioutil.WriteFile(out, []byte(standardOutputJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var list solcList
+	for _, v := range versions {
+		name := "solc-linux-amd64-v" + v + "+commit.0123abcd"
+		script := "#!/bin/sh\n# " + v + "\necho " + v + " >> " + dir + "/calls\ncat > /dev/null\ncat " + out + "\n"
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		sum := sha256.Sum256([]byte(script))
+		list.Builds = append(list.Builds, struct {
+			Path    string
+			Version string
+			Sha256  string
+		}{name, v, "0x" + hex.EncodeToString(sum[:])})
+	}
+
+	data, _ := json.Marshal(list)
+	if err := ioutil.WriteFile(filepath.Join(dir, "list.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func solcCalls(t *testing.T, dir string) []string {
+	data, err := ioutil.ReadFile(filepath.Join(dir, "calls"))
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return strings.Fields(string(data))
+}
+
+func TestSelectSolc(t *testing.T) {
+	dir := fakeSolcDir(t, "0.4.26", "0.6.12", "0.8.4", "0.8.19")
+	defer os.RemoveAll(dir)
+
+	// Of binaries with the same version, the first by name is used.
+	plain := filepath.Join(dir, "solc-v0.8.19")
+	if err := ioutil.WriteFile(plain, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadFile(filepath.Join(dir, "list.json"))
+	data = []byte(strings.Replace(string(data), `"Builds":[`, `"Builds":[{"Path":"solc-v0.8.19"},`, 1))
+	if err := ioutil.WriteFile(filepath.Join(dir, "list.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	versions, err := SolcVersions(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := versions[Version{0, 8, 19}]; filepath.Base(p) != "solc-linux-amd64-v0.8.19+commit.0123abcd" {
+		t.Fatalf("Expected: solc-linux-amd64-v0.8.19+commit.0123abcd, received: %s", p)
+	}
+
+	// Binaries missing from the list are ignored.
+	if err := ioutil.WriteFile(filepath.Join(dir, "solc-v0.9.0"), nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		rng      string
+		expected string
+	}{
+		{"^0.8.0", "0.8.19"},
+		{">=0.6.0 <0.8.5", "0.8.4"},
+		{"^0.4.0 || ^0.6.0", "0.6.12"},
+		{"*", "0.8.19"},
+	}
+
+	for _, test := range tests {
+		r, _ := ParseVersionRange(test.rng)
+		path, v, err := SelectSolc(dir, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.String() != test.expected || !strings.Contains(path, "-v"+test.expected+"+") {
+			t.Fatalf("Expected: %s, received: %s at %s", test.expected, v, path)
+		}
+	}
+
+	r, _ := ParseVersionRange("^0.5.0")
+	if _, _, err := SelectSolc(dir, r); err == nil {
+		t.Fatal("Expected error for missing version")
+	}
+
+	// A tampered binary fails verification.
+	name := filepath.Join(dir, "solc-linux-amd64-v0.8.19+commit.0123abcd")
+	if err := ioutil.WriteFile(name, []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	r, _ = ParseVersionRange("^0.8.0")
+	if _, _, err := SelectSolc(dir, r); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Fatalf("Expected checksum error, received: %v", err)
+	}
+}
+
+func TestCompilerSolcDir(t *testing.T) {
+	dir := fakeSolcDir(t, "0.6.12", "0.8.4")
+	defer os.RemoveAll(dir)
+
+	c := Compiler{SolcDir: dir, CacheDir: filepath.Join(dir, "cache")}
+
+	sources := map[string]string{
+		"a.sol": "pragma solidity ^0.8.0; contract A {}",
+		"b.sol": "pragma solidity >=0.6.0; contract A {}",
+	}
+	out, err := c.CompileSources(sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Contracts) != 3 {
+		t.Fatalf("Expected: 3 contracts, received: %v", Names(out.Contracts))
+	}
+	if calls := solcCalls(t, dir); strings.Join(calls, " ") != "0.8.4" {
+		t.Fatalf("Expected: [0.8.4], received: %v", calls)
+	}
+
+	// Repeated builds are read from the cache.
+	if _, err := c.CompileSources(sources); err != nil {
+		t.Fatal(err)
+	}
+	if calls := solcCalls(t, dir); len(calls) != 1 {
+		t.Fatalf("Expected: 1 call, received: %v", calls)
+	}
+
+	// Sources needing different versions are compiled separately.
+	sources["b.sol"] = "pragma solidity ^0.6.0; contract A {}"
+	out, err = c.CompileSources(sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := strings.Join(Names(out.Contracts), " "); names != "a.sol:A a.sol:I b.sol:A" {
+		t.Fatalf("Expected: a.sol:A a.sol:I b.sol:A, received: %s", names)
+	}
+	if calls := solcCalls(t, dir); strings.Join(calls, " ") != "0.8.4 0.8.4 0.6.12" {
+		t.Fatalf("Expected: [0.8.4 0.8.4 0.6.12], received: %v", calls)
+	}
+
+	if _, err := c.CompileSources(map[string]string{"c.sol": "pragma solidity ^0.7.0;"}); err == nil {
+		t.Fatal("Expected error for missing version")
+	}
+}
+
+func TestImportedSources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"a.sol":     `import "./lib/b.sol"; import {C} from './c.sol'; import "@oz/d.sol";`,
+		"lib/b.sol": `import "../c.sol";`,
+		"c.sol":     `contract C {}`,
+		"oz/d.sol":  `import "./e.sol";`,
+		"oz/e.sol":  `contract E {}`,
+	}
+	for name, src := range files {
+		os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755)
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	slash := func(name string) string {
+		return filepath.ToSlash(filepath.Join(dir, name))
+	}
+	in := StandardInput{Sources: map[string]StandardSource{slash("a.sol"): {Content: files["a.sol"]}}}
+	in.Settings.Remappings = []string{"@oz/=" + slash("oz") + "/"}
+	imports, ok := importedSources(in)
+	if !ok {
+		t.Fatal("Expected all imports to be read")
+	}
+
+	expected := []string{slash("c.sol"), slash("lib/b.sol"), slash("oz/d.sol"), slash("oz/e.sol")}
+	if names := sortedKeys(imports); strings.Join(names, " ") != strings.Join(expected, " ") {
+		t.Fatalf("Expected: %v, received: %v", expected, names)
+	}
+
+	// Without the remapping, @oz/d.sol can't be read, so the output isn't
+	// cached.
+	in.Settings.Remappings = nil
+	if _, ok := importedSources(in); ok {
+		t.Fatal("Expected unreadable import")
+	}
+}
+
+func TestRemap(t *testing.T) {
+	remappings := []string{"@oz/=lib/oz/", "@oz/token/=lib/token/", "old/:@oz/=lib/oz-3/"}
+
+	var tests = []struct {
+		file, imp string
+		expected  string
+	}{
+		{"a.sol", "@oz/access/Ownable.sol", "lib/oz/access/Ownable.sol"},
+		{"a.sol", "@oz/token/ERC20.sol", "lib/token/ERC20.sol"},
+		{"old/a.sol", "@oz/token/ERC20.sol", "lib/oz-3/token/ERC20.sol"},
+		{"a.sol", "b.sol", "b.sol"},
+	}
+
+	for _, test := range tests {
+		if imp := remap(remappings, test.file, test.imp); imp != test.expected {
+			t.Fatalf("Expected: %s, received: %s", test.expected, imp)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package contract
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kind is the kind of a Solidity ABI type.
+type Kind int
+
+const (
+	UintKind       Kind = iota // uint8 ... uint256
+	IntKind                    // int8 ... int256
+	BoolKind                   // bool
+	AddressKind                // address
+	FixedBytesKind             // bytes1 ... bytes32
+	BytesKind                  // bytes
+	StringKind                 // string
+	SliceKind                  // T[]
+	ArrayKind                  // T[k]
+	TupleKind                  // (T1,T2,...)
+)
+
+// Type is a parsed Solidity ABI type.
+type Type struct {
+	Kind Kind
+
+	// Size is the number of bits of integers, the number of bytes of fixed
+	// size byte arrays, and the length of fixed size arrays.
+	Size int
+
+	// Elem is the element type of slices and arrays.
+	Elem *Type
+
+	// Components are the types of a tuple's members, and ComponentNames
+	// their names as given in the ABI.
+	Components     []Type
+	ComponentNames []string
+}
+
+// NewType parses an ABI type such as "uint256", "bytes32[]" or "tuple[2]".
+// components describes the members of tuple types, and is ignored otherwise.
+func NewType(t string, components []Param) (Type, error) {
+	// Array types are parsed from the outermost dimension, which is the last
+	// one written.
+	if strings.HasSuffix(t, "]") {
+		i := strings.LastIndex(t, "[")
+		if i < 0 {
+			return Type{}, fmt.Errorf("invalid type %q", t)
+		}
+
+		elem, err := NewType(t[:i], components)
+		if err != nil {
+			return Type{}, err
+		}
+
+		dim := t[i+1 : len(t)-1]
+		if dim == "" {
+			return Type{Kind: SliceKind, Elem: &elem}, nil
+		}
+
+		n, err := strconv.Atoi(dim)
+		if err != nil || n <= 0 {
+			return Type{}, fmt.Errorf("invalid array length in %q", t)
+		}
+		return Type{Kind: ArrayKind, Size: n, Elem: &elem}, nil
+	}
+
+	switch {
+	case t == "tuple":
+		tt := Type{Kind: TupleKind}
+		for _, c := range components {
+			ct, err := NewType(c.Type, c.Components)
+			if err != nil {
+				return Type{}, err
+			}
+			tt.Components = append(tt.Components, ct)
+			tt.ComponentNames = append(tt.ComponentNames, c.Name)
+		}
+		return tt, nil
+	case t == "bool":
+		return Type{Kind: BoolKind}, nil
+	case t == "address":
+		return Type{Kind: AddressKind}, nil
+	case t == "string":
+		return Type{Kind: StringKind}, nil
+	case t == "bytes":
+		return Type{Kind: BytesKind}, nil
+	case strings.HasPrefix(t, "bytes"):
+		n, err := strconv.Atoi(t[len("bytes"):])
+		if err != nil || n < 1 || n > 32 {
+			return Type{}, fmt.Errorf("invalid type %q", t)
+		}
+		return Type{Kind: FixedBytesKind, Size: n}, nil
+	case strings.HasPrefix(t, "uint"):
+		n, err := intSize(t[len("uint"):])
+		if err != nil {
+			return Type{}, fmt.Errorf("invalid type %q: %s", t, err)
+		}
+		return Type{Kind: UintKind, Size: n}, nil
+	case strings.HasPrefix(t, "int"):
+		n, err := intSize(t[len("int"):])
+		if err != nil {
+			return Type{}, fmt.Errorf("invalid type %q: %s", t, err)
+		}
+		return Type{Kind: IntKind, Size: n}, nil
+	default:
+		return Type{}, fmt.Errorf("unsupported type %q", t)
+	}
+}
+
+// intSize parses the number of bits of an integer type, where an empty size
+// is an alias for 256.
+func intSize(s string) (int, error) {
+	if s == "" {
+		return 256, nil
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 8 || n > 256 || n%8 != 0 {
+		return 0, errors.New("size must be a multiple of 8 between 8 and 256")
+	}
+	return n, nil
+}
+
+// String returns the canonical form of the type, as used in function
+// signatures.
+func (t Type) String() string {
+	switch t.Kind {
+	case UintKind:
+		return "uint" + strconv.Itoa(t.Size)
+	case IntKind:
+		return "int" + strconv.Itoa(t.Size)
+	case BoolKind:
+		return "bool"
+	case AddressKind:
+		return "address"
+	case FixedBytesKind:
+		return "bytes" + strconv.Itoa(t.Size)
+	case BytesKind:
+		return "bytes"
+	case StringKind:
+		return "string"
+	case SliceKind:
+		return t.Elem.String() + "[]"
+	case ArrayKind:
+		return t.Elem.String() + "[" + strconv.Itoa(t.Size) + "]"
+	case TupleKind:
+		c := make([]string, len(t.Components))
+		for i, ct := range t.Components {
+			c[i] = ct.String()
+		}
+		return "(" + strings.Join(c, ",") + ")"
+	default:
+		return "unknown"
+	}
+}
+
+// IsDynamic reports whether the encoded size of the type depends on its
+// value.  Dynamic values are encoded in the tail of their enclosing tuple and
+// referenced by offset.
+func (t Type) IsDynamic() bool {
+	switch t.Kind {
+	case BytesKind, StringKind, SliceKind:
+		return true
+	case ArrayKind:
+		return t.Elem.IsDynamic()
+	case TupleKind:
+		for _, c := range t.Components {
+			if c.IsDynamic() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// headSize returns the number of bytes the type occupies in the head of its
+// enclosing tuple.
+func (t Type) headSize() int {
+	if t.IsDynamic() {
+		return 32
+	}
+
+	switch t.Kind {
+	case ArrayKind:
+		return t.Size * t.Elem.headSize()
+	case TupleKind:
+		n := 0
+		for _, c := range t.Components {
+			n += c.headSize()
+		}
+		return n
+	default:
+		return 32
+	}
+}
+
+// paramTypes parses the types of a list of parameters.
+func paramTypes(params []Param) ([]Type, error) {
+	types := make([]Type, len(params))
+	for i, p := range params {
+		t, err := NewType(p.Type, p.Components)
+		if err != nil {
+			return nil, err
+		}
+		types[i] = t
+	}
+	return types, nil
+}
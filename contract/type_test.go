@@ -0,0 +1,102 @@
+package contract
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestNewType(t *testing.T) {
+	var tests = []struct {
+		in         string
+		components []Param
+		expected   string
+		dynamic    bool
+	}{
+		{in: "uint", expected: "uint256"},
+		{in: "int8", expected: "int8"},
+		{in: "bool", expected: "bool"},
+		{in: "address", expected: "address"},
+		{in: "bytes32", expected: "bytes32"},
+		{in: "bytes", expected: "bytes", dynamic: true},
+		{in: "string", expected: "string", dynamic: true},
+		{in: "uint[]", expected: "uint256[]", dynamic: true},
+		{in: "bytes3[2]", expected: "bytes3[2]"},
+		{in: "string[2]", expected: "string[2]", dynamic: true},
+		{in: "uint8[2][]", expected: "uint8[2][]", dynamic: true},
+		{in: "uint8[][2]", expected: "uint8[][2]", dynamic: true},
+		{
+			in:         "tuple",
+			components: []Param{{Name: "a", Type: "uint"}, {Name: "b", Type: "address"}},
+			expected:   "(uint256,address)",
+		},
+		{
+			in: "tuple[]",
+			components: []Param{
+				{Name: "a", Type: "string"},
+				{Name: "b", Type: "tuple", Components: []Param{{Name: "c", Type: "bool"}}},
+			},
+			expected: "(string,(bool))[]",
+			dynamic:  true,
+		},
+	}
+
+	for _, test := range tests {
+		typ, err := NewType(test.in, test.components)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if s := typ.String(); s != test.expected {
+			t.Fatalf("Expected: %s, received: %s", test.expected, s)
+		}
+
+		if d := typ.IsDynamic(); d != test.dynamic {
+			t.Fatalf("%s: Expected dynamic: %t, received: %t", test.in, test.dynamic, d)
+		}
+	}
+
+	for _, in := range []string{"uint7", "uint264", "bytes0", "bytes33", "int[0]", "uint]", "float", "fixed128x18"} {
+		if _, err := NewType(in, nil); err == nil {
+			t.Fatalf("Expected error for %q", in)
+		}
+	}
+}
+
+func TestSignature(t *testing.T) {
+	var tests = []struct {
+		f        Function
+		expected string
+		id       string
+	}{
+		{
+			f:        Function{Name: "displayMessage"},
+			expected: "displayMessage()",
+			id:       "2d59dc12",
+		},
+		{
+			f:        Function{Name: "transfer", Inputs: []Param{{Name: "_to", Type: "address"}, {Name: "_value", Type: "uint"}}},
+			expected: "transfer(address,uint256)",
+			id:       "a9059cbb",
+		},
+		{
+			f:        Function{Name: "balanceOf", Inputs: []Param{{Name: "", Type: "address"}}},
+			expected: "balanceOf(address)",
+			id:       "70a08231",
+		},
+	}
+
+	for _, test := range tests {
+		sig, err := test.f.Signature()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if sig != test.expected {
+			t.Fatalf("Expected: %s, received: %s", test.expected, sig)
+		}
+
+		if id := hex.EncodeToString(test.f.Id()); id != test.id {
+			t.Fatalf("Expected: %s, received: %s", test.id, id)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package contract
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version of solc, e.g. 0.8.4.
+type Version [3]int
+
+// ParseVersion parses a version such as 0.8.4 or v0.8.4+commit.c7e474f2.
+func ParseVersion(s string) (Version, error) {
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.IndexAny(s, "+-"); i >= 0 {
+		s = s[:i]
+	}
+
+	var v Version
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("invalid version %q", s)
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version %q", s)
+		}
+		v[i] = n
+	}
+	return v, nil
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+}
+
+// Less reports whether v is an earlier version than w.
+func (v Version) Less(w Version) bool {
+	for i := range v {
+		if v[i] != w[i] {
+			return v[i] < w[i]
+		}
+	}
+	return false
+}
+
+// comparator is a single constraint of a range, e.g. >=0.6.0.
+type comparator struct {
+	op string // one of "=", "<", "<=", ">" and ">="
+	v  Version
+}
+
+func (c comparator) matches(v Version) bool {
+	switch c.op {
+	case "<":
+		return v.Less(c.v)
+	case "<=":
+		return !c.v.Less(v)
+	case ">":
+		return c.v.Less(v)
+	case ">=":
+		return !v.Less(c.v)
+	default:
+		return v == c.v
+	}
+}
+
+// VersionRange is a range of versions as given by pragma solidity, e.g.
+// ^0.8.0 or >=0.6.0 <0.9.0.  Ranges separated by || match if any does.
+type VersionRange struct {
+	sets [][]comparator
+	text string
+}
+
+var pragmaRegexp = regexp.MustCompile(`pragma\s+solidity\s+([^;]+);`)
+
+// PragmaRange returns the intersection of the ranges of the pragma solidity
+// directives in Solidity source.  A source without any matches all versions.
+func PragmaRange(src string) (VersionRange, error) {
+	var texts []string
+	for _, m := range pragmaRegexp.FindAllStringSubmatch(stripComments(src), -1) {
+		texts = append(texts, strings.TrimSpace(m[1]))
+	}
+	if len(texts) == 0 {
+		return VersionRange{sets: [][]comparator{nil}}, nil
+	}
+
+	r, err := ParseVersionRange(texts[0])
+	if err != nil {
+		return VersionRange{}, err
+	}
+	for _, t := range texts[1:] {
+		other, err := ParseVersionRange(t)
+		if err != nil {
+			return VersionRange{}, err
+		}
+		r = r.intersect(other)
+	}
+	return r, nil
+}
+
+// ParseVersionRange parses a range in the syntax of pragma solidity, which
+// is that of npm.
+func ParseVersionRange(s string) (VersionRange, error) {
+	r := VersionRange{text: s}
+	for _, alt := range strings.Split(s, "||") {
+		var (
+			set    []comparator
+			fields = strings.Fields(alt)
+		)
+		for i := 0; i < len(fields); i++ {
+			f := fields[i]
+
+			// Hyphen ranges: 0.5.0 - 0.6.0
+			if i+2 < len(fields) && fields[i+1] == "-" {
+				lo, err := parsePartial(f)
+				if err != nil {
+					return VersionRange{}, err
+				}
+				hi, err := parsePartial(fields[i+2])
+				if err != nil {
+					return VersionRange{}, err
+				}
+				set = append(set, comparator{">=", lo.v})
+				set = append(set, hi.upper("<="))
+				i += 2
+				continue
+			}
+
+			// Operators may be separated from their version.
+			if strings.Trim(f, "<>=^~") == "" && i+1 < len(fields) {
+				f += fields[i+1]
+				i++
+			}
+
+			cs, err := parseComparator(f)
+			if err != nil {
+				return VersionRange{}, err
+			}
+			set = append(set, cs...)
+		}
+		r.sets = append(r.sets, set)
+	}
+	return r, nil
+}
+
+func (r VersionRange) String() string {
+	if r.text == "" {
+		return "*"
+	}
+	return r.text
+}
+
+// Matches reports whether v is in r.
+func (r VersionRange) Matches(v Version) bool {
+	for _, set := range r.sets {
+		ok := true
+		for _, c := range set {
+			if !c.matches(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// intersect returns the range of versions in both r and other.
+func (r VersionRange) intersect(other VersionRange) VersionRange {
+	res := VersionRange{text: r.String() + " " + other.String()}
+	for _, a := range r.sets {
+		for _, b := range other.sets {
+			res.sets = append(res.sets, append(append([]comparator{}, a...), b...))
+		}
+	}
+	return res
+}
+
+// partial is a version with possibly missing or wildcard parts, e.g. 0.8
+// or 0.8.x.
+type partial struct {
+	v Version
+	n int // number of given parts
+}
+
+func parsePartial(s string) (partial, error) {
+	s = strings.TrimPrefix(s, "v")
+	var p partial
+	for i, part := range strings.Split(s, ".") {
+		if i >= 3 {
+			return partial{}, fmt.Errorf("invalid version %q", s)
+		}
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return partial{}, fmt.Errorf("invalid version %q", s)
+		}
+		p.v[i] = n
+		p.n++
+	}
+	return p, nil
+}
+
+// upper returns the comparator bounding the versions matching p from above,
+// op being < or <= as if p were a full version.
+func (p partial) upper(op string) comparator {
+	if p.n == 3 {
+		return comparator{op, p.v}
+	}
+	if p.n == 0 {
+		return comparator{">=", Version{}}
+	}
+	v := p.v
+	v[p.n-1]++
+	return comparator{"<", v}
+}
+
+func parseComparator(s string) ([]comparator, error) {
+	op := s[:len(s)-len(strings.TrimLeft(s, "<>=^~"))]
+	p, err := parsePartial(s[len(op):])
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "", "=":
+		if p.n == 3 {
+			return []comparator{{"=", p.v}}, nil
+		}
+		return []comparator{{">=", p.v}, p.upper("<")}, nil
+	case "^":
+		// Up to the next change of the first non-zero part.
+		hi := p.v
+		switch {
+		case hi[0] > 0 || p.n == 1:
+			hi = Version{hi[0] + 1, 0, 0}
+		case hi[1] > 0 || p.n == 2:
+			hi = Version{0, hi[1] + 1, 0}
+		default:
+			hi = Version{0, 0, hi[2] + 1}
+		}
+		return []comparator{{">=", p.v}, {"<", hi}}, nil
+	case "~":
+		if p.n == 1 {
+			return []comparator{{">=", p.v}, {"<", Version{p.v[0] + 1, 0, 0}}}, nil
+		}
+		return []comparator{{">=", p.v}, {"<", Version{p.v[0], p.v[1] + 1, 0}}}, nil
+	case ">":
+		if p.n < 3 {
+			return []comparator{{">=", p.upper("<").v}}, nil
+		}
+		return []comparator{{">", p.v}}, nil
+	case ">=":
+		return []comparator{{">=", p.v}}, nil
+	case "<":
+		return []comparator{{"<", p.v}}, nil
+	case "<=":
+		return []comparator{p.upper("<=")}, nil
+	}
+	return nil, fmt.Errorf("invalid version constraint %q", s)
+}
+
+var commentRegexp = regexp.MustCompile(`(?s)//[^\n]*|/\*.*?\*/`)
+
+// stripComments removes comments from Solidity source, so that commented out
+// pragmas are ignored.
+func stripComments(src string) string {
+	return commentRegexp.ReplaceAllString(src, "")
+}
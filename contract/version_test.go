@@ -0,0 +1,87 @@
+package contract
+
+import (
+	"testing"
+)
+
+func TestVersionRange(t *testing.T) {
+	var tests = []struct {
+		rng      string
+		matches  []string
+		excludes []string
+	}{
+		{"^0.8.0", []string{"0.8.0", "0.8.19"}, []string{"0.7.6", "0.9.0"}},
+		{"^0.4.24", []string{"0.4.24", "0.4.26"}, []string{"0.4.23", "0.5.0"}},
+		{"^1.2.3", []string{"1.2.3", "1.9.0"}, []string{"2.0.0"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"~0.5.1", []string{"0.5.1", "0.5.17"}, []string{"0.5.0", "0.6.0"}},
+		{">=0.6.0 <0.9.0", []string{"0.6.0", "0.8.30"}, []string{"0.5.17", "0.9.0"}},
+		{">= 0.6.0 < 0.9.0", []string{"0.6.0"}, []string{"0.9.0"}},
+		{"0.8.4", []string{"0.8.4"}, []string{"0.8.5"}},
+		{"=0.8.4", []string{"0.8.4"}, []string{"0.8.3"}},
+		{"0.8", []string{"0.8.0", "0.8.9"}, []string{"0.9.0", "0.7.0"}},
+		{"0.8.x", []string{"0.8.9"}, []string{"0.9.0"}},
+		{">0.7", []string{"0.8.0"}, []string{"0.7.6"}},
+		{"<=0.7", []string{"0.7.6"}, []string{"0.8.0"}},
+		{"0.5.0 - 0.6.2", []string{"0.5.0", "0.6.2"}, []string{"0.6.3"}},
+		{"^0.4.0 || ^0.8.0", []string{"0.4.26", "0.8.1"}, []string{"0.5.0"}},
+		{"*", []string{"0.1.0", "0.8.0"}, nil},
+	}
+
+	for _, test := range tests {
+		r, err := ParseVersionRange(test.rng)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range test.matches {
+			v, _ := ParseVersion(s)
+			if !r.Matches(v) {
+				t.Fatalf("Expected %s to match %s", test.rng, s)
+			}
+		}
+		for _, s := range test.excludes {
+			v, _ := ParseVersion(s)
+			if r.Matches(v) {
+				t.Fatalf("Expected %s not to match %s", test.rng, s)
+			}
+		}
+	}
+
+	for _, s := range []string{"^a.b", "0.8.0.1", "!0.8.0"} {
+		if _, err := ParseVersionRange(s); err == nil {
+			t.Fatalf("Expected error for %s", s)
+		}
+	}
+}
+
+func TestPragmaRange(t *testing.T) {
+	src := `// SPDX-License-Identifier: MIT
+// pragma solidity ^0.4.0;
+pragma solidity >=0.6.0;
+/* pragma solidity 0.5.0; */
+pragma solidity <0.8.0;
+contract A {}`
+
+	r, err := PragmaRange(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for s, expected := range map[string]bool{"0.6.0": true, "0.7.6": true, "0.5.0": false, "0.4.26": false, "0.8.0": false} {
+		v, _ := ParseVersion(s)
+		if r.Matches(v) != expected {
+			t.Fatalf("%s: Expected: %v, received: %v", s, expected, !expected)
+		}
+	}
+
+	r, err = PragmaRange("contract A {}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Matches(Version{0, 4, 11}) {
+		t.Fatal("Expected source without pragma to match all versions")
+	}
+
+	if v, err := ParseVersion("v0.8.4+commit.c7e474f2"); err != nil || v != (Version{0, 8, 4}) {
+		t.Fatalf("Expected: 0.8.4, received: %v, %v", v, err)
+	}
+}
@@ -0,0 +1,156 @@
+package asm
+
+import (
+	"ethereum/evm"
+	"math/big"
+)
+
+// JumpDests returns the offsets of the valid jump destinations of code: the
+// JUMPDEST opcodes which aren't PUSH data.
+func JumpDests(code []byte) []int {
+	var dests []int
+	for _, in := range Disassemble(code) {
+		if in.Op == evm.JUMPDEST {
+			dests = append(dests, in.Pc)
+		}
+	}
+	return dests
+}
+
+// Block is a basic block: a run of instructions which is only entered at its
+// start and only left at its end.
+type Block struct {
+	Start, End   int // offsets of the first instruction and past the last
+	Instructions []Instruction
+}
+
+// BasicBlocks splits code into basic blocks.  Blocks start at the beginning
+// of the code, at JUMPDESTs and after JUMPIs, and end after instructions
+// which jump or halt.
+func BasicBlocks(code []byte) []Block {
+	var blocks []Block
+	var cur Block
+	flush := func(end int) {
+		if len(cur.Instructions) > 0 {
+			cur.End = end
+			blocks = append(blocks, cur)
+		}
+		cur = Block{Start: end}
+	}
+
+	for _, in := range Disassemble(code) {
+		if in.Op == evm.JUMPDEST {
+			flush(in.Pc)
+		}
+		cur.Instructions = append(cur.Instructions, in)
+		if in.Op.Terminates() || in.Op == evm.JUMPI {
+			flush(in.Pc + in.Size())
+		}
+	}
+	flush(len(code))
+	return blocks
+}
+
+// Selectors returns the 4 byte function selectors which the dispatcher of
+// runtime code compiled by solc compares the call data against, in order.
+// Each is found as a PUSH of the selector compared with EQ, optionally after
+// a DUP, followed by a conditional jump.  Selectors with leading zero bytes
+// are pushed with fewer than 4 bytes.
+func Selectors(code []byte) [][4]byte {
+	instrs := Disassemble(code)
+	seen := make(map[[4]byte]bool)
+	var sels [][4]byte
+	for i, in := range instrs {
+		if in.Op.PushSize() < 1 || in.Op.PushSize() > 4 || in.Truncated() {
+			continue
+		}
+
+		j := i + 1
+		if j < len(instrs) && instrs[j].Op >= evm.DUP1 && instrs[j].Op <= evm.DUP16 {
+			j++
+		}
+		if j+2 >= len(instrs) || instrs[j].Op != evm.EQ || !instrs[j+1].Op.IsPush() || instrs[j+2].Op != evm.JUMPI {
+			continue
+		}
+
+		var sel [4]byte
+		copy(sel[4-len(in.Data):], in.Data)
+		if !seen[sel] {
+			seen[sel] = true
+			sels = append(sels, sel)
+		}
+	}
+	return sels
+}
+
+// CreationCode is creation code split into its parts.
+type CreationCode struct {
+	Init    []byte // the code run on deployment, which returns Runtime
+	Runtime []byte // the code of the deployed contract
+	Args    []byte // the encoded constructor arguments appended to the code
+}
+
+// SplitCreation splits creation code into the init code, runtime code and
+// constructor arguments, by finding the CODECOPY of constant offset and size
+// which copies the runtime code into memory to be returned.  It returns
+// false if there is no such CODECOPY, e.g. because the code isn't creation
+// code.
+func SplitCreation(code []byte) (CreationCode, bool) {
+	offset, size, ok := runtimeCopy(code)
+	if !ok {
+		return CreationCode{}, false
+	}
+	return CreationCode{
+		Init:    code[:offset],
+		Runtime: code[offset : offset+size],
+		Args:    code[offset+size:],
+	}, true
+}
+
+// runtimeCopy returns the offset and size of the first CODECOPY of a
+// constant range after the copying code.  Constants are tracked through
+// PUSH, DUP, SWAP and POP within basic blocks.
+func runtimeCopy(code []byte) (int, int, bool) {
+	for _, b := range BasicBlocks(code) {
+		// The values pushed in the block, nil if unknown.  Values below
+		// those are unknown too.
+		var stack []*big.Int
+		peek := func(n int) *big.Int {
+			if n >= len(stack) {
+				return nil
+			}
+			return stack[len(stack)-1-n]
+		}
+
+		for _, in := range b.Instructions {
+			switch {
+			case in.Op == evm.PUSH0 || in.Op.IsPush():
+				stack = append(stack, new(big.Int).SetBytes(in.Data))
+			case in.Op >= evm.DUP1 && in.Op <= evm.DUP16:
+				stack = append(stack, peek(int(in.Op-evm.DUP1)))
+			case in.Op >= evm.SWAP1 && in.Op <= evm.SWAP16:
+				n := int(in.Op-evm.SWAP1) + 1
+				if n >= len(stack) {
+					stack = nil
+					continue
+				}
+				top := len(stack) - 1
+				stack[top], stack[top-n] = stack[top-n], stack[top]
+			case in.Op == evm.POP && len(stack) > 0:
+				stack = stack[:len(stack)-1]
+			case in.Op == evm.CODECOPY:
+				offset, size := peek(1), peek(2)
+				if offset != nil && size != nil && offset.IsInt64() && size.IsInt64() {
+					o, s := int(offset.Int64()), int(size.Int64())
+					if o > in.Pc && s > 0 && o+s <= len(code) {
+						return o, s, true
+					}
+				}
+				stack = nil
+			default:
+				stack = nil
+			}
+		}
+	}
+	return 0, 0, false
+}
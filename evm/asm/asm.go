@@ -0,0 +1,419 @@
+package asm
+
+import (
+	"encoding/hex"
+	"ethereum/evm"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// Assemble assembles source into bytecode, the inverse of Disassemble.  The
+// result can be used as a contract's Bin or a transaction's Data.
+//
+// Source has an instruction per line, an opcode name followed by the value
+// of a PUSH.  Comments start with ";" or "//".
+//
+//	start:                   ; a label, at the offset of the next instruction
+//	    PUSH1 0x80           ; PUSHn pushes an n byte value, an error if it doesn't fit
+//	    PUSH @end-@start     ; PUSH is sized to fit its value, 1 byte at least
+//	    JUMPDEST             ; labels don't add JUMPDESTs
+//	    .bytes 0xfe00        ; raw bytes
+//	end:
+//
+// Values are hex or decimal numbers or label offsets prefixed by "@",
+// added and subtracted with "+" and "-".
+//
+// Macros are defined between ".macro name params..." and ".end", and are
+// expanded where their name is used as an instruction, with "$param" in
+// their body replaced by the argument for param.  Labels defined in a macro
+// must be unique, e.g. by passing them as arguments.
+func Assemble(source string) ([]byte, error) {
+	lines, err := expand(source)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []item
+	for _, l := range lines {
+		parsed, err := parseLine(l.text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", l.num, err)
+		}
+		for i := range parsed {
+			parsed[i].line = l.num
+		}
+		items = append(items, parsed...)
+	}
+
+	labels, err := layout(items)
+	if err != nil {
+		return nil, err
+	}
+
+	var code []byte
+	for _, it := range items {
+		switch {
+		case it.label != "":
+		case it.data != nil:
+			code = append(code, it.data...)
+		default:
+			code = append(code, byte(it.op))
+			if it.op.IsPush() {
+				v, err := eval(it.expr, labels)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %s", it.line, err)
+				}
+				code = append(code, v.FillBytes(make([]byte, it.op.PushSize()))...)
+			}
+		}
+	}
+	return code, nil
+}
+
+// InitCode returns creation code which deploys runtime: init code which
+// returns the runtime code appended to it.
+func InitCode(runtime []byte) []byte {
+	// PUSH1 0 rather than PUSH0, so that it runs before Shanghai.
+	code, err := Assemble(fmt.Sprintf(`
+		PUSH %d
+		DUP1
+		PUSH @runtime
+		PUSH1 0
+		CODECOPY
+		PUSH1 0
+		RETURN
+	runtime:`, len(runtime)))
+	if err != nil {
+		panic(err)
+	}
+	return append(code, runtime...)
+}
+
+// item is a label, instruction or raw bytes.
+type item struct {
+	line int
+
+	label string // label defined at this position
+
+	op   evm.OpCode
+	auto bool   // a PUSH sized to fit its value
+	expr []term // the value of a PUSH
+
+	data []byte // raw bytes, if not nil
+}
+
+func (it item) size() int {
+	switch {
+	case it.label != "":
+		return 0
+	case it.data != nil:
+		return len(it.data)
+	}
+	return 1 + it.op.PushSize()
+}
+
+// term is a number or label offset, added or subtracted.
+type term struct {
+	neg   bool
+	label string
+	value *big.Int
+}
+
+// sourceLine is a line of source after macro expansion, with its line number
+// in the source.
+type sourceLine struct {
+	num  int
+	text string
+}
+
+type macro struct {
+	params []string
+	body   []string
+}
+
+// maxExpansion limits the nesting of macros, which may be recursive.
+const maxExpansion = 16
+
+// expand removes comments and blank lines from source, and expands macros.
+func expand(source string) ([]sourceLine, error) {
+	macros := make(map[string]macro)
+	var lines []sourceLine
+	var cur *macro
+	var curName string
+
+	for i, text := range strings.Split(source, "\n") {
+		num := i + 1
+		if j := strings.Index(text, ";"); j >= 0 {
+			text = text[:j]
+		}
+		if j := strings.Index(text, "//"); j >= 0 {
+			text = text[:j]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch {
+		case fields[0] == ".macro":
+			if cur != nil {
+				return nil, fmt.Errorf("line %d: macro defined within macro %s", num, curName)
+			}
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("line %d: macro has no name", num)
+			}
+			if _, ok := macros[fields[1]]; ok {
+				return nil, fmt.Errorf("line %d: macro %s already defined", num, fields[1])
+			}
+			cur, curName = &macro{params: fields[2:]}, fields[1]
+		case fields[0] == ".end":
+			if cur == nil {
+				return nil, fmt.Errorf("line %d: .end outside macro", num)
+			}
+			macros[curName] = *cur
+			cur = nil
+		case cur != nil:
+			cur.body = append(cur.body, strings.Join(fields, " "))
+		default:
+			expanded, err := expandLine(macros, fields, 0)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", num, err)
+			}
+			for _, e := range expanded {
+				lines = append(lines, sourceLine{num: num, text: e})
+			}
+		}
+	}
+
+	if cur != nil {
+		return nil, fmt.Errorf("macro %s has no .end", curName)
+	}
+	return lines, nil
+}
+
+// expandLine expands a line if it is a macro invocation.
+func expandLine(macros map[string]macro, fields []string, depth int) ([]string, error) {
+	m, ok := macros[fields[0]]
+	if !ok {
+		return []string{strings.Join(fields, " ")}, nil
+	}
+	if depth == maxExpansion {
+		return nil, fmt.Errorf("macros nested more than %d deep", maxExpansion)
+	}
+	args := fields[1:]
+	if len(args) != len(m.params) {
+		return nil, fmt.Errorf("macro %s takes %d args, received %d", fields[0], len(m.params), len(args))
+	}
+
+	// Longer params are replaced first, so that $ab isn't replaced as $a.
+	order := make([]int, len(m.params))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return len(m.params[order[i]]) > len(m.params[order[j]]) })
+	var pairs []string
+	for _, i := range order {
+		pairs = append(pairs, "$"+m.params[i], args[i])
+	}
+	r := strings.NewReplacer(pairs...)
+
+	var lines []string
+	for _, body := range m.body {
+		expanded, err := expandLine(macros, strings.Fields(r.Replace(body)), depth+1)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, expanded...)
+	}
+	return lines, nil
+}
+
+// parseLine parses a line into its label, if any, and its instruction or
+// bytes, if any.
+func parseLine(text string) ([]item, error) {
+	var items []item
+	fields := strings.Fields(text)
+	if strings.HasSuffix(fields[0], ":") {
+		label := strings.TrimSuffix(fields[0], ":")
+		if !isLabel(label) {
+			return nil, fmt.Errorf("invalid label %q", label)
+		}
+		items = append(items, item{label: label})
+		fields = fields[1:]
+		if len(fields) == 0 {
+			return items, nil
+		}
+	}
+
+	name, args := fields[0], fields[1:]
+	if name == ".bytes" {
+		if len(args) != 1 {
+			return nil, fmt.Errorf(".bytes takes 1 arg, received %d", len(args))
+		}
+		if !strings.HasPrefix(args[0], "0x") {
+			return nil, fmt.Errorf("bytes %s must have '0x' prefix", args[0])
+		}
+		data, err := hex.DecodeString(args[0][2:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid bytes %s: %s", args[0], err)
+		}
+		return append(items, item{data: data}), nil
+	}
+
+	it := item{}
+	name = strings.ToUpper(name)
+	if name == "PUSH" {
+		it.op, it.auto = evm.PUSH1, true
+	} else {
+		op, ok := evm.OpCodeByName(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown opcode %s", fields[0])
+		}
+		it.op = op
+	}
+
+	if !it.op.IsPush() {
+		if len(args) != 0 {
+			return nil, fmt.Errorf("%s takes no args", name)
+		}
+		return append(items, it), nil
+	}
+
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%s takes 1 arg, received %d", name, len(args))
+	}
+	expr, err := parseExpr(args[0])
+	if err != nil {
+		return nil, err
+	}
+	it.expr = expr
+	return append(items, it), nil
+}
+
+// parseExpr parses a sum of numbers and label offsets.
+func parseExpr(s string) ([]term, error) {
+	var terms []term
+	neg := false
+	for s != "" {
+		end := strings.IndexAny(s, "+-")
+		if end == 0 {
+			return nil, fmt.Errorf("invalid value %q", s)
+		}
+		if end < 0 {
+			end = len(s)
+		}
+
+		t := term{neg: neg}
+		operand := s[:end]
+		if strings.HasPrefix(operand, "@") {
+			t.label = operand[1:]
+			if !isLabel(t.label) {
+				return nil, fmt.Errorf("invalid label %q", t.label)
+			}
+		} else {
+			v, ok := new(big.Int).SetString(operand, 0)
+			if !ok {
+				return nil, fmt.Errorf("invalid number %q", operand)
+			}
+			t.value = v
+		}
+		terms = append(terms, t)
+
+		if end == len(s) {
+			break
+		}
+		if end == len(s)-1 {
+			return nil, fmt.Errorf("value %q ends with %c", s, s[end])
+		}
+		neg = s[end] == '-'
+		s = s[end+1:]
+	}
+	return terms, nil
+}
+
+func isLabel(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		if c != '_' && !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') && !(i > 0 && '0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// eval evaluates a value, which must be 32 bytes at most.
+func eval(expr []term, labels map[string]int) (*big.Int, error) {
+	sum := new(big.Int)
+	for _, t := range expr {
+		v := t.value
+		if t.label != "" {
+			pc, ok := labels[t.label]
+			if !ok {
+				return nil, fmt.Errorf("undefined label %s", t.label)
+			}
+			v = big.NewInt(int64(pc))
+		}
+		if t.neg {
+			sum.Sub(sum, v)
+		} else {
+			sum.Add(sum, v)
+		}
+	}
+	if sum.Sign() < 0 {
+		return nil, fmt.Errorf("negative value %s", sum)
+	}
+	if sum.BitLen() > 256 {
+		return nil, fmt.Errorf("value %#x is larger than 32 bytes", sum)
+	}
+	return sum, nil
+}
+
+// layout sizes the PUSHes which fit their values, returning the offsets of
+// the labels.  As PUSHes of labels grow the labels after them move, it
+// repeats until no PUSH grows.
+func layout(items []item) (map[string]int, error) {
+	for {
+		labels := make(map[string]int)
+		pc := 0
+		for _, it := range items {
+			if it.label != "" {
+				if _, ok := labels[it.label]; ok {
+					return nil, fmt.Errorf("line %d: label %s already defined", it.line, it.label)
+				}
+				labels[it.label] = pc
+			}
+			pc += it.size()
+		}
+
+		grown := false
+		for i, it := range items {
+			if !it.op.IsPush() || it.data != nil || it.label != "" {
+				continue
+			}
+			v, err := eval(it.expr, labels)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", it.line, err)
+			}
+
+			n := (v.BitLen() + 7) / 8
+			if !it.auto {
+				if n > it.op.PushSize() {
+					return nil, fmt.Errorf("line %d: value %#x doesn't fit %s", it.line, v, it.op)
+				}
+				continue
+			}
+			// PUSHes only grow, so that this terminates.
+			if n > it.op.PushSize() {
+				items[i].op = evm.PUSH1 + evm.OpCode(n-1)
+				grown = true
+			}
+		}
+		if !grown {
+			return labels, nil
+		}
+	}
+}
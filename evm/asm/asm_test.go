@@ -0,0 +1,139 @@
+package asm
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestAssemble(t *testing.T) {
+	var tests = []struct {
+		source   string
+		expected string
+	}{
+		{
+			// The EIP-1167 minimal proxy.
+			source: `
+				CALLDATASIZE
+				RETURNDATASIZE
+				RETURNDATASIZE
+				CALLDATACOPY
+				RETURNDATASIZE
+				RETURNDATASIZE
+				RETURNDATASIZE
+				CALLDATASIZE
+				RETURNDATASIZE
+				PUSH20 0xbebebebebebebebebebebebebebebebebebebebe
+				GAS
+				DELEGATECALL
+				RETURNDATASIZE
+				DUP3
+				DUP1
+				RETURNDATACOPY
+				SWAP1
+				RETURNDATASIZE
+				SWAP2
+				PUSH @success
+				JUMPI
+				REVERT
+			success: JUMPDEST
+				RETURN`,
+			expected: "363d3d373d3d3d363d73bebebebebebebebebebebebebebebebebebebebe5af43d82803e903d91602b57fd5bf3",
+		},
+		{
+			source:   "push 0\npush 256 ; comment\nPUSH2 1 // comment\nPUSH0\nPUSH 0xffffff+1-2",
+			expected: "6000" + "610100" + "610001" + "5f" + "62fffffe",
+		},
+		{
+			// The jump over 300 bytes needs a PUSH2, which moves the label.
+			source:   "PUSH @end\nJUMP\n.bytes 0x" + strings.Repeat("fe", 300) + "\nend: JUMPDEST\nPUSH @end-@end",
+			expected: "61013056" + strings.Repeat("fe", 300) + "5b6000",
+		},
+		{
+			source: `
+				.macro require cond target
+					$cond
+					PUSH @$target
+					JUMPI
+					PUSH0
+					DUP1
+					REVERT
+				.end
+				.macro require_no_value
+					require CALLVALUE_ISZERO ok
+				.end
+				.macro CALLVALUE_ISZERO
+					CALLVALUE
+					ISZERO
+				.end
+				require_no_value
+			ok: JUMPDEST`,
+			expected: "3415600857" + "5f80fd5b",
+		},
+	}
+
+	for _, test := range tests {
+		code, err := Assemble(test.source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h := hex.EncodeToString(code); h != test.expected {
+			t.Fatalf("Expected: %s, received: %s", test.expected, h)
+		}
+	}
+}
+
+func TestAssembleErrors(t *testing.T) {
+	var tests = []struct {
+		source   string
+		expected string
+	}{
+		{"ADD\nFOO", "line 2: unknown opcode FOO"},
+		{"PUSH1 256", "line 1: value 0x100 doesn't fit PUSH1"},
+		{"PUSH 1-2", "line 1: negative value -1"},
+		{"PUSH @nowhere", "line 1: undefined label nowhere"},
+		{"a:\na:", "line 2: label a already defined"},
+		{"ADD 1", "line 1: ADD takes no args"},
+		{"PUSH 1+", `line 1: value "1+" ends with +`},
+		{".macro m x\nPUSH $x\n.end\nm", "line 4: macro m takes 1 args, received 0"},
+		{".macro m\nm\n.end\nm", "line 4: macros nested more than 16 deep"},
+		{".macro m", "macro m has no .end"},
+	}
+
+	for _, test := range tests {
+		_, err := Assemble(test.source)
+		if err == nil || err.Error() != test.expected {
+			t.Fatalf("Expected: %s, received: %v", test.expected, err)
+		}
+	}
+}
+
+func TestInitCode(t *testing.T) {
+	runtime, err := Assemble("PUSH 42\nPUSH0\nMSTORE\nPUSH 32\nPUSH0\nRETURN")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := InitCode(runtime)
+	c, ok := SplitCreation(code)
+	if !ok {
+		t.Fatal("Expected init code to split")
+	}
+	if !bytes.Equal(c.Runtime, runtime) || len(c.Args) != 0 {
+		t.Fatalf("Expected: %x, received: %x %x", runtime, c.Runtime, c.Args)
+	}
+
+	// Disassembling and assembling is the identity.
+	var source []string
+	for _, in := range Disassemble(code) {
+		source = append(source, in.String())
+	}
+	reassembled, err := Assemble(strings.Join(source, "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(reassembled, code) {
+		t.Fatalf("Expected: %x, received: %x", code, reassembled)
+	}
+}
@@ -0,0 +1,65 @@
+// Package asm assembles, disassembles and analyzes EVM bytecode.
+package asm
+
+import (
+	"encoding/hex"
+	"ethereum/evm"
+	"fmt"
+	"strings"
+)
+
+// Instruction is an instruction of disassembled code.
+type Instruction struct {
+	Pc   int // offset of the opcode in the code
+	Op   evm.OpCode
+	Data []byte // immediate data of PUSH1 to PUSH32
+}
+
+// Size returns the number of bytes of code the instruction takes.
+func (in Instruction) Size() int {
+	return 1 + len(in.Data)
+}
+
+// Truncated reports whether the code ends before all the PUSH data of the
+// instruction.  The missing bytes are zero when executed.
+func (in Instruction) Truncated() bool {
+	return len(in.Data) < in.Op.PushSize()
+}
+
+// String formats the instruction as its opcode name followed by its PUSH
+// data, e.g. "PUSH1 0x80".
+func (in Instruction) String() string {
+	if in.Op.IsPush() {
+		return fmt.Sprintf("%s 0x%s", in.Op, hex.EncodeToString(in.Data))
+	}
+	return in.Op.String()
+}
+
+// Disassemble splits code into instructions.  Data which isn't code, such as
+// the metadata trailer, is disassembled as if it were.
+func Disassemble(code []byte) []Instruction {
+	var instrs []Instruction
+	for pc := 0; pc < len(code); {
+		in := Instruction{Pc: pc, Op: evm.OpCode(code[pc])}
+		if n := in.Op.PushSize(); n > 0 {
+			end := pc + 1 + n
+			if end > len(code) {
+				end = len(code)
+			}
+			in.Data = code[pc+1 : end]
+		}
+		instrs = append(instrs, in)
+		pc += in.Size()
+	}
+	return instrs
+}
+
+// Format returns a listing of instructions, one per line, prefixed by their
+// offsets in hex.
+func Format(instrs []Instruction) string {
+	var b strings.Builder
+	for _, in := range instrs {
+		fmt.Fprintf(&b, "%04x: %s\n", in.Pc, in)
+	}
+	return b.String()
+}
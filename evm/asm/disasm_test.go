@@ -0,0 +1,137 @@
+package asm
+
+import (
+	"encoding/hex"
+	"ethereum/evm"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Runtime code of a solc style dispatcher of transfer(address,uint256) and
+// balanceOf(address), without its metadata trailer.
+const runtimeHex = "6080604052" + // PUSH1 0x80 PUSH1 0x40 MSTORE
+	"5f3560e01c" + // PUSH0 CALLDATALOAD PUSH1 0xe0 SHR
+	"8063a9059cbb14602157" + // DUP1 PUSH4 0xa9059cbb EQ PUSH1 0x21 JUMPI
+	"6370a082318114602357" + // PUSH4 0x70a08231 DUP2 EQ PUSH1 0x23 JUMPI
+	"5f80fd" + // PUSH0 DUP1 REVERT
+	"5b00" + // 0x21: JUMPDEST STOP
+	"5b00" // 0x23: JUMPDEST STOP
+
+// metadataHex is the metadata trailer {"ipfs": sha256 multihash of "",
+// "solc": 0.8.19}.
+const metadataHex = "a264697066735822" + "1220e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" +
+	"64736f6c6343000813" + "0033"
+
+// initHex is init code which returns the 0x5a bytes of runtime code and
+// metadata following it.
+const initHex = "6080604052" + // PUSH1 0x80 PUSH1 0x40 MSTORE
+	"348015600e575f80fd5b50" + // CALLVALUE DUP1 ISZERO PUSH1 0x0e JUMPI PUSH0 DUP1 REVERT JUMPDEST POP
+	"605a80601a5f395ff3fe" // PUSH1 0x5a DUP1 PUSH1 0x1a PUSH0 CODECOPY PUSH0 RETURN INVALID
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestDisassemble(t *testing.T) {
+	instrs := Disassemble(decodeHex(t, "6080604052600a5b61ab"))
+	expected := []Instruction{
+		{Pc: 0, Op: evm.PUSH1, Data: []byte{0x80}},
+		{Pc: 2, Op: evm.PUSH1, Data: []byte{0x40}},
+		{Pc: 4, Op: evm.MSTORE},
+		{Pc: 5, Op: evm.PUSH1, Data: []byte{0x0a}},
+		{Pc: 7, Op: evm.JUMPDEST},
+		{Pc: 8, Op: evm.PUSH1 + 1, Data: []byte{0xab}},
+	}
+	if !reflect.DeepEqual(instrs, expected) {
+		t.Fatalf("Expected: %v, received: %v", expected, instrs)
+	}
+	if !instrs[5].Truncated() || instrs[4].Truncated() {
+		t.Fatal("Expected only the last PUSH2 to be truncated")
+	}
+
+	listing := "0000: PUSH1 0x80\n0002: PUSH1 0x40\n0004: MSTORE\n0005: PUSH1 0x0a\n0007: JUMPDEST\n0008: PUSH2 0xab\n"
+	if f := Format(instrs); f != listing {
+		t.Fatalf("Expected: %q, received: %q", listing, f)
+	}
+
+	if s := (Instruction{Op: 0x0c}).String(); s != "0x0c" {
+		t.Fatalf("Expected: 0x0c, received: %s", s)
+	}
+}
+
+func TestJumpDests(t *testing.T) {
+	// The JUMPDEST pushed as data isn't a destination.
+	dests := JumpDests(decodeHex(t, "605b5b00"+runtimeHex))
+	expected := []int{2, 4 + 0x21, 4 + 0x23}
+	if !reflect.DeepEqual(dests, expected) {
+		t.Fatalf("Expected: %v, received: %v", expected, dests)
+	}
+}
+
+func TestBasicBlocks(t *testing.T) {
+	blocks := BasicBlocks(decodeHex(t, runtimeHex))
+
+	var bounds [][2]int
+	for _, b := range blocks {
+		bounds = append(bounds, [2]int{b.Start, b.End})
+	}
+	expected := [][2]int{{0, 0x14}, {0x14, 0x1e}, {0x1e, 0x21}, {0x21, 0x23}, {0x23, 0x25}}
+	if !reflect.DeepEqual(bounds, expected) {
+		t.Fatalf("Expected: %v, received: %v", expected, bounds)
+	}
+	if last := blocks[0].Instructions[len(blocks[0].Instructions)-1]; last.Op != evm.JUMPI {
+		t.Fatalf("Expected: JUMPI, received: %s", last)
+	}
+}
+
+func TestSelectors(t *testing.T) {
+	var tests = []struct {
+		code     string
+		expected [][4]byte
+	}{
+		{runtimeHex, [][4]byte{{0xa9, 0x05, 0x9c, 0xbb}, {0x70, 0xa0, 0x82, 0x31}}},
+		{
+			// The ERC-1155 balanceOf(address,uint256) selector 0x00fdd58e is
+			// pushed with PUSH3.
+			"5f3560e01c" + // PUSH0 CALLDATALOAD PUSH1 0xe0 SHR
+				"8062fdd58e14602157" + // DUP1 PUSH3 0xfdd58e EQ PUSH1 0x21 JUMPI
+				"80600114602157" + // DUP1 PUSH1 0x01 EQ PUSH1 0x21 JUMPI
+				"63a22cb46514602157" + // PUSH4 0xa22cb465 EQ PUSH1 0x21 JUMPI
+				"5f80fd" + // PUSH0 DUP1 REVERT
+				"5b00", // 0x21: JUMPDEST STOP
+			[][4]byte{{0x00, 0xfd, 0xd5, 0x8e}, {0x00, 0x00, 0x00, 0x01}, {0xa2, 0x2c, 0xb4, 0x65}},
+		},
+	}
+
+	for _, test := range tests {
+		if sels := Selectors(decodeHex(t, test.code)); !reflect.DeepEqual(sels, test.expected) {
+			t.Fatalf("Expected: %x, received: %x", test.expected, sels)
+		}
+	}
+}
+
+func TestSplitCreation(t *testing.T) {
+	args := strings.Repeat("0", 63) + "1"
+	c, ok := SplitCreation(decodeHex(t, initHex+runtimeHex+metadataHex+args))
+	if !ok {
+		t.Fatal("Expected creation code to split")
+	}
+	if h := hex.EncodeToString(c.Init); h != initHex {
+		t.Fatalf("Expected: %s, received: %s", initHex, h)
+	}
+	if h := hex.EncodeToString(c.Runtime); h != runtimeHex+metadataHex {
+		t.Fatalf("Expected: %s, received: %s", runtimeHex+metadataHex, h)
+	}
+	if h := hex.EncodeToString(c.Args); h != args {
+		t.Fatalf("Expected: %s, received: %s", args, h)
+	}
+
+	if _, ok := SplitCreation(decodeHex(t, runtimeHex)); ok {
+		t.Fatal("Expected runtime code not to split")
+	}
+}
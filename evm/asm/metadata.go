@@ -0,0 +1,191 @@
+package asm
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrNoMetadata is returned by ParseMetadata for code without a metadata
+// trailer.
+var ErrNoMetadata = errors.New("no metadata trailer")
+
+// Metadata is the metadata solc appends to runtime code: a CBOR encoded map
+// followed by its length as 2 big endian bytes.
+type Metadata struct {
+	Solc         string // compiler version, e.g. "0.8.19"
+	IPFS         []byte // multihash of the metadata JSON on IPFS
+	Swarm        []byte // hash of the metadata JSON on Swarm
+	Experimental bool   // compiled with experimental features
+
+	Length int // of the trailer in bytes, including the 2 length bytes
+}
+
+// IPFSHash returns the IPFS CIDv0 of the metadata JSON, the base58 "Qm..."
+// form of the multihash, or "" if it has none.
+func (m Metadata) IPFSHash() string {
+	if len(m.IPFS) == 0 {
+		return ""
+	}
+	return base58(m.IPFS)
+}
+
+// ParseMetadata parses the metadata trailer of runtime code.  The code
+// before the trailer is code[:len(code)-m.Length].
+func ParseMetadata(code []byte) (Metadata, error) {
+	if len(code) < 2 {
+		return Metadata{}, ErrNoMetadata
+	}
+	n := int(code[len(code)-2])<<8 | int(code[len(code)-1])
+	if n == 0 || n+2 > len(code) {
+		return Metadata{}, ErrNoMetadata
+	}
+
+	d := cborDecoder{data: code[len(code)-2-n : len(code)-2]}
+	major, size, err := d.header()
+	if err != nil || major != cborMap {
+		return Metadata{}, ErrNoMetadata
+	}
+
+	m := Metadata{Length: n + 2}
+	for i := uint64(0); i < size; i++ {
+		key, err := d.item()
+		if err != nil {
+			return Metadata{}, err
+		}
+		value, err := d.item()
+		if err != nil {
+			return Metadata{}, err
+		}
+
+		k, ok := key.(string)
+		if !ok {
+			return Metadata{}, fmt.Errorf("metadata: key %v is not a string", key)
+		}
+		switch v := value.(type) {
+		case []byte:
+			switch k {
+			case "ipfs":
+				m.IPFS = v
+			case "bzzr0", "bzzr1":
+				m.Swarm = v
+			case "solc":
+				if len(v) != 3 {
+					return Metadata{}, fmt.Errorf("metadata: solc version %x is not 3 bytes", v)
+				}
+				m.Solc = fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+			}
+		case string:
+			// Prerelease versions are stored as strings.
+			if k == "solc" {
+				m.Solc = v
+			}
+		case bool:
+			if k == "experimental" {
+				m.Experimental = v
+			}
+		}
+	}
+	if len(d.data) != 0 {
+		return Metadata{}, errors.New("metadata: trailing data after map")
+	}
+	return m, nil
+}
+
+// CBOR major types.
+const (
+	cborUint   = 0
+	cborBytes  = 2
+	cborText   = 3
+	cborMap    = 5
+	cborSimple = 7
+)
+
+// cborDecoder decodes the subset of CBOR solc uses for metadata.
+type cborDecoder struct {
+	data []byte
+}
+
+// header decodes the header of an item, returning its major type and its
+// argument, which is its length for strings and maps.
+func (d *cborDecoder) header() (byte, uint64, error) {
+	if len(d.data) == 0 {
+		return 0, 0, errors.New("metadata: unexpected end of cbor")
+	}
+	major, info := d.data[0]>>5, d.data[0]&0x1f
+	d.data = d.data[1:]
+
+	var n int
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info <= 27:
+		n = 1 << (info - 24)
+	default:
+		return 0, 0, fmt.Errorf("metadata: unsupported cbor item %#x", info)
+	}
+	if len(d.data) < n {
+		return 0, 0, errors.New("metadata: unexpected end of cbor")
+	}
+	arg := new(big.Int).SetBytes(d.data[:n]).Uint64()
+	d.data = d.data[n:]
+	return major, arg, nil
+}
+
+// item decodes an unsigned integer, byte or text string, or boolean.
+func (d *cborDecoder) item() (interface{}, error) {
+	major, arg, err := d.header()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUint:
+		return arg, nil
+	case cborBytes, cborText:
+		if uint64(len(d.data)) < arg {
+			return nil, errors.New("metadata: unexpected end of cbor")
+		}
+		b := d.data[:arg]
+		d.data = d.data[arg:]
+		if major == cborText {
+			return string(b), nil
+		}
+		return b, nil
+	case cborSimple:
+		switch arg {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		}
+	}
+	return nil, fmt.Errorf("metadata: unsupported cbor item of type %d", major)
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58 encodes b in the bitcoin base58 alphabet, as IPFS hashes are.
+func base58(b []byte) string {
+	n := new(big.Int).SetBytes(b)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	// Leading zero bytes are encoded as leading '1's.
+	for _, c := range b {
+		if c != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
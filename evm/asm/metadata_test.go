@@ -0,0 +1,36 @@
+package asm
+
+import (
+	"testing"
+)
+
+func TestParseMetadata(t *testing.T) {
+	m, err := ParseMetadata(decodeHex(t, runtimeHex+metadataHex))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Solc != "0.8.19" || m.Length != 0x35 || len(m.Swarm) != 0 || m.Experimental {
+		t.Fatalf("Unexpected metadata: %+v", m)
+	}
+	// The well known hash of the empty file.
+	if h := m.IPFSHash(); h != "QmdfTbBqBPQ7VNxZEYEj14VmRuZBkqFbiwReogJgS1zR1n" {
+		t.Fatalf("Expected: QmdfTbBqBPQ7VNxZEYEj14VmRuZBkqFbiwReogJgS1zR1n, received: %s", h)
+	}
+
+	// {"bzzr1": 32 bytes, "solc": "0.6.0-nightly", "experimental": true}
+	swarm := "a365627a7a72315820" + "0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20" +
+		"64736f6c636d302e362e302d6e696768746c79" + "6c6578706572696d656e74616cf5" + "004a"
+	m, err = ParseMetadata(decodeHex(t, "00"+swarm))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Solc != "0.6.0-nightly" || len(m.Swarm) != 32 || m.Swarm[31] != 0x20 || !m.Experimental || m.IPFSHash() != "" {
+		t.Fatalf("Unexpected metadata: %+v", m)
+	}
+
+	for _, code := range []string{"", "00", runtimeHex, "a1" + "0003"} {
+		if _, err := ParseMetadata(decodeHex(t, code)); err == nil {
+			t.Fatalf("Expected error for %s", code)
+		}
+	}
+}
@@ -0,0 +1,454 @@
+package evm
+
+import (
+	"encoding/hex"
+	"errors"
+	"ethereum/accnt"
+	"ethereum/txn"
+	"ethereum/util"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Errors which halt execution.  All of them but ErrExecutionReverted
+// consume all the gas given to the call.
+var (
+	ErrOutOfGas                 = errors.New("out of gas")
+	ErrStackUnderflow           = errors.New("stack underflow")
+	ErrStackOverflow            = errors.New("stack overflow")
+	ErrInvalidJump              = errors.New("invalid jump destination")
+	ErrInvalidOpCode            = errors.New("invalid opcode")
+	ErrWriteProtection          = errors.New("write protection")
+	ErrReturnDataOutOfBounds    = errors.New("return data out of bounds")
+	ErrExecutionReverted        = errors.New("execution reverted")
+	ErrDepth                    = errors.New("max call depth exceeded")
+	ErrInsufficientBalance      = errors.New("insufficient balance for transfer")
+	ErrContractAddressCollision = errors.New("contract address collision")
+	ErrMaxCodeSizeExceeded      = errors.New("max code size exceeded")
+	ErrMaxInitCodeSizeExceeded  = errors.New("max initcode size exceeded")
+	ErrInvalidCode              = errors.New("invalid code: must not begin with 0xef")
+	ErrCodeStoreOutOfGas        = errors.New("contract creation code storage out of gas")
+	ErrNonceMax                 = errors.New("nonce has max value")
+)
+
+// BlockContext is the block a transaction executes in.
+type BlockContext struct {
+	Coinbase    accnt.Address
+	Number      uint64
+	Time        uint64
+	GasLimit    uint64
+	Difficulty  *big.Int // before Paris
+	Random      *big.Int // PREVRANDAO, from Paris
+	BaseFee     *big.Int // from London
+	BlobBaseFee *big.Int // from Cancun
+
+	// GetHash returns the hash of a previous block by number.  Nil hashes
+	// are zero.
+	GetHash func(number uint64) []byte
+}
+
+// TxContext is the transaction executing.
+type TxContext struct {
+	Origin     accnt.Address
+	GasPrice   *big.Int
+	BlobHashes [][]byte
+}
+
+// Config is the chain configuration.
+type Config struct {
+	Fork    Fork
+	ChainID *big.Int
+
+	// Precompiles are the precompiled contracts, keyed by `0xHEX` address,
+	// such as those returned by Precompiles.
+	Precompiles map[string]Precompile
+}
+
+// Precompile is a contract implemented natively rather than in EVM code.
+type Precompile interface {
+	RequiredGas(input []byte) uint64
+	Run(input []byte) ([]byte, error)
+}
+
+// EVM executes calls and transactions against a state.  An EVM executes one
+// transaction at a time, and isn't safe for concurrent use.
+type EVM struct {
+	Block  BlockContext
+	Tx     TxContext
+	State  StateDB
+	Config Config
+
+	depth int
+	tx    *txState
+}
+
+// New returns an EVM executing against state in a block.
+func New(block BlockContext, state StateDB, config Config) *EVM {
+	return &EVM{Block: block, State: state, Config: config, tx: newTxState()}
+}
+
+func (evm *EVM) precompile(addr accnt.Address) (Precompile, bool) {
+	p, ok := evm.Config.Precompiles["0x"+hex.EncodeToString(addr)]
+	return p, ok
+}
+
+// empty reports whether an account is empty as defined by EIP-161.
+func (evm *EVM) empty(addr accnt.Address) bool {
+	return evm.State.GetNonce(addr) == 0 && evm.State.GetBalance(addr).Sign() == 0 &&
+		len(evm.State.GetCode(addr)) == 0
+}
+
+type snapshot struct {
+	state, tx int
+}
+
+func (evm *EVM) snapshot() snapshot {
+	return snapshot{evm.State.Snapshot(), len(evm.tx.journal)}
+}
+
+func (evm *EVM) revert(s snapshot) {
+	evm.State.RevertToSnapshot(s.state)
+	evm.tx.revert(s.tx)
+}
+
+// transfer moves value between accounts, touching the recipient.
+func (evm *EVM) transfer(from, to accnt.Address, value *big.Int) {
+	if value.Sign() != 0 {
+		evm.State.SetBalance(from, new(big.Int).Sub(evm.State.GetBalance(from), value))
+		evm.State.SetBalance(to, new(big.Int).Add(evm.State.GetBalance(to), value))
+	}
+	evm.tx.touch(to)
+}
+
+// Call calls the contract at to with input, transferring value to it from
+// caller.  It returns the output of the call and the gas left.  If the
+// contract reverts, the output is the revert data and the error is
+// ErrExecutionReverted.
+func (evm *EVM) Call(caller, to accnt.Address, input []byte, gas uint64, value *big.Int) ([]byte, uint64, error) {
+	if value == nil {
+		value = new(big.Int)
+	}
+	if evm.depth > maxCallDepth {
+		return nil, gas, ErrDepth
+	}
+	if value.Sign() != 0 && evm.State.GetBalance(caller).Cmp(value) < 0 {
+		return nil, gas, ErrInsufficientBalance
+	}
+
+	snap := evm.snapshot()
+	p, isPrecompile := evm.precompile(to)
+	if !evm.State.Exists(to) {
+		if !isPrecompile && evm.Config.Fork >= SpuriousDragon && value.Sign() == 0 {
+			return nil, gas, nil
+		}
+		evm.State.CreateAccount(to)
+	}
+	evm.transfer(caller, to, value)
+
+	var ret []byte
+	var err error
+	if isPrecompile {
+		ret, gas, err = runPrecompile(p, input, gas)
+	} else {
+		f := evm.newFrame(caller, to, value, input, evm.State.GetCode(to), gas, false)
+		ret, err = f.run()
+		gas = f.gas
+	}
+	return ret, evm.finishCall(snap, gas, err), err
+}
+
+// CallCode calls the code of the contract at to in the context of caller,
+// as Call.
+func (evm *EVM) CallCode(caller, to accnt.Address, input []byte, gas uint64, value *big.Int) ([]byte, uint64, error) {
+	if value == nil {
+		value = new(big.Int)
+	}
+	if evm.depth > maxCallDepth {
+		return nil, gas, ErrDepth
+	}
+	if value.Sign() != 0 && evm.State.GetBalance(caller).Cmp(value) < 0 {
+		return nil, gas, ErrInsufficientBalance
+	}
+
+	snap := evm.snapshot()
+	var ret []byte
+	var err error
+	if p, ok := evm.precompile(to); ok {
+		ret, gas, err = runPrecompile(p, input, gas)
+	} else {
+		f := evm.newFrame(caller, caller, value, input, evm.State.GetCode(to), gas, false)
+		ret, err = f.run()
+		gas = f.gas
+	}
+	return ret, evm.finishCall(snap, gas, err), err
+}
+
+// delegateCall runs the code at to in the context of parent, keeping its
+// caller and value.
+func (evm *EVM) delegateCall(parent *frame, to accnt.Address, input []byte, gas uint64) ([]byte, uint64, error) {
+	if evm.depth > maxCallDepth {
+		return nil, gas, ErrDepth
+	}
+
+	snap := evm.snapshot()
+	var ret []byte
+	var err error
+	if p, ok := evm.precompile(to); ok {
+		ret, gas, err = runPrecompile(p, input, gas)
+	} else {
+		f := evm.newFrame(parent.caller, parent.address, parent.value, input, evm.State.GetCode(to), gas,
+			parent.static)
+		ret, err = f.run()
+		gas = f.gas
+	}
+	return ret, evm.finishCall(snap, gas, err), err
+}
+
+// StaticCall calls the contract at to as Call, without value, reverting if
+// it modifies state.
+func (evm *EVM) StaticCall(caller, to accnt.Address, input []byte, gas uint64) ([]byte, uint64, error) {
+	if evm.depth > maxCallDepth {
+		return nil, gas, ErrDepth
+	}
+
+	snap := evm.snapshot()
+	// The recipient is touched, as by a call of zero value, although it
+	// can't change as a result.
+	evm.tx.touch(to)
+
+	var ret []byte
+	var err error
+	if p, ok := evm.precompile(to); ok {
+		ret, gas, err = runPrecompile(p, input, gas)
+	} else {
+		f := evm.newFrame(caller, to, new(big.Int), input, evm.State.GetCode(to), gas, true)
+		ret, err = f.run()
+		gas = f.gas
+	}
+	return ret, evm.finishCall(snap, gas, err), err
+}
+
+// finishCall reverts the changes of a failed call, returning the gas left.
+func (evm *EVM) finishCall(snap snapshot, gas uint64, err error) uint64 {
+	if err == nil {
+		return gas
+	}
+	evm.revert(snap)
+	if err == ErrExecutionReverted {
+		return gas
+	}
+	return 0
+}
+
+func runPrecompile(p Precompile, input []byte, gas uint64) ([]byte, uint64, error) {
+	cost := p.RequiredGas(input)
+	if cost > gas {
+		return nil, 0, ErrOutOfGas
+	}
+	ret, err := p.Run(input)
+	return ret, gas - cost, err
+}
+
+// CreateAddress returns the address of the contract created by CREATE.
+func CreateAddress(sender accnt.Address, nonce uint64) accnt.Address {
+	return crypto.Keccak256(util.EncodeRLP([][]byte{sender, util.IntToArr(nonce)}))[12:]
+}
+
+// Create2Address returns the address of the contract created by CREATE2.
+func Create2Address(sender accnt.Address, salt *big.Int, initCode []byte) accnt.Address {
+	return crypto.Keccak256([]byte{0xff}, sender, salt.FillBytes(make([]byte, 32)), crypto.Keccak256(initCode))[12:]
+}
+
+// Create creates a contract with the given init code, returning the output
+// of the init code, which is the revert data if it reverts, the address of
+// the contract and the gas left.
+func (evm *EVM) Create(caller accnt.Address, code []byte, gas uint64, value *big.Int) ([]byte, accnt.Address, uint64, error) {
+	addr := CreateAddress(caller, evm.State.GetNonce(caller))
+	return evm.create(caller, code, gas, value, addr)
+}
+
+// Create2 creates a contract as Create, at an address given by the salt.
+func (evm *EVM) Create2(caller accnt.Address, code []byte, gas uint64, value, salt *big.Int) ([]byte, accnt.Address, uint64, error) {
+	return evm.create(caller, code, gas, value, Create2Address(caller, salt, code))
+}
+
+func (evm *EVM) create(caller accnt.Address, code []byte, gas uint64, value *big.Int, addr accnt.Address) ([]byte, accnt.Address, uint64, error) {
+	if value == nil {
+		value = new(big.Int)
+	}
+	if evm.depth > maxCallDepth {
+		return nil, nil, gas, ErrDepth
+	}
+	if evm.State.GetBalance(caller).Cmp(value) < 0 {
+		return nil, nil, gas, ErrInsufficientBalance
+	}
+	nonce := evm.State.GetNonce(caller)
+	if nonce+1 < nonce {
+		return nil, nil, gas, ErrNonceMax
+	}
+	evm.State.SetNonce(caller, nonce+1)
+
+	// The address stays warm even if the creation fails.
+	if evm.Config.Fork >= Berlin {
+		evm.tx.addAddress(addr)
+	}
+	if evm.State.GetNonce(addr) != 0 || len(evm.State.GetCode(addr)) != 0 {
+		return nil, nil, 0, ErrContractAddressCollision
+	}
+
+	snap := evm.snapshot()
+	evm.State.CreateAccount(addr)
+	if evm.Config.Fork >= SpuriousDragon {
+		evm.State.SetNonce(addr, 1)
+	}
+	evm.transfer(caller, addr, value)
+	evm.tx.markCreated(addr)
+
+	f := evm.newFrame(caller, addr, value, nil, code, gas, false)
+	ret, err := f.run()
+	gas = f.gas
+
+	if err == nil && evm.Config.Fork >= SpuriousDragon && len(ret) > maxCodeSize {
+		err = ErrMaxCodeSizeExceeded
+	}
+	if err == nil && evm.Config.Fork >= London && len(ret) > 0 && ret[0] == 0xef {
+		err = ErrInvalidCode
+	}
+	if err == nil {
+		deposit := uint64(len(ret)) * gasCodeDeposit
+		if gas >= deposit {
+			gas -= deposit
+			evm.State.SetCode(addr, ret)
+		} else if evm.Config.Fork >= Homestead {
+			err = ErrCodeStoreOutOfGas
+		}
+	}
+
+	if err != nil {
+		gas = evm.finishCall(snap, gas, err)
+		if err != ErrExecutionReverted {
+			ret = nil
+		}
+		return ret, addr, gas, err
+	}
+	return nil, addr, gas, nil
+}
+
+// txState is the state of the executing transaction which isn't part of the
+// world state: the access list, transient storage, refund, logs, and the
+// accounts touched, created and self destructed.
+type txState struct {
+	journal []func()
+
+	addresses map[string]bool
+	slots     map[string]bool // keyed by address and slot
+	transient map[string]*big.Int
+	refund    uint64
+	logs      []txn.Log
+	touched   map[string]bool
+	created   map[string]bool
+	destructs map[string]bool
+
+	// originals are the values of storage slots at the start of the
+	// transaction, which isn't reverted.
+	originals map[string]*big.Int
+}
+
+func newTxState() *txState {
+	return &txState{
+		addresses: make(map[string]bool),
+		slots:     make(map[string]bool),
+		transient: make(map[string]*big.Int),
+		touched:   make(map[string]bool),
+		created:   make(map[string]bool),
+		destructs: make(map[string]bool),
+		originals: make(map[string]*big.Int),
+	}
+}
+
+func (s *txState) revert(id int) {
+	for i := len(s.journal) - 1; i >= id; i-- {
+		s.journal[i]()
+	}
+	s.journal = s.journal[:id]
+}
+
+// setFlag sets m[key], journaling the change.
+func (s *txState) setFlag(m map[string]bool, key string) {
+	if m[key] {
+		return
+	}
+	m[key] = true
+	s.journal = append(s.journal, func() { delete(m, key) })
+}
+
+func (s *txState) addAddress(addr accnt.Address) bool {
+	warm := s.addresses[string(addr)]
+	s.setFlag(s.addresses, string(addr))
+	return warm
+}
+
+func (s *txState) addSlot(addr accnt.Address, key *big.Int) bool {
+	k := string(addr) + storageKey(key)
+	warm := s.slots[k]
+	s.setFlag(s.slots, k)
+	return warm
+}
+
+func (s *txState) touch(addr accnt.Address) {
+	s.setFlag(s.touched, string(addr))
+}
+
+func (s *txState) markCreated(addr accnt.Address) {
+	s.setFlag(s.created, string(addr))
+}
+
+func (s *txState) selfDestruct(addr accnt.Address) bool {
+	destructed := s.destructs[string(addr)]
+	s.setFlag(s.destructs, string(addr))
+	return destructed
+}
+
+func (s *txState) addRefund(gas int64) {
+	prev := s.refund
+	s.journal = append(s.journal, func() { s.refund = prev })
+	s.refund = uint64(int64(s.refund) + gas)
+}
+
+func (s *txState) addLog(l txn.Log) {
+	n := len(s.logs)
+	s.journal = append(s.journal, func() { s.logs = s.logs[:n] })
+	s.logs = append(s.logs, l)
+}
+
+func (s *txState) getTransient(addr accnt.Address, key *big.Int) *big.Int {
+	if v := s.transient[string(addr)+storageKey(key)]; v != nil {
+		return new(big.Int).Set(v)
+	}
+	return new(big.Int)
+}
+
+func (s *txState) setTransient(addr accnt.Address, key, value *big.Int) {
+	k := string(addr) + storageKey(key)
+	prev, ok := s.transient[k]
+	s.journal = append(s.journal, func() {
+		if ok {
+			s.transient[k] = prev
+		} else {
+			delete(s.transient, k)
+		}
+	})
+	s.transient[k] = new(big.Int).Set(value)
+}
+
+// original returns the value of a storage slot at the start of the
+// transaction, given its current value if it hasn't been read before.
+func (s *txState) original(addr accnt.Address, key, current *big.Int) *big.Int {
+	k := string(addr) + storageKey(key)
+	if v, ok := s.originals[k]; ok {
+		return v
+	}
+	s.originals[k] = new(big.Int).Set(current)
+	return s.originals[k]
+}
@@ -0,0 +1,311 @@
+package evm
+
+import (
+	"bytes"
+	"encoding/hex"
+	"ethereum/accnt"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+var (
+	testCaller   = accnt.Address(bytes.Repeat([]byte{0xbb}, 20))
+	testContract = accnt.Address(bytes.Repeat([]byte{0xaa}, 20))
+)
+
+// word left pads hex to 32 bytes.
+func word(hx string) string {
+	return strings.Repeat("0", 64-len(hx)) + hx
+}
+
+func mustDecode(t *testing.T, hx string) []byte {
+	b, err := hex.DecodeString(strings.TrimPrefix(hx, "0x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// newTestEVM returns an EVM with code deployed at testContract, and a
+// funded testCaller.
+func newTestEVM(fork Fork, code []byte) *EVM {
+	state := NewMemoryState()
+	state.SetCode(testContract, code)
+	state.SetBalance(testCaller, big.NewInt(1e18))
+	state.Commit()
+	return New(BlockContext{Number: 1}, state, Config{Fork: fork, ChainID: big.NewInt(1)})
+}
+
+func TestSstoreGas(t *testing.T) {
+	// From EIP-2200 and EIP-3529.  The London cases run with the slot
+	// already warm.
+	var tests = []struct {
+		fork     Fork
+		code     string
+		original int64
+		used     uint64
+		refund   uint64
+	}{
+		{Istanbul, "0x60006000556000600055", 0, 1612, 0},
+		{Istanbul, "0x60006000556001600055", 0, 20812, 0},
+		{Istanbul, "0x60016000556000600055", 0, 20812, 19200},
+		{Istanbul, "0x60016000556002600055", 0, 20812, 0},
+		{Istanbul, "0x60016000556001600055", 0, 20812, 0},
+		{Istanbul, "0x60006000556000600055", 1, 5812, 15000},
+		{Istanbul, "0x60006000556001600055", 1, 5812, 4200},
+		{Istanbul, "0x60006000556002600055", 1, 5812, 0},
+		{Istanbul, "0x60026000556000600055", 1, 5812, 15000},
+		{Istanbul, "0x60026000556003600055", 1, 5812, 0},
+		{Istanbul, "0x60026000556001600055", 1, 5812, 4200},
+		{Istanbul, "0x60026000556002600055", 1, 5812, 0},
+		{Istanbul, "0x60016000556000600055", 1, 5812, 15000},
+		{Istanbul, "0x60016000556002600055", 1, 5812, 0},
+		{Istanbul, "0x60016000556001600055", 1, 1612, 0},
+		{Istanbul, "0x600160005560006000556001600055", 0, 40818, 19200},
+		{Istanbul, "0x600060005560016000556000600055", 1, 10818, 19200},
+		{London, "0x60006000556000600055", 0, 212, 0},
+		{London, "0x60016000556000600055", 0, 20112, 19900},
+		{London, "0x60006000556001600055", 1, 3012, 2800},
+		{London, "0x60006000556000600055", 1, 3012, 4800},
+		{Constantinople, "0x60016000556000600055", 0, 25012, 15000},
+	}
+
+	for _, test := range tests {
+		evm := newTestEVM(test.fork, mustDecode(t, test.code))
+		evm.State.SetStorage(testContract, new(big.Int), big.NewInt(test.original))
+		if test.fork >= Berlin {
+			evm.tx.addSlot(testContract, new(big.Int))
+		}
+
+		_, gas, err := evm.Call(testCaller, testContract, nil, 100000, nil)
+		if err != nil {
+			t.Fatalf("%s: %s", test.code, err)
+		}
+		if used := 100000 - gas; used != test.used {
+			t.Errorf("%s %d: Expected: %d, received: %d", test.code, test.original, test.used, used)
+		}
+		if evm.tx.refund != test.refund {
+			t.Errorf("%s %d: Expected refund: %d, received: %d", test.code, test.original, test.refund,
+				evm.tx.refund)
+		}
+	}
+}
+
+func TestShift(t *testing.T) {
+	// From EIP-145.
+	max := strings.Repeat("f", 64)
+	min := "8" + strings.Repeat("0", 63)
+	var tests = []struct {
+		op       string
+		value    string
+		shift    string
+		expected string
+	}{
+		{"1b", "01", "00", "01"},
+		{"1b", "01", "01", "02"},
+		{"1b", "01", "ff", min},
+		{"1b", "01", "0100", "00"},
+		{"1b", "01", "0101", "00"},
+		{"1b", max, "00", max},
+		{"1b", max, "01", strings.Repeat("f", 63) + "e"},
+		{"1b", max, "ff", min},
+		{"1b", max, "0100", "00"},
+		{"1b", "00", "01", "00"},
+		{"1b", "7" + strings.Repeat("f", 63), "01", strings.Repeat("f", 63) + "e"},
+		{"1c", "01", "00", "01"},
+		{"1c", "01", "01", "00"},
+		{"1c", min, "01", "4" + strings.Repeat("0", 63)},
+		{"1c", min, "ff", "01"},
+		{"1c", min, "0100", "00"},
+		{"1c", max, "01", "7" + strings.Repeat("f", 63)},
+		{"1c", max, "ff", "01"},
+		{"1c", max, "0100", "00"},
+		{"1d", "01", "00", "01"},
+		{"1d", "01", "01", "00"},
+		{"1d", min, "01", "c" + strings.Repeat("0", 63)},
+		{"1d", min, "ff", max},
+		{"1d", min, "0100", max},
+		{"1d", min, "0101", max},
+		{"1d", max, "00", max},
+		{"1d", max, "0100", max},
+		{"1d", "00", "01", "00"},
+		{"1d", "4" + strings.Repeat("0", 63), "fe", "01"},
+		{"1d", "7" + strings.Repeat("f", 63), "f8", "7f"},
+		{"1d", "7" + strings.Repeat("f", 63), "fe", "01"},
+		{"1d", "7" + strings.Repeat("f", 63), "ff", "00"},
+		{"1d", "7" + strings.Repeat("f", 63), "0100", "00"},
+	}
+
+	for _, test := range tests {
+		// PUSH32 value PUSH32 shift op, and return the result.
+		code := "7f" + word(test.value) + "7f" + word(test.shift) + test.op + "60005260206000f3"
+		evm := newTestEVM(Constantinople, mustDecode(t, code))
+		ret, _, err := evm.Call(testCaller, testContract, nil, 100000, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(ret) != word(test.expected) {
+			t.Errorf("%s %s %s: Expected: %s, received: %x", test.op, test.value, test.shift, word(test.expected),
+				ret)
+		}
+	}
+}
+
+func TestCreateAddress(t *testing.T) {
+	sender := accnt.Address(mustDecode(t, "6ac7ea33f8831ea9dcc53393aaa88b25a785dbf0"))
+	var tests = []struct {
+		nonce    uint64
+		expected string
+	}{
+		{0, "0xcd234a471b72ba2f1ccf0a70fcaba648a5eecd8d"},
+		{1, "0x343c43a37d37dff08ae8c4a11544c718abb4fcf8"},
+		{2, "0xf778b86fa74e846c4f0a1fbd1335fe81c00a0c91"},
+	}
+
+	for _, test := range tests {
+		if a := CreateAddress(sender, test.nonce).String(); a != test.expected {
+			t.Errorf("Expected: %s, received: %s", test.expected, a)
+		}
+	}
+}
+
+func TestCreate2Address(t *testing.T) {
+	// From EIP-1014.
+	var tests = []struct {
+		sender   string
+		salt     string
+		initCode string
+		expected string
+	}{
+		{"0000000000000000000000000000000000000000", "00", "00", "0x4d1a2e2bb4f88f0250f26ffff098b0b30b26bf38"},
+		{"deadbeef00000000000000000000000000000000", "00", "00", "0xb928f69bb1d91cd65274e3c79d8986362984fda3"},
+		{"deadbeef00000000000000000000000000000000", "feed000000000000000000000000000000000000", "00",
+			"0xd04116cdd17bebe565eb2422f2497e06cc1c9833"},
+		{"0000000000000000000000000000000000000000", "00", "deadbeef", "0x70f2b2914a2a4b783faefb75f459a580616fcb5e"},
+		{"00000000000000000000000000000000deadbeef", "cafebabe", "deadbeef",
+			"0x60f3f640a8508fc6a86d45df051962668e1e8ac7"},
+		{"0000000000000000000000000000000000000000", "00", "", "0xe33c0c7f7df4809055c3eba6c09cfe4baf1bd9e0"},
+	}
+
+	for _, test := range tests {
+		salt := new(big.Int).SetBytes(mustDecode(t, test.salt))
+		a := Create2Address(mustDecode(t, test.sender), salt, mustDecode(t, test.initCode)).String()
+		if a != test.expected {
+			t.Errorf("Expected: %s, received: %s", test.expected, a)
+		}
+	}
+}
+
+func TestRevert(t *testing.T) {
+	// SSTORE 1 at 0, then REVERT with 0xabcd.
+	evm := newTestEVM(Cancun, mustDecode(t, "0x6001600055"+"61abcd6000526002601ef3"))
+	ret, _, err := evm.Call(testCaller, testContract, nil, 100000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(ret) != "abcd" {
+		t.Fatalf("Expected: abcd, received: %x", ret)
+	}
+
+	evm = newTestEVM(Cancun, mustDecode(t, "0x6001600055"+"61abcd6000526002601efd"))
+	ret, gas, err := evm.Call(testCaller, testContract, nil, 100000, nil)
+	if err != ErrExecutionReverted {
+		t.Fatalf("Expected: %v, received: %v", ErrExecutionReverted, err)
+	}
+	if hex.EncodeToString(ret) != "abcd" {
+		t.Fatalf("Expected: abcd, received: %x", ret)
+	}
+	if gas == 0 {
+		t.Fatal("Expected gas left after a revert")
+	}
+	if v := evm.State.GetStorage(testContract, new(big.Int)); v.Sign() != 0 {
+		t.Fatalf("Expected: 0, received: %s", v)
+	}
+}
+
+func TestStaticCall(t *testing.T) {
+	// SSTORE 1 at 0.
+	evm := newTestEVM(Cancun, mustDecode(t, "0x6001600055"))
+	_, gas, err := evm.StaticCall(testCaller, testContract, nil, 100000)
+	if err != ErrWriteProtection {
+		t.Fatalf("Expected: %v, received: %v", ErrWriteProtection, err)
+	}
+	if gas != 0 {
+		t.Fatalf("Expected: 0, received: %d", gas)
+	}
+}
+
+type testPrecompile struct{}
+
+func (testPrecompile) RequiredGas(input []byte) uint64 {
+	return 10 + uint64(len(input))
+}
+
+func (testPrecompile) Run(input []byte) ([]byte, error) {
+	out := make([]byte, len(input))
+	for i, b := range input {
+		out[len(input)-1-i] = b
+	}
+	return out, nil
+}
+
+func TestPrecompile(t *testing.T) {
+	// STATICCALL 0x0b with 0x0102, and return its output.
+	code := "610102600052" + "600260006002601e600b61fffffa50" + "60026000f3"
+	evm := newTestEVM(Cancun, mustDecode(t, code))
+	evm.Config.Precompiles = map[string]Precompile{"0x000000000000000000000000000000000000000b": testPrecompile{}}
+
+	ret, _, err := evm.Call(testCaller, testContract, nil, 100000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(ret) != "0201" {
+		t.Fatalf("Expected: 0201, received: %x", ret)
+	}
+}
+
+func TestApplyMessage(t *testing.T) {
+	evm := newTestEVM(Cancun, nil)
+	evm.Block.BaseFee = big.NewInt(1)
+
+	// Deploy code which returns 42, then call it as eth_call does.
+	runtime := "602a60005260206000f3"
+	res, err := ApplyMessage(evm, Message{
+		From:     testCaller,
+		Data:     mustDecode(t, "600a80600b6000396000f3"+runtime),
+		GasLimit: 100000,
+		GasPrice: big.NewInt(1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Failed() {
+		t.Fatal(res.Err)
+	}
+	if a := CreateAddress(testCaller, 0).String(); res.ContractAddress.String() != a {
+		t.Fatalf("Expected: %s, received: %s", a, res.ContractAddress)
+	}
+	if c := hex.EncodeToString(evm.State.GetCode(res.ContractAddress)); c != runtime {
+		t.Fatalf("Expected: %s, received: %s", runtime, c)
+	}
+
+	res, err = ApplyMessage(evm, Message{
+		From:           testCaller,
+		To:             res.ContractAddress,
+		GasLimit:       100000,
+		GasPrice:       big.NewInt(1),
+		SkipNonceCheck: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(res.ReturnData) != word("2a") {
+		t.Fatalf("Expected: %s, received: %x", word("2a"), res.ReturnData)
+	}
+
+	_, err = ApplyMessage(evm, Message{From: testCaller, Nonce: 5, To: testContract, GasLimit: 100000})
+	if err != ErrNonceTooHigh {
+		t.Fatalf("Expected: %v, received: %v", ErrNonceTooHigh, err)
+	}
+}
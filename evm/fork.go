@@ -0,0 +1,60 @@
+package evm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Fork is a hard fork of Ethereum, whose rules the EVM follows from it until
+// the next fork.
+type Fork int
+
+const (
+	Frontier Fork = iota
+	Homestead
+	TangerineWhistle // EIP-150
+	SpuriousDragon   // EIP-155, EIP-158
+	Byzantium
+	Constantinople // as Petersburg, without EIP-1283
+	Petersburg
+	Istanbul
+	Berlin
+	London
+	Paris // the merge
+	Shanghai
+	Cancun
+)
+
+var forkNames = []string{
+	"Frontier", "Homestead", "TangerineWhistle", "SpuriousDragon", "Byzantium", "Constantinople", "Petersburg",
+	"Istanbul", "Berlin", "London", "Paris", "Shanghai", "Cancun",
+}
+
+// Latest is the latest fork the EVM supports.
+const Latest = Cancun
+
+func (f Fork) String() string {
+	if f < 0 || int(f) >= len(forkNames) {
+		return fmt.Sprintf("Fork(%d)", int(f))
+	}
+	return forkNames[f]
+}
+
+// ParseFork returns the fork with the given name, as used by the state
+// tests.  "EIP150", "EIP158" and "Merge" are accepted too.
+func ParseFork(name string) (Fork, error) {
+	switch name {
+	case "EIP150":
+		return TangerineWhistle, nil
+	case "EIP158":
+		return SpuriousDragon, nil
+	case "Merge":
+		return Paris, nil
+	}
+	for i, n := range forkNames {
+		if strings.EqualFold(n, name) {
+			return Fork(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown fork %q", name)
+}
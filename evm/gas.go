@@ -0,0 +1,302 @@
+package evm
+
+import "math/big"
+
+// Gas costs, named as in the yellow paper and EIPs.
+const (
+	gasZero    = 0
+	gasBase    = 2
+	gasVeryLow = 3
+	gasLow     = 5
+	gasMid     = 8
+	gasHigh    = 10
+
+	gasJumpDest     = 1
+	gasBlockHash    = 20
+	gasKeccak256    = 30
+	gasKeccakWord   = 6
+	gasCopyWord     = 3
+	gasMemory       = 3
+	gasQuadCoeff    = 512
+	gasLog          = 375
+	gasLogTopic     = 375
+	gasLogData      = 8
+	gasCreate       = 32000
+	gasCodeDeposit  = 200
+	gasInitCodeWord = 2 // EIP-3860
+	gasTransient    = 100
+
+	gasCallValue          = 9000
+	gasCallStipend        = 2300
+	gasNewAccount         = 25000
+	gasSelfDestructRefund = 24000
+
+	gasSstoreSet         = 20000
+	gasSstoreReset       = 5000
+	gasSstoreClearRefund = 15000
+	gasSstoreSentry      = 2300 // EIP-2200
+
+	// EIP-2929
+	gasColdSload         = 2100
+	gasColdAccountAccess = 2600
+	gasWarmAccess        = 100
+
+	// EIP-3529
+	gasSstoreClearRefundLondon = gasSstoreReset - gasColdSload + 1900
+
+	gasTx                    = 21000
+	gasTxCreate              = 53000
+	gasTxDataZero            = 4
+	gasTxDataNonZero         = 68
+	gasTxDataNonZeroIstanbul = 16
+	gasTxAccessListAddress   = 2400
+	gasTxAccessListKey       = 1900
+)
+
+const (
+	maxCodeSize     = 24576           // EIP-170
+	maxInitCodeSize = 2 * maxCodeSize // EIP-3860
+	maxCallDepth    = 1024
+	maxStack        = 1024
+)
+
+// constantGas returns the gas an opcode costs before any dynamic costs.
+// Under EIP-2929, accesses to accounts and storage cost nothing up front, and
+// are charged warm or cold dynamically.
+func constantGas(op OpCode, fork Fork) uint64 {
+	switch {
+	case op >= PUSH1 && op <= PUSH32, op >= DUP1 && op <= DUP16, op >= SWAP1 && op <= SWAP16:
+		return gasVeryLow
+	case op >= LOG0 && op <= LOG4:
+		return gasLog + gasLogTopic*uint64(op-LOG0)
+	}
+
+	switch op {
+	case STOP, RETURN, REVERT, INVALID:
+		return gasZero
+	case ADDRESS, ORIGIN, CALLER, CALLVALUE, CALLDATASIZE, CODESIZE, GASPRICE, COINBASE, TIMESTAMP, NUMBER,
+		PREVRANDAO, GASLIMIT, RETURNDATASIZE, POP, PC, MSIZE, GAS, CHAINID, BASEFEE, PUSH0, BLOBBASEFEE:
+		return gasBase
+	case ADD, SUB, NOT, LT, GT, SLT, SGT, EQ, ISZERO, AND, OR, XOR, BYTE, SHL, SHR, SAR, CALLDATALOAD, MLOAD,
+		MSTORE, MSTORE8, CALLDATACOPY, CODECOPY, RETURNDATACOPY, MCOPY, BLOBHASH:
+		return gasVeryLow
+	case MUL, DIV, SDIV, MOD, SMOD, SIGNEXTEND, SELFBALANCE:
+		return gasLow
+	case ADDMOD, MULMOD, JUMP:
+		return gasMid
+	case JUMPI, EXP:
+		return gasHigh
+	case JUMPDEST:
+		return gasJumpDest
+	case BLOCKHASH:
+		return gasBlockHash
+	case KECCAK256:
+		return gasKeccak256
+	case CREATE, CREATE2:
+		return gasCreate
+	case TLOAD, TSTORE:
+		return gasTransient
+	case SSTORE:
+		return 0
+	}
+
+	if fork >= Berlin {
+		switch op {
+		case BALANCE, EXTCODESIZE, EXTCODECOPY, EXTCODEHASH, SLOAD, CALL, CALLCODE, DELEGATECALL, STATICCALL:
+			return 0
+		case SELFDESTRUCT:
+			return 5000
+		}
+	}
+
+	switch op {
+	case BALANCE:
+		switch {
+		case fork >= Istanbul:
+			return 700
+		case fork >= TangerineWhistle:
+			return 400
+		}
+		return 20
+	case EXTCODESIZE, EXTCODECOPY:
+		if fork >= TangerineWhistle {
+			return 700
+		}
+		return 20
+	case EXTCODEHASH:
+		if fork >= Istanbul {
+			return 700
+		}
+		return 400
+	case SLOAD:
+		switch {
+		case fork >= Istanbul:
+			return 800
+		case fork >= TangerineWhistle:
+			return 200
+		}
+		return 50
+	case CALL, CALLCODE, DELEGATECALL, STATICCALL:
+		if fork >= TangerineWhistle {
+			return 700
+		}
+		return 40
+	case SELFDESTRUCT:
+		if fork >= TangerineWhistle {
+			return 5000
+		}
+		return 0
+	}
+	return 0
+}
+
+// available reports whether op exists at fork.
+func available(op OpCode, fork Fork) bool {
+	if !op.Defined() || op == INVALID {
+		return false
+	}
+	switch op {
+	case DELEGATECALL:
+		return fork >= Homestead
+	case REVERT, RETURNDATASIZE, RETURNDATACOPY, STATICCALL:
+		return fork >= Byzantium
+	case SHL, SHR, SAR, CREATE2, EXTCODEHASH:
+		return fork >= Constantinople
+	case CHAINID, SELFBALANCE:
+		return fork >= Istanbul
+	case BASEFEE:
+		return fork >= London
+	case PUSH0:
+		return fork >= Shanghai
+	case TLOAD, TSTORE, MCOPY, BLOBHASH, BLOBBASEFEE:
+		return fork >= Cancun
+	}
+	return true
+}
+
+// stackIO is the number of items each opcode pops and pushes.
+var stackIO [256][2]int
+
+func init() {
+	set := func(pops, pushes int, ops ...OpCode) {
+		for _, op := range ops {
+			stackIO[op] = [2]int{pops, pushes}
+		}
+	}
+	set(0, 0, STOP, JUMPDEST, INVALID)
+	set(2, 1, ADD, MUL, SUB, DIV, SDIV, MOD, SMOD, EXP, SIGNEXTEND, LT, GT, SLT, SGT, EQ, AND, OR, XOR, BYTE,
+		SHL, SHR, SAR, KECCAK256)
+	set(3, 1, ADDMOD, MULMOD)
+	set(1, 1, ISZERO, NOT, BALANCE, CALLDATALOAD, EXTCODESIZE, EXTCODEHASH, BLOCKHASH, MLOAD, SLOAD, TLOAD,
+		BLOBHASH)
+	set(0, 1, ADDRESS, ORIGIN, CALLER, CALLVALUE, CALLDATASIZE, CODESIZE, GASPRICE, RETURNDATASIZE, COINBASE,
+		TIMESTAMP, NUMBER, PREVRANDAO, GASLIMIT, CHAINID, SELFBALANCE, BASEFEE, BLOBBASEFEE, PC, MSIZE, GAS, PUSH0)
+	set(3, 0, CALLDATACOPY, CODECOPY, RETURNDATACOPY, MCOPY)
+	set(4, 0, EXTCODECOPY)
+	set(1, 0, POP, JUMP, SELFDESTRUCT)
+	set(2, 0, MSTORE, MSTORE8, SSTORE, JUMPI, TSTORE, RETURN, REVERT)
+	set(3, 1, CREATE)
+	set(4, 1, CREATE2)
+	set(7, 1, CALL, CALLCODE)
+	set(6, 1, DELEGATECALL, STATICCALL)
+	for i := 0; i < 32; i++ {
+		set(0, 1, PUSH1+OpCode(i))
+	}
+	for i := 0; i < 16; i++ {
+		set(i+1, i+2, DUP1+OpCode(i))
+		set(i+2, i+2, SWAP1+OpCode(i))
+	}
+	for i := 0; i < 5; i++ {
+		set(i+2, 0, LOG0+OpCode(i))
+	}
+}
+
+// toWords returns the number of 32 byte words size bytes take.
+func toWords(size uint64) uint64 {
+	return (size + 31) / 32
+}
+
+// memoryGas returns the total cost of memory of size bytes.
+func memoryGas(size uint64) uint64 {
+	words := toWords(size)
+	return words*gasMemory + words*words/gasQuadCoeff
+}
+
+// IntrinsicGas returns the gas a transaction costs before it executes.
+func IntrinsicGas(data []byte, create bool, accessListAddresses, accessListKeys int, fork Fork) uint64 {
+	gas := uint64(gasTx)
+	if create && fork >= Homestead {
+		gas = gasTxCreate
+	}
+
+	nonZero := uint64(gasTxDataNonZero)
+	if fork >= Istanbul {
+		nonZero = gasTxDataNonZeroIstanbul
+	}
+	for _, b := range data {
+		if b == 0 {
+			gas += gasTxDataZero
+		} else {
+			gas += nonZero
+		}
+	}
+
+	if create && fork >= Shanghai {
+		gas += gasInitCodeWord * toWords(uint64(len(data)))
+	}
+	return gas + uint64(accessListAddresses)*gasTxAccessListAddress + uint64(accessListKeys)*gasTxAccessListKey
+}
+
+// sstoreGas returns the gas and refund change of an SSTORE of value to a slot
+// whose value is current, and was original at the start of the transaction.
+// It doesn't include the cold access cost of EIP-2929.
+func sstoreGas(original, current, value *big.Int, fork Fork) (uint64, int64) {
+	if fork < Istanbul {
+		switch {
+		case current.Sign() == 0 && value.Sign() != 0:
+			return gasSstoreSet, 0
+		case current.Sign() != 0 && value.Sign() == 0:
+			return gasSstoreReset, gasSstoreClearRefund
+		}
+		return gasSstoreReset, 0
+	}
+
+	// EIP-2200, repriced by EIP-2929 and EIP-3529.
+	var sloadGas, resetGas, clearRefund uint64 = 800, gasSstoreReset, gasSstoreClearRefund
+	if fork >= Berlin {
+		sloadGas, resetGas = gasWarmAccess, gasSstoreReset-gasColdSload
+	}
+	if fork >= London {
+		clearRefund = gasSstoreClearRefundLondon
+	}
+
+	if current.Cmp(value) == 0 {
+		return sloadGas, 0
+	}
+	if original.Cmp(current) == 0 {
+		if original.Sign() == 0 {
+			return gasSstoreSet, 0
+		}
+		if value.Sign() == 0 {
+			return resetGas, int64(clearRefund)
+		}
+		return resetGas, 0
+	}
+
+	var refund int64
+	if original.Sign() != 0 {
+		if current.Sign() == 0 {
+			refund -= int64(clearRefund)
+		} else if value.Sign() == 0 {
+			refund += int64(clearRefund)
+		}
+	}
+	if original.Cmp(value) == 0 {
+		if original.Sign() == 0 {
+			refund += int64(gasSstoreSet - sloadGas)
+		} else {
+			refund += int64(resetGas - sloadGas)
+		}
+	}
+	return sloadGas, refund
+}
@@ -0,0 +1,853 @@
+package evm
+
+import (
+	"encoding/hex"
+	"ethereum/accnt"
+	"ethereum/txn"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	tt255   = new(big.Int).Lsh(big.NewInt(1), 255)
+	tt256   = new(big.Int).Lsh(big.NewInt(1), 256)
+	tt256m1 = new(big.Int).Sub(tt256, big.NewInt(1))
+)
+
+// frame is the execution of code in a call or creation.
+type frame struct {
+	evm *EVM
+
+	caller  accnt.Address
+	address accnt.Address // whose storage and balance the code uses
+	value   *big.Int
+	input   []byte
+	code    []byte
+	static  bool
+
+	gas        uint64
+	pc         uint64
+	stack      []*big.Int
+	memory     []byte
+	returnData []byte // of the last call made
+
+	jumpDests []bool
+}
+
+func (evm *EVM) newFrame(caller, address accnt.Address, value *big.Int, input, code []byte,
+	gas uint64, static bool) *frame {
+	return &frame{
+		evm:     evm,
+		caller:  caller,
+		address: address,
+		value:   value,
+		input:   input,
+		code:    code,
+		static:  static,
+		gas:     gas,
+	}
+}
+
+func (f *frame) useGas(gas uint64) error {
+	if f.gas < gas {
+		return ErrOutOfGas
+	}
+	f.gas -= gas
+	return nil
+}
+
+func (f *frame) pop() *big.Int {
+	v := f.stack[len(f.stack)-1]
+	f.stack = f.stack[:len(f.stack)-1]
+	return v
+}
+
+func (f *frame) push(v *big.Int) {
+	f.stack = append(f.stack, v)
+}
+
+func (f *frame) peek(n int) *big.Int {
+	return f.stack[len(f.stack)-1-n]
+}
+
+// memoryEnd returns offset+size, or false if it's too large to be paid for.
+// Regions of zero size don't use memory, whatever their offset.
+func memoryEnd(offset, size *big.Int) (uint64, bool) {
+	if size.Sign() == 0 {
+		return 0, true
+	}
+	if !offset.IsUint64() || !size.IsUint64() {
+		return 0, false
+	}
+	end := offset.Uint64() + size.Uint64()
+	// Memory of 2^32 bytes costs more gas than can exist.
+	if end < offset.Uint64() || end > 1<<32 {
+		return 0, false
+	}
+	return end, true
+}
+
+// expand charges for and expands memory to hold the regions given as pairs
+// of offsets and sizes.
+func (f *frame) expand(regions ...*big.Int) error {
+	var end uint64
+	for i := 0; i < len(regions); i += 2 {
+		e, ok := memoryEnd(regions[i], regions[i+1])
+		if !ok {
+			return ErrOutOfGas
+		}
+		if e > end {
+			end = e
+		}
+	}
+	if end <= uint64(len(f.memory)) {
+		return nil
+	}
+
+	size := toWords(end) * 32
+	if err := f.useGas(memoryGas(size) - memoryGas(uint64(len(f.memory)))); err != nil {
+		return err
+	}
+	f.memory = append(f.memory, make([]byte, size-uint64(len(f.memory)))...)
+	return nil
+}
+
+// mem returns memory[offset:offset+size], which must have been expanded.
+func (f *frame) mem(offset, size *big.Int) []byte {
+	if size.Sign() == 0 {
+		return nil
+	}
+	return f.memory[offset.Uint64() : offset.Uint64()+size.Uint64()]
+}
+
+// copyGas charges for copying size bytes.
+func (f *frame) copyGas(size *big.Int) error {
+	if !size.IsUint64() || size.Uint64() > 1<<32 {
+		return ErrOutOfGas
+	}
+	return f.useGas(toWords(size.Uint64()) * gasCopyWord)
+}
+
+// slice returns data[offset:offset+size], padded with zeros past its end.
+func slice(data []byte, offset, size *big.Int) []byte {
+	out := make([]byte, size.Uint64())
+	if offset.IsUint64() && offset.Uint64() < uint64(len(data)) {
+		copy(out, data[offset.Uint64():])
+	}
+	return out
+}
+
+// accessAccount charges the EIP-2929 cost of accessing an account, warming
+// it.
+func (f *frame) accessAccount(addr accnt.Address) error {
+	if f.evm.Config.Fork < Berlin {
+		return nil
+	}
+	if f.evm.tx.addAddress(addr) {
+		return f.useGas(gasWarmAccess)
+	}
+	return f.useGas(gasColdAccountAccess)
+}
+
+func (f *frame) validJump(dest *big.Int) bool {
+	if f.jumpDests == nil {
+		f.jumpDests = make([]bool, len(f.code))
+		for pc := 0; pc < len(f.code); pc++ {
+			op := OpCode(f.code[pc])
+			if op == JUMPDEST {
+				f.jumpDests[pc] = true
+			}
+			pc += op.PushSize()
+		}
+	}
+	return dest.IsUint64() && dest.Uint64() < uint64(len(f.code)) && f.jumpDests[dest.Uint64()]
+}
+
+func toAddress(v *big.Int) accnt.Address {
+	b := v.FillBytes(make([]byte, 32))
+	return accnt.Address(b[12:])
+}
+
+func addressToInt(addr accnt.Address) *big.Int {
+	return new(big.Int).SetBytes(addr)
+}
+
+func toSigned(v *big.Int) *big.Int {
+	if v.Cmp(tt255) >= 0 {
+		return new(big.Int).Sub(v, tt256)
+	}
+	return v
+}
+
+func toUnsigned(v *big.Int) *big.Int {
+	return v.And(v, tt256m1)
+}
+
+func boolToInt(b bool) *big.Int {
+	if b {
+		return big.NewInt(1)
+	}
+	return new(big.Int)
+}
+
+// run executes the frame's code, returning its output.
+func (f *frame) run() (ret []byte, err error) {
+	f.evm.depth++
+	defer func() { f.evm.depth-- }()
+
+	fork := f.evm.Config.Fork
+	for {
+		op := STOP
+		if f.pc < uint64(len(f.code)) {
+			op = OpCode(f.code[f.pc])
+		}
+		if !available(op, fork) {
+			return nil, ErrInvalidOpCode
+		}
+
+		io := stackIO[op]
+		if len(f.stack) < io[0] {
+			return nil, ErrStackUnderflow
+		}
+		if len(f.stack)-io[0]+io[1] > maxStack {
+			return nil, ErrStackOverflow
+		}
+		if f.static && writes(op, f) {
+			return nil, ErrWriteProtection
+		}
+		if err := f.useGas(constantGas(op, fork)); err != nil {
+			return nil, err
+		}
+
+		ret, halt, err := f.execute(op)
+		if err != nil || halt {
+			return ret, err
+		}
+	}
+}
+
+// writes reports whether op modifies state, which isn't allowed in a static
+// call.
+func writes(op OpCode, f *frame) bool {
+	switch op {
+	case SSTORE, TSTORE, CREATE, CREATE2, SELFDESTRUCT:
+		return true
+	case CALL:
+		return f.peek(2).Sign() != 0
+	}
+	return op >= LOG0 && op <= LOG4
+}
+
+// execute executes op, returning whether execution halted, and its output if
+// so.  The pc is advanced past op unless it jumps.
+func (f *frame) execute(op OpCode) ([]byte, bool, error) {
+	evm := f.evm
+	fork := evm.Config.Fork
+	pc := f.pc
+	f.pc++
+
+	switch {
+	case op == PUSH0:
+		f.push(new(big.Int))
+		return nil, false, nil
+	case op.IsPush():
+		n := uint64(op.PushSize())
+		f.push(new(big.Int).SetBytes(slice(f.code, new(big.Int).SetUint64(pc+1), new(big.Int).SetUint64(n))))
+		f.pc += n
+		return nil, false, nil
+	case op >= DUP1 && op <= DUP16:
+		f.push(new(big.Int).Set(f.peek(int(op - DUP1))))
+		return nil, false, nil
+	case op >= SWAP1 && op <= SWAP16:
+		n := len(f.stack) - 1
+		m := n - int(op-SWAP1) - 1
+		f.stack[n], f.stack[m] = f.stack[m], f.stack[n]
+		return nil, false, nil
+	case op >= LOG0 && op <= LOG4:
+		offset, size := f.pop(), f.pop()
+		if err := f.expand(offset, size); err != nil {
+			return nil, false, err
+		}
+		if err := f.useGas(size.Uint64() * gasLogData); err != nil {
+			return nil, false, err
+		}
+		topics := make([]string, op-LOG0)
+		for i := range topics {
+			topics[i] = "0x" + hex.EncodeToString(f.pop().FillBytes(make([]byte, 32)))
+		}
+		evm.tx.addLog(txn.Log{
+			Address: f.address.String(),
+			Topics:  topics,
+			Data:    append([]byte{}, f.mem(offset, size)...),
+		})
+		return nil, false, nil
+	}
+
+	switch op {
+	case STOP:
+		return nil, true, nil
+
+	case ADD:
+		x, y := f.pop(), f.pop()
+		f.push(toUnsigned(x.Add(x, y)))
+	case MUL:
+		x, y := f.pop(), f.pop()
+		f.push(toUnsigned(x.Mul(x, y)))
+	case SUB:
+		x, y := f.pop(), f.pop()
+		f.push(toUnsigned(x.Sub(x, y)))
+	case DIV:
+		x, y := f.pop(), f.pop()
+		if y.Sign() == 0 {
+			f.push(new(big.Int))
+		} else {
+			f.push(x.Div(x, y))
+		}
+	case SDIV:
+		x, y := toSigned(f.pop()), toSigned(f.pop())
+		if y.Sign() == 0 {
+			f.push(new(big.Int))
+		} else {
+			// Quo truncates towards zero, as SDIV does.
+			f.push(toUnsigned(new(big.Int).Quo(x, y)))
+		}
+	case MOD:
+		x, y := f.pop(), f.pop()
+		if y.Sign() == 0 {
+			f.push(new(big.Int))
+		} else {
+			f.push(x.Mod(x, y))
+		}
+	case SMOD:
+		x, y := toSigned(f.pop()), toSigned(f.pop())
+		if y.Sign() == 0 {
+			f.push(new(big.Int))
+		} else {
+			// Rem takes the sign of x, as SMOD does.
+			f.push(toUnsigned(new(big.Int).Rem(x, y)))
+		}
+	case ADDMOD:
+		x, y, m := f.pop(), f.pop(), f.pop()
+		if m.Sign() == 0 {
+			f.push(new(big.Int))
+		} else {
+			x.Add(x, y)
+			f.push(x.Mod(x, m))
+		}
+	case MULMOD:
+		x, y, m := f.pop(), f.pop(), f.pop()
+		if m.Sign() == 0 {
+			f.push(new(big.Int))
+		} else {
+			x.Mul(x, y)
+			f.push(x.Mod(x, m))
+		}
+	case EXP:
+		base, exp := f.pop(), f.pop()
+		perByte := uint64(10)
+		if fork >= SpuriousDragon {
+			perByte = 50
+		}
+		if err := f.useGas(perByte * uint64((exp.BitLen()+7)/8)); err != nil {
+			return nil, false, err
+		}
+		f.push(base.Exp(base, exp, tt256))
+	case SIGNEXTEND:
+		b, x := f.pop(), f.pop()
+		if b.Cmp(big.NewInt(31)) < 0 {
+			bit := uint(b.Uint64()*8 + 7)
+			mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), bit), big.NewInt(1))
+			if x.Bit(int(bit)) == 1 {
+				x.Or(x, new(big.Int).Xor(tt256m1, mask))
+			} else {
+				x.And(x, mask)
+			}
+		}
+		f.push(x)
+
+	case LT:
+		x, y := f.pop(), f.pop()
+		f.push(boolToInt(x.Cmp(y) < 0))
+	case GT:
+		x, y := f.pop(), f.pop()
+		f.push(boolToInt(x.Cmp(y) > 0))
+	case SLT:
+		x, y := toSigned(f.pop()), toSigned(f.pop())
+		f.push(boolToInt(x.Cmp(y) < 0))
+	case SGT:
+		x, y := toSigned(f.pop()), toSigned(f.pop())
+		f.push(boolToInt(x.Cmp(y) > 0))
+	case EQ:
+		x, y := f.pop(), f.pop()
+		f.push(boolToInt(x.Cmp(y) == 0))
+	case ISZERO:
+		f.push(boolToInt(f.pop().Sign() == 0))
+	case AND:
+		x, y := f.pop(), f.pop()
+		f.push(x.And(x, y))
+	case OR:
+		x, y := f.pop(), f.pop()
+		f.push(x.Or(x, y))
+	case XOR:
+		x, y := f.pop(), f.pop()
+		f.push(x.Xor(x, y))
+	case NOT:
+		x := f.pop()
+		f.push(x.Xor(x, tt256m1))
+	case BYTE:
+		i, x := f.pop(), f.pop()
+		if i.Cmp(big.NewInt(32)) >= 0 {
+			f.push(new(big.Int))
+		} else {
+			f.push(big.NewInt(int64(x.FillBytes(make([]byte, 32))[i.Uint64()])))
+		}
+	case SHL:
+		shift, x := f.pop(), f.pop()
+		if shift.Cmp(big.NewInt(256)) >= 0 {
+			f.push(new(big.Int))
+		} else {
+			f.push(toUnsigned(x.Lsh(x, uint(shift.Uint64()))))
+		}
+	case SHR:
+		shift, x := f.pop(), f.pop()
+		if shift.Cmp(big.NewInt(256)) >= 0 {
+			f.push(new(big.Int))
+		} else {
+			f.push(x.Rsh(x, uint(shift.Uint64())))
+		}
+	case SAR:
+		shift, x := f.pop(), toSigned(f.pop())
+		if shift.Cmp(big.NewInt(256)) >= 0 {
+			shift = big.NewInt(256)
+		}
+		// Rsh of a negative number rounds towards negative infinity, as SAR
+		// does.
+		f.push(toUnsigned(new(big.Int).Rsh(x, uint(shift.Uint64()))))
+
+	case KECCAK256:
+		offset, size := f.pop(), f.pop()
+		if err := f.expand(offset, size); err != nil {
+			return nil, false, err
+		}
+		if err := f.useGas(toWords(size.Uint64()) * gasKeccakWord); err != nil {
+			return nil, false, err
+		}
+		f.push(new(big.Int).SetBytes(crypto.Keccak256(f.mem(offset, size))))
+
+	case ADDRESS:
+		f.push(addressToInt(f.address))
+	case BALANCE:
+		addr := toAddress(f.pop())
+		if err := f.accessAccount(addr); err != nil {
+			return nil, false, err
+		}
+		f.push(evm.State.GetBalance(addr))
+	case ORIGIN:
+		f.push(addressToInt(evm.Tx.Origin))
+	case CALLER:
+		f.push(addressToInt(f.caller))
+	case CALLVALUE:
+		f.push(new(big.Int).Set(f.value))
+	case CALLDATALOAD:
+		f.push(new(big.Int).SetBytes(slice(f.input, f.pop(), big.NewInt(32))))
+	case CALLDATASIZE:
+		f.push(big.NewInt(int64(len(f.input))))
+	case CALLDATACOPY, CODECOPY, RETURNDATACOPY:
+		memOffset, offset, size := f.pop(), f.pop(), f.pop()
+		if err := f.expand(memOffset, size); err != nil {
+			return nil, false, err
+		}
+		if err := f.copyGas(size); err != nil {
+			return nil, false, err
+		}
+
+		data := f.input
+		switch op {
+		case CODECOPY:
+			data = f.code
+		case RETURNDATACOPY:
+			end := new(big.Int).Add(offset, size)
+			if end.Cmp(big.NewInt(int64(len(f.returnData)))) > 0 {
+				return nil, false, ErrReturnDataOutOfBounds
+			}
+			data = f.returnData
+		}
+		if size.Sign() != 0 {
+			copy(f.mem(memOffset, size), slice(data, offset, size))
+		}
+	case CODESIZE:
+		f.push(big.NewInt(int64(len(f.code))))
+	case GASPRICE:
+		f.push(new(big.Int).Set(evm.Tx.GasPrice))
+	case EXTCODESIZE:
+		addr := toAddress(f.pop())
+		if err := f.accessAccount(addr); err != nil {
+			return nil, false, err
+		}
+		f.push(big.NewInt(int64(len(evm.State.GetCode(addr)))))
+	case EXTCODECOPY:
+		addr, memOffset, offset, size := toAddress(f.pop()), f.pop(), f.pop(), f.pop()
+		if err := f.accessAccount(addr); err != nil {
+			return nil, false, err
+		}
+		if err := f.expand(memOffset, size); err != nil {
+			return nil, false, err
+		}
+		if err := f.copyGas(size); err != nil {
+			return nil, false, err
+		}
+		if size.Sign() != 0 {
+			copy(f.mem(memOffset, size), slice(evm.State.GetCode(addr), offset, size))
+		}
+	case RETURNDATASIZE:
+		f.push(big.NewInt(int64(len(f.returnData))))
+	case EXTCODEHASH:
+		addr := toAddress(f.pop())
+		if err := f.accessAccount(addr); err != nil {
+			return nil, false, err
+		}
+		if !evm.State.Exists(addr) || evm.empty(addr) {
+			f.push(new(big.Int))
+		} else {
+			f.push(new(big.Int).SetBytes(crypto.Keccak256(evm.State.GetCode(addr))))
+		}
+
+	case BLOCKHASH:
+		n := f.pop()
+		current := evm.Block.Number
+		if n.IsUint64() && n.Uint64() < current && current-n.Uint64() <= 256 && evm.Block.GetHash != nil {
+			f.push(new(big.Int).SetBytes(evm.Block.GetHash(n.Uint64())))
+		} else {
+			f.push(new(big.Int))
+		}
+	case COINBASE:
+		f.push(addressToInt(evm.Block.Coinbase))
+	case TIMESTAMP:
+		f.push(new(big.Int).SetUint64(evm.Block.Time))
+	case NUMBER:
+		f.push(new(big.Int).SetUint64(evm.Block.Number))
+	case PREVRANDAO:
+		v := evm.Block.Difficulty
+		if fork >= Paris {
+			v = evm.Block.Random
+		}
+		f.push(orZero(v))
+	case GASLIMIT:
+		f.push(new(big.Int).SetUint64(evm.Block.GasLimit))
+	case CHAINID:
+		f.push(orZero(evm.Config.ChainID))
+	case SELFBALANCE:
+		f.push(evm.State.GetBalance(f.address))
+	case BASEFEE:
+		f.push(orZero(evm.Block.BaseFee))
+	case BLOBHASH:
+		i := f.pop()
+		if i.IsUint64() && i.Uint64() < uint64(len(evm.Tx.BlobHashes)) {
+			f.push(new(big.Int).SetBytes(evm.Tx.BlobHashes[i.Uint64()]))
+		} else {
+			f.push(new(big.Int))
+		}
+	case BLOBBASEFEE:
+		f.push(orZero(evm.Block.BlobBaseFee))
+
+	case POP:
+		f.pop()
+	case MLOAD:
+		offset := f.pop()
+		if err := f.expand(offset, big.NewInt(32)); err != nil {
+			return nil, false, err
+		}
+		f.push(new(big.Int).SetBytes(f.mem(offset, big.NewInt(32))))
+	case MSTORE:
+		offset, v := f.pop(), f.pop()
+		if err := f.expand(offset, big.NewInt(32)); err != nil {
+			return nil, false, err
+		}
+		v.FillBytes(f.mem(offset, big.NewInt(32)))
+	case MSTORE8:
+		offset, v := f.pop(), f.pop()
+		if err := f.expand(offset, big.NewInt(1)); err != nil {
+			return nil, false, err
+		}
+		f.memory[offset.Uint64()] = byte(v.Uint64() & 0xff)
+	case SLOAD:
+		key := f.pop()
+		if fork >= Berlin {
+			cost := uint64(gasWarmAccess)
+			if !evm.tx.addSlot(f.address, key) {
+				cost = gasColdSload
+			}
+			if err := f.useGas(cost); err != nil {
+				return nil, false, err
+			}
+		}
+		f.push(evm.State.GetStorage(f.address, key))
+	case SSTORE:
+		if err := f.sstore(); err != nil {
+			return nil, false, err
+		}
+	case JUMP:
+		dest := f.pop()
+		if !f.validJump(dest) {
+			return nil, false, ErrInvalidJump
+		}
+		f.pc = dest.Uint64()
+	case JUMPI:
+		dest, cond := f.pop(), f.pop()
+		if cond.Sign() != 0 {
+			if !f.validJump(dest) {
+				return nil, false, ErrInvalidJump
+			}
+			f.pc = dest.Uint64()
+		}
+	case PC:
+		f.push(new(big.Int).SetUint64(pc))
+	case MSIZE:
+		f.push(big.NewInt(int64(len(f.memory))))
+	case GAS:
+		f.push(new(big.Int).SetUint64(f.gas))
+	case JUMPDEST:
+	case TLOAD:
+		f.push(evm.tx.getTransient(f.address, f.pop()))
+	case TSTORE:
+		key, v := f.pop(), f.pop()
+		evm.tx.setTransient(f.address, key, v)
+	case MCOPY:
+		dst, src, size := f.pop(), f.pop(), f.pop()
+		if err := f.expand(dst, size, src, size); err != nil {
+			return nil, false, err
+		}
+		if err := f.copyGas(size); err != nil {
+			return nil, false, err
+		}
+		copy(f.mem(dst, size), f.mem(src, size))
+
+	case CREATE, CREATE2:
+		return nil, false, f.create(op)
+	case CALL, CALLCODE, DELEGATECALL, STATICCALL:
+		return nil, false, f.call(op)
+	case RETURN, REVERT:
+		offset, size := f.pop(), f.pop()
+		if err := f.expand(offset, size); err != nil {
+			return nil, false, err
+		}
+		ret := append([]byte{}, f.mem(offset, size)...)
+		if op == REVERT {
+			return ret, true, ErrExecutionReverted
+		}
+		return ret, true, nil
+	case SELFDESTRUCT:
+		return nil, true, f.selfDestruct()
+
+	default:
+		return nil, false, ErrInvalidOpCode
+	}
+	return nil, false, nil
+}
+
+func orZero(v *big.Int) *big.Int {
+	if v == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(v)
+}
+
+func (f *frame) sstore() error {
+	evm := f.evm
+	fork := evm.Config.Fork
+	key, value := f.pop(), f.pop()
+
+	// EIP-2200 keeps SSTORE from being called with only the stipend.
+	if fork >= Istanbul && f.gas <= gasSstoreSentry {
+		return ErrOutOfGas
+	}
+	if fork >= Berlin && !evm.tx.addSlot(f.address, key) {
+		if err := f.useGas(gasColdSload); err != nil {
+			return err
+		}
+	}
+
+	current := evm.State.GetStorage(f.address, key)
+	original := evm.tx.original(f.address, key, current)
+	gas, refund := sstoreGas(original, current, value, fork)
+	if err := f.useGas(gas); err != nil {
+		return err
+	}
+	if refund != 0 {
+		evm.tx.addRefund(refund)
+	}
+	evm.State.SetStorage(f.address, key, value)
+	return nil
+}
+
+func (f *frame) create(op OpCode) error {
+	evm := f.evm
+	value, offset, size := f.pop(), f.pop(), f.pop()
+	var salt *big.Int
+	if op == CREATE2 {
+		salt = f.pop()
+	}
+
+	if err := f.expand(offset, size); err != nil {
+		return err
+	}
+	if evm.Config.Fork >= Shanghai {
+		if size.Uint64() > maxInitCodeSize {
+			return ErrMaxInitCodeSizeExceeded
+		}
+		if err := f.useGas(gasInitCodeWord * toWords(size.Uint64())); err != nil {
+			return err
+		}
+	}
+	if op == CREATE2 {
+		if err := f.useGas(gasKeccakWord * toWords(size.Uint64())); err != nil {
+			return err
+		}
+	}
+	code := append([]byte{}, f.mem(offset, size)...)
+
+	gas := f.gas
+	if evm.Config.Fork >= TangerineWhistle {
+		gas -= gas / 64
+	}
+	f.gas -= gas
+
+	var ret []byte
+	var addr accnt.Address
+	var err error
+	if op == CREATE {
+		ret, addr, gas, err = evm.Create(f.address, code, gas, value)
+	} else {
+		ret, addr, gas, err = evm.Create2(f.address, code, gas, value, salt)
+	}
+	f.gas += gas
+
+	if err != nil {
+		f.push(new(big.Int))
+	} else {
+		f.push(addressToInt(addr))
+	}
+	f.returnData = nil
+	if err == ErrExecutionReverted {
+		f.returnData = ret
+	}
+	return nil
+}
+
+func (f *frame) call(op OpCode) error {
+	evm := f.evm
+	fork := evm.Config.Fork
+
+	requested, addr := f.pop(), toAddress(f.pop())
+	value := new(big.Int)
+	if op == CALL || op == CALLCODE {
+		value = f.pop()
+	}
+	inOffset, inSize, outOffset, outSize := f.pop(), f.pop(), f.pop(), f.pop()
+
+	if err := f.accessAccount(addr); err != nil {
+		return err
+	}
+	if err := f.expand(inOffset, inSize, outOffset, outSize); err != nil {
+		return err
+	}
+
+	var cost uint64
+	if value.Sign() != 0 {
+		cost += gasCallValue
+	}
+	if op == CALL {
+		if fork >= SpuriousDragon {
+			if value.Sign() != 0 && evm.empty(addr) {
+				cost += gasNewAccount
+			}
+		} else if !evm.State.Exists(addr) {
+			cost += gasNewAccount
+		}
+	}
+	if err := f.useGas(cost); err != nil {
+		return err
+	}
+
+	// EIP-150 gives calls at most all but a 64th of the gas left.
+	gas := f.gas
+	if fork >= TangerineWhistle {
+		gas -= gas / 64
+		if requested.IsUint64() && requested.Uint64() < gas {
+			gas = requested.Uint64()
+		}
+	} else {
+		if !requested.IsUint64() || requested.Uint64() > gas {
+			return ErrOutOfGas
+		}
+		gas = requested.Uint64()
+	}
+	f.gas -= gas
+	if value.Sign() != 0 {
+		gas += gasCallStipend
+	}
+
+	input := append([]byte{}, f.mem(inOffset, inSize)...)
+	var ret []byte
+	var err error
+	switch op {
+	case CALL:
+		ret, gas, err = evm.Call(f.address, addr, input, gas, value)
+	case CALLCODE:
+		ret, gas, err = evm.CallCode(f.address, addr, input, gas, value)
+	case DELEGATECALL:
+		ret, gas, err = evm.delegateCall(f, addr, input, gas)
+	case STATICCALL:
+		ret, gas, err = evm.StaticCall(f.address, addr, input, gas)
+	}
+	f.gas += gas
+
+	f.push(boolToInt(err == nil))
+	if err == nil || err == ErrExecutionReverted {
+		copy(f.mem(outOffset, outSize), ret)
+	}
+	f.returnData = ret
+	return nil
+}
+
+func (f *frame) selfDestruct() error {
+	evm := f.evm
+	fork := evm.Config.Fork
+	beneficiary := toAddress(f.pop())
+
+	if fork >= Berlin && !evm.tx.addAddress(beneficiary) {
+		if err := f.useGas(gasColdAccountAccess); err != nil {
+			return err
+		}
+	}
+	balance := evm.State.GetBalance(f.address)
+	if fork >= SpuriousDragon {
+		if balance.Sign() != 0 && evm.empty(beneficiary) {
+			if err := f.useGas(gasNewAccount); err != nil {
+				return err
+			}
+		}
+	} else if fork >= TangerineWhistle && !evm.State.Exists(beneficiary) {
+		if err := f.useGas(gasNewAccount); err != nil {
+			return err
+		}
+	}
+
+	// EIP-6780 only destroys contracts created in the same transaction;
+	// others only send their balance.
+	destroy := fork < Cancun || evm.tx.created[string(f.address)]
+	if !destroy && string(beneficiary) == string(f.address) {
+		return nil
+	}
+
+	evm.State.SetBalance(beneficiary, new(big.Int).Add(evm.State.GetBalance(beneficiary), balance))
+	evm.tx.touch(beneficiary)
+	evm.State.SetBalance(f.address, new(big.Int))
+	if destroy {
+		if !evm.tx.selfDestruct(f.address) && fork < London {
+			evm.tx.addRefund(gasSelfDestructRefund)
+		}
+	}
+	return nil
+}
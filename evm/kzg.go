@@ -0,0 +1,23 @@
+package evm
+
+import (
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+)
+
+// KZG4844 verifies point evaluation proofs with go-ethereum's kzg4844
+// package, against the trusted setup of the Ethereum KZG ceremony.
+type KZG4844 struct{}
+
+func (KZG4844) VerifyProof(commitment, z, y, proof []byte) error {
+	var (
+		c kzg4844.Commitment
+		p kzg4844.Proof
+		x kzg4844.Point
+		v kzg4844.Claim
+	)
+	copy(c[:], commitment)
+	copy(p[:], proof)
+	copy(x[:], z)
+	copy(v[:], y)
+	return kzg4844.VerifyProof(c, x, v, p)
+}
@@ -0,0 +1,370 @@
+package evm
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"ethereum/accnt"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/bn256"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// KZG verifies the proofs of the point evaluation precompile.  KZG4844 is
+// the implementation for mainnet.
+type KZG interface {
+	// VerifyProof verifies that the polynomial committed to by the 48 byte
+	// commitment evaluates to the 32 byte y at the 32 byte z.
+	VerifyProof(commitment, z, y, proof []byte) error
+}
+
+var (
+	errBadPairingInput   = errors.New("bad elliptic curve pairing input size")
+	errBlake2FInputSize  = errors.New("invalid input length")
+	errBlake2FFinalFlag  = errors.New("invalid final flag")
+	errPointEvalInput    = errors.New("invalid input length")
+	errVersionedHash     = errors.New("mismatched versioned hash")
+	errNoKZG             = errors.New("no KZG verifier")
+	blsModulus, _        = new(big.Int).SetString("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001", 16)
+	fieldElementsPerBlob = big.NewInt(4096)
+)
+
+// Precompiles returns the precompiled contracts of a fork, priced as at that
+// fork, to be used as Config.Precompiles.  The point evaluation precompile
+// of Cancun fails without kzg.
+func Precompiles(fork Fork, kzg KZG) map[string]Precompile {
+	p := []Precompile{ecrecover{}, sha256Hash{}, ripemd160Hash{}, dataCopy{}}
+	if fork >= Byzantium {
+		if fork >= Istanbul {
+			p = append(p, bigModExp{eip2565: fork >= Berlin}, bn256Add{gas: 150}, bn256ScalarMul{gas: 6000},
+				bn256Pairing{base: 45000, perPair: 34000})
+		} else {
+			p = append(p, bigModExp{}, bn256Add{gas: 500}, bn256ScalarMul{gas: 40000},
+				bn256Pairing{base: 100000, perPair: 80000})
+		}
+	}
+	if fork >= Istanbul {
+		p = append(p, blake2F{})
+	}
+	if fork >= Cancun {
+		p = append(p, pointEvaluation{kzg: kzg})
+	}
+
+	precompiles := make(map[string]Precompile, len(p))
+	for i, c := range p {
+		precompiles[fmt.Sprintf("0x%040x", i+1)] = c
+	}
+	return precompiles
+}
+
+// rightPad returns data padded with zeros to at least n bytes.
+func rightPad(data []byte, n int) []byte {
+	if len(data) >= n {
+		return data
+	}
+	return append(append([]byte{}, data...), make([]byte, n-len(data))...)
+}
+
+// wordGas returns base plus perWord for each 32 byte word of input.
+func wordGas(input []byte, base, perWord uint64) uint64 {
+	return base + toWords(uint64(len(input)))*perWord
+}
+
+// ecrecover returns the address which signed a hash, or nothing if the
+// signature is invalid.
+type ecrecover struct{}
+
+func (ecrecover) RequiredGas(input []byte) uint64 {
+	return 3000
+}
+
+func (ecrecover) Run(input []byte) ([]byte, error) {
+	input = rightPad(input, 128)
+	v := new(big.Int).SetBytes(input[32:64])
+	r := new(big.Int).SetBytes(input[64:96])
+	s := new(big.Int).SetBytes(input[96:128])
+
+	n := crypto.S256().Params().N
+	if !v.IsUint64() || (v.Uint64() != 27 && v.Uint64() != 28) ||
+		r.Sign() == 0 || r.Cmp(n) >= 0 || s.Sign() == 0 || s.Cmp(n) >= 0 {
+		return nil, nil
+	}
+
+	pub, err := accnt.Recover(input[:32], accnt.Signature{R: r, S: s, V: v.Uint64() == 28})
+	if err != nil {
+		return nil, nil
+	}
+	return append(make([]byte, 12), pub.Address()...), nil
+}
+
+type sha256Hash struct{}
+
+func (sha256Hash) RequiredGas(input []byte) uint64 {
+	return wordGas(input, 60, 12)
+}
+
+func (sha256Hash) Run(input []byte) ([]byte, error) {
+	h := sha256.Sum256(input)
+	return h[:], nil
+}
+
+type ripemd160Hash struct{}
+
+func (ripemd160Hash) RequiredGas(input []byte) uint64 {
+	return wordGas(input, 600, 120)
+}
+
+func (ripemd160Hash) Run(input []byte) ([]byte, error) {
+	h := ripemd160.New()
+	h.Write(input)
+	return append(make([]byte, 12), h.Sum(nil)...), nil
+}
+
+// dataCopy is the identity function.
+type dataCopy struct{}
+
+func (dataCopy) RequiredGas(input []byte) uint64 {
+	return wordGas(input, 15, 3)
+}
+
+func (dataCopy) Run(input []byte) ([]byte, error) {
+	return append([]byte{}, input...), nil
+}
+
+// bigModExp returns base**exp % mod, priced by EIP-198, or by EIP-2565 if
+// eip2565.
+type bigModExp struct {
+	eip2565 bool
+}
+
+// modExpLengths returns the lengths of the base, exponent and modulus.
+func modExpLengths(input []byte) (*big.Int, *big.Int, *big.Int) {
+	input = rightPad(input, 96)
+	return new(big.Int).SetBytes(input[:32]), new(big.Int).SetBytes(input[32:64]),
+		new(big.Int).SetBytes(input[64:96])
+}
+
+// slicePadded returns size bytes of data from offset, padded with zeros.
+func slicePadded(data []byte, offset, size uint64) []byte {
+	if offset > uint64(len(data)) {
+		offset = uint64(len(data))
+	}
+	end := offset + size
+	if end > uint64(len(data)) {
+		end = uint64(len(data))
+	}
+	return rightPad(data[offset:end], int(size))
+}
+
+func (c bigModExp) RequiredGas(input []byte) uint64 {
+	baseLen, expLen, modLen := modExpLengths(input)
+
+	// The bit length of the exponent's first 32 bytes, less one, plus 8
+	// bits for each byte after them.
+	head := new(big.Int)
+	// A base longer than the input leaves the exponent zero.
+	if baseLen.Cmp(big.NewInt(int64(len(input)))) < 0 {
+		headLen := uint64(32)
+		if expLen.Cmp(big.NewInt(32)) < 0 {
+			headLen = expLen.Uint64()
+		}
+		head.SetBytes(slicePadded(input, 96+baseLen.Uint64(), headLen))
+	}
+	adjExpLen := new(big.Int)
+	if expLen.Cmp(big.NewInt(32)) > 0 {
+		adjExpLen.Sub(expLen, big.NewInt(32))
+		adjExpLen.Mul(adjExpLen, big.NewInt(8))
+	}
+	if head.BitLen() > 0 {
+		adjExpLen.Add(adjExpLen, big.NewInt(int64(head.BitLen()-1)))
+	}
+	if adjExpLen.Sign() == 0 {
+		adjExpLen.SetInt64(1)
+	}
+
+	x := baseLen
+	if modLen.Cmp(x) > 0 {
+		x = modLen
+	}
+	var gas *big.Int
+	if c.eip2565 {
+		words := new(big.Int).Add(x, big.NewInt(7))
+		words.Rsh(words, 3)
+		gas = new(big.Int).Mul(words, words)
+		gas.Mul(gas, adjExpLen)
+		gas.Div(gas, big.NewInt(3))
+		if gas.Cmp(big.NewInt(200)) < 0 {
+			gas.SetInt64(200)
+		}
+	} else {
+		gas = multComplexity(x)
+		gas.Mul(gas, adjExpLen)
+		gas.Div(gas, big.NewInt(20))
+	}
+	if !gas.IsUint64() {
+		return math.MaxUint64
+	}
+	return gas.Uint64()
+}
+
+// multComplexity is the multiplication complexity of EIP-198.
+func multComplexity(x *big.Int) *big.Int {
+	sq := new(big.Int).Mul(x, x)
+	switch {
+	case x.Cmp(big.NewInt(64)) <= 0:
+		return sq
+	case x.Cmp(big.NewInt(1024)) <= 0:
+		sq.Div(sq, big.NewInt(4))
+		sq.Add(sq, new(big.Int).Mul(x, big.NewInt(96)))
+		return sq.Sub(sq, big.NewInt(3072))
+	}
+	sq.Div(sq, big.NewInt(16))
+	sq.Add(sq, new(big.Int).Mul(x, big.NewInt(480)))
+	return sq.Sub(sq, big.NewInt(199680))
+}
+
+func (bigModExp) Run(input []byte) ([]byte, error) {
+	// The lengths can be paid for, so fit in a uint64.
+	l1, l2, l3 := modExpLengths(input)
+	baseLen, expLen, modLen := l1.Uint64(), l2.Uint64(), l3.Uint64()
+	if baseLen == 0 && modLen == 0 {
+		return nil, nil
+	}
+	base := new(big.Int).SetBytes(slicePadded(input, 96, baseLen))
+	exp := new(big.Int).SetBytes(slicePadded(input, 96+baseLen, expLen))
+	mod := new(big.Int).SetBytes(slicePadded(input, 96+baseLen+expLen, modLen))
+
+	out := make([]byte, modLen)
+	if mod.Sign() == 0 {
+		return out, nil
+	}
+	return base.Exp(base, exp, mod).FillBytes(out), nil
+}
+
+func newG1(b []byte) (*bn256.G1, error) {
+	p := new(bn256.G1)
+	if _, err := p.Unmarshal(b); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func newG2(b []byte) (*bn256.G2, error) {
+	p := new(bn256.G2)
+	if _, err := p.Unmarshal(b); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// bn256Add adds points of G1, as EIP-196.
+type bn256Add struct {
+	gas uint64
+}
+
+func (c bn256Add) RequiredGas(input []byte) uint64 {
+	return c.gas
+}
+
+func (bn256Add) Run(input []byte) ([]byte, error) {
+	input = rightPad(input, 128)
+	x, err := newG1(input[:64])
+	if err != nil {
+		return nil, err
+	}
+	y, err := newG1(input[64:128])
+	if err != nil {
+		return nil, err
+	}
+	return new(bn256.G1).Add(x, y).Marshal(), nil
+}
+
+// bn256ScalarMul multiplies a point of G1 by a scalar, as EIP-196.
+type bn256ScalarMul struct {
+	gas uint64
+}
+
+func (c bn256ScalarMul) RequiredGas(input []byte) uint64 {
+	return c.gas
+}
+
+func (bn256ScalarMul) Run(input []byte) ([]byte, error) {
+	input = rightPad(input, 96)
+	p, err := newG1(input[:64])
+	if err != nil {
+		return nil, err
+	}
+	return new(bn256.G1).ScalarMult(p, new(big.Int).SetBytes(input[64:96])).Marshal(), nil
+}
+
+// bn256Pairing checks that the product of the pairings of pairs of points
+// of G1 and G2 is one, as EIP-197.
+type bn256Pairing struct {
+	base, perPair uint64
+}
+
+func (c bn256Pairing) RequiredGas(input []byte) uint64 {
+	return c.base + uint64(len(input)/192)*c.perPair
+}
+
+func (bn256Pairing) Run(input []byte) ([]byte, error) {
+	if len(input)%192 != 0 {
+		return nil, errBadPairingInput
+	}
+	var g1s []*bn256.G1
+	var g2s []*bn256.G2
+	for i := 0; i < len(input); i += 192 {
+		g1, err := newG1(input[i : i+64])
+		if err != nil {
+			return nil, err
+		}
+		g2, err := newG2(input[i+64 : i+192])
+		if err != nil {
+			return nil, err
+		}
+		g1s = append(g1s, g1)
+		g2s = append(g2s, g2)
+	}
+
+	out := make([]byte, 32)
+	if bn256.PairingCheck(g1s, g2s) {
+		out[31] = 1
+	}
+	return out, nil
+}
+
+// pointEvaluation verifies a KZG proof of the evaluation of a blob's
+// polynomial, as EIP-4844.
+type pointEvaluation struct {
+	kzg KZG
+}
+
+func (pointEvaluation) RequiredGas(input []byte) uint64 {
+	return 50000
+}
+
+func (c pointEvaluation) Run(input []byte) ([]byte, error) {
+	if len(input) != 192 {
+		return nil, errPointEvalInput
+	}
+	versionedHash, z, y := input[:32], input[32:64], input[64:96]
+	commitment, proof := input[96:144], input[144:192]
+
+	h := sha256.Sum256(commitment)
+	h[0] = 0x01
+	if !bytes.Equal(h[:], versionedHash) {
+		return nil, errVersionedHash
+	}
+	if c.kzg == nil {
+		return nil, errNoKZG
+	}
+	if err := c.kzg.VerifyProof(commitment, z, y, proof); err != nil {
+		return nil, err
+	}
+	return append(fieldElementsPerBlob.FillBytes(make([]byte, 32)), blsModulus.FillBytes(make([]byte, 32))...),
+		nil
+}
@@ -0,0 +1,187 @@
+package evm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const (
+	// The generators of G1 and G2 of bn256, and -G1.
+	g1    = "0000000000000000000000000000000000000000000000000000000000000001" + "0000000000000000000000000000000000000000000000000000000000000002"
+	negG1 = "0000000000000000000000000000000000000000000000000000000000000001" + "30644e72e131a029b85045b68181585d97816a916871ca8d3c208c16d87cfd45"
+	g2    = "198e9393920d483a7260bfb731fb5d25f1aa493335a9e71297e485b7aef312c2" + "1800deef121f1e76426a00665e5c4479674322d4f75edadd46debd5cd992f6ed" +
+		"090689d0585ff075ec9e99ad690c3395bc4b313370b38ef355acdadcd122975b" + "12c85ea5db8c6deb4aab71808dcb408fe3d1e7690c43d37b4ce6cc0166fa7daa"
+	twoG1 = "030644e72e131a029b85045b68181585d97816a916871ca8d3c208c16d87cfd3" + "15ed738c0e0a7c92e7845f96b2ae9c0a68a6a449e3538fc7ff3ebf7a5a18a2c4"
+)
+
+var (
+	// The blake2b state of EIP-152 hashing "abc", less the rounds and the
+	// final flag.
+	blake2State = "48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b" +
+		"6162630000000000000000000000000000000000000000000000000000000000" + strings.Repeat("00", 96) +
+		"0300000000000000" + "0000000000000000"
+)
+
+func TestPrecompiles(t *testing.T) {
+	var tests = []struct {
+		fork     Fork
+		address  int
+		input    string
+		expected string
+		gas      uint64
+		err      bool
+	}{
+		// ecrecover
+		{Cancun, 1, "38d18acb67d25c8bb9942764b62f18e17054f66a817bd4295423adf9ed98873e" + word("1b") +
+			"38d18acb67d25c8bb9942764b62f18e17054f66a817bd4295423adf9ed98873e" +
+			"789d1dd423d25f0772d2748d60f7e4b81bb14d086eba8e8e8efb6dcff8a4ae02",
+			word("ceaccac640adf55b2028469bd36ba501f28b699d"), 3000, false},
+		{Cancun, 1, "38d18acb67d25c8bb9942764b62f18e17054f66a817bd4295423adf9ed98873e" + word("1d") +
+			"38d18acb67d25c8bb9942764b62f18e17054f66a817bd4295423adf9ed98873e" +
+			"789d1dd423d25f0772d2748d60f7e4b81bb14d086eba8e8e8efb6dcff8a4ae02",
+			"", 3000, false},
+		// sha256, ripemd160, identity
+		{Cancun, 2, "", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", 60, false},
+		{Cancun, 2, "616263", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", 72, false},
+		{Cancun, 3, "", word("9c1185a5c5e9fc54612808977ee8f548b2258d31"), 600, false},
+		{Cancun, 3, "616263", word("8eb208f7e05d987a9b044a8e98c6b087f15a0bfc"), 720, false},
+		{Cancun, 4, "0102", "0102", 18, false},
+		// modexp, 3**(p-1) % p, from EIP-198, priced by EIP-198 and
+		// EIP-2565.
+		{Istanbul, 5, word("01") + word("20") + word("20") + "03" +
+			"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e" +
+			"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f",
+			word("01"), 13056, false},
+		{Berlin, 5, word("01") + word("20") + word("20") + "03" +
+			"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e" +
+			"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f",
+			word("01"), 1360, false},
+		{Berlin, 5, word("00") + word("20") + word("20") +
+			"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2e" +
+			"fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f",
+			word("00"), 1360, false},
+		{Berlin, 5, word("01") + word("01") + word("00") + "0203", "", 200, false},
+		// bn256
+		{Byzantium, 6, g1 + g1, twoG1, 500, false},
+		{Istanbul, 6, g1 + g1, twoG1, 150, false},
+		{Istanbul, 6, g1, g1, 150, false},
+		{Istanbul, 6, g1 + strings.Repeat("0", 62) + "0103", "", 150, true},
+		{Istanbul, 7, g1 + word("02"), twoG1, 6000, false},
+		{Byzantium, 7, g1 + word("02"), twoG1, 40000, false},
+		{Istanbul, 8, "", word("01"), 45000, false},
+		{Istanbul, 8, g1 + g2, word("00"), 79000, false},
+		{Istanbul, 8, g1 + g2 + negG1 + g2, word("01"), 113000, false},
+		{Byzantium, 8, g1 + g2 + negG1 + g2, word("01"), 260000, false},
+		{Istanbul, 8, g1 + g2 + "00", "", 79000, true},
+		// blake2f, from EIP-152
+		{Istanbul, 9, "0000000c" + blake2State + "01",
+			"ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d1" +
+				"7d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923", 12, false},
+		{Istanbul, 9, "00000000" + blake2State + "01",
+			"08c9bcf367e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5" +
+				"d282e6ad7f520e511f6c3e2b8c68059b9442be0454267ce079217e1319cde05b", 0, false},
+		{Istanbul, 9, "0000000c" + blake2State + "02", "", 12, true},
+		{Istanbul, 9, "0000000c" + blake2State, "", 0, true},
+	}
+
+	for _, test := range tests {
+		p := Precompiles(test.fork, nil)[hexAddress(test.address)]
+		if p == nil {
+			t.Fatalf("Expected precompile %d at %s", test.address, test.fork)
+		}
+		input := mustDecode(t, test.input)
+		if gas := p.RequiredGas(input); gas != test.gas {
+			t.Errorf("%d %s: Expected gas: %d, received: %d", test.address, test.input, test.gas, gas)
+		}
+		out, err := p.Run(input)
+		if (err != nil) != test.err {
+			t.Errorf("%d %s: Expected error: %v, received: %v", test.address, test.input, test.err, err)
+			continue
+		}
+		if hex.EncodeToString(out) != test.expected {
+			t.Errorf("%d %s: Expected: %s, received: %x", test.address, test.input, test.expected, out)
+		}
+	}
+}
+
+func hexAddress(i int) string {
+	return "0x" + word(hex.EncodeToString([]byte{byte(i)}))[24:]
+}
+
+func TestPrecompilesFork(t *testing.T) {
+	var tests = []struct {
+		fork     Fork
+		expected int
+	}{
+		{Frontier, 4},
+		{Byzantium, 8},
+		{Istanbul, 9},
+		{Cancun, 10},
+	}
+
+	for _, test := range tests {
+		if n := len(Precompiles(test.fork, nil)); n != test.expected {
+			t.Errorf("%s: Expected: %d, received: %d", test.fork, test.expected, n)
+		}
+	}
+}
+
+type testKZG struct {
+	err error
+}
+
+func (k testKZG) VerifyProof(commitment, z, y, proof []byte) error {
+	return k.err
+}
+
+func TestPointEvaluation(t *testing.T) {
+	commitment := mustDecode(t, "c0"+strings.Repeat("00", 47))
+	h := sha256.Sum256(commitment)
+	h[0] = 0x01
+	input := append(append(h[:], make([]byte, 64)...), commitment...)
+	input = append(input, mustDecode(t, "c0"+strings.Repeat("00", 47))...)
+
+	// The vector of go-ethereum's core/vm/testdata/precompiles/pointEvaluation.json.
+	vector := mustDecode(t, "01e798154708fe7789429634053cbf9f99b619f9f084048927333fce637f549b564c0a11a0f704f4fc3e8"+
+		"acfe0f8245f0ad1347b378fbf96e206da11a5d3630624d25032e67a7e6a4910df5834b8fe70e6bcfeeac0352434196bdf4b2485d5a1"+
+		"8f59a8d2a1a625a17f3fea0fe5eb8c896db3764f3185481bc22f91b4aaffcca25f26936857bc3a7c2539ea8ec3a952b7873033e0383"+
+		"26e87ed3e1276fd140253fa08e9fc25fb2d9a98527fc22a2c9612fbeafdad446cbc7bcdbdcd780af2c16a")
+
+	errProof := errors.New("invalid proof")
+	var tests = []struct {
+		kzg      KZG
+		input    []byte
+		expected string
+		err      error
+	}{
+		{testKZG{}, input, word("1000") + "73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001", nil},
+		{KZG4844{}, vector, word("1000") + "73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001", nil},
+		{testKZG{errProof}, input, "", errProof},
+		{nil, input, "", errNoKZG},
+		{testKZG{}, input[:191], "", errPointEvalInput},
+		{testKZG{}, append(make([]byte, 32), input[32:]...), "", errVersionedHash},
+	}
+
+	for _, test := range tests {
+		p := Precompiles(Cancun, test.kzg)[hexAddress(10)]
+		if gas := p.RequiredGas(test.input); gas != 50000 {
+			t.Errorf("Expected gas: 50000, received: %d", gas)
+		}
+		out, err := p.Run(test.input)
+		if err != test.err {
+			t.Errorf("Expected: %v, received: %v", test.err, err)
+		}
+		if hex.EncodeToString(out) != test.expected {
+			t.Errorf("Expected: %s, received: %x", test.expected, out)
+		}
+	}
+
+	// The vector with a bit of the proof flipped.
+	vector[191] ^= 1
+	if _, err := Precompiles(Cancun, KZG4844{})[hexAddress(10)].Run(vector); err == nil {
+		t.Error("Expected an invalid proof error")
+	}
+}
@@ -0,0 +1,247 @@
+package evm
+
+import (
+	"bytes"
+	"ethereum/accnt"
+	"ethereum/trie"
+	"ethereum/util"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// StateDB is the world state the EVM executes against.  Changes made since
+// a snapshot are undone by reverting to it.  Missing accounts have zero
+// nonce and balance, and no code or storage.
+type StateDB interface {
+	Exists(addr accnt.Address) bool
+	// CreateAccount creates an account, or resets the nonce, code and
+	// storage of an existing one, keeping its balance.
+	CreateAccount(addr accnt.Address)
+	DeleteAccount(addr accnt.Address)
+
+	GetBalance(addr accnt.Address) *big.Int
+	SetBalance(addr accnt.Address, balance *big.Int)
+	GetNonce(addr accnt.Address) uint64
+	SetNonce(addr accnt.Address, nonce uint64)
+	GetCode(addr accnt.Address) []byte
+	SetCode(addr accnt.Address, code []byte)
+
+	GetStorage(addr accnt.Address, key *big.Int) *big.Int
+	SetStorage(addr accnt.Address, key, value *big.Int)
+
+	Snapshot() int
+	RevertToSnapshot(id int)
+}
+
+// Account is an account of a MemoryState.
+type Account struct {
+	Nonce   uint64
+	Balance *big.Int
+	Code    []byte
+	Storage map[string]*big.Int // keyed by the 32 byte storage key
+}
+
+// MemoryState is an in-memory StateDB.  The zero value is not usable; use
+// NewMemoryState.
+type MemoryState struct {
+	accounts map[string]*Account
+	journal  []func()
+}
+
+// NewMemoryState returns an empty state.
+func NewMemoryState() *MemoryState {
+	return &MemoryState{accounts: make(map[string]*Account)}
+}
+
+// Copy returns a copy of the state, without its snapshots.
+func (s *MemoryState) Copy() *MemoryState {
+	c := NewMemoryState()
+	for addr, a := range s.accounts {
+		ac := &Account{
+			Nonce:   a.Nonce,
+			Balance: new(big.Int).Set(a.Balance),
+			Code:    a.Code,
+			Storage: make(map[string]*big.Int, len(a.Storage)),
+		}
+		for k, v := range a.Storage {
+			ac.Storage[k] = v
+		}
+		c.accounts[addr] = ac
+	}
+	return c
+}
+
+// Addresses returns the addresses of the accounts in the state, in order.
+func (s *MemoryState) Addresses() []accnt.Address {
+	addrs := make([]accnt.Address, 0, len(s.accounts))
+	for a := range s.accounts {
+		addrs = append(addrs, accnt.Address(a))
+	}
+	sort.Slice(addrs, func(i, j int) bool { return bytes.Compare(addrs[i], addrs[j]) < 0 })
+	return addrs
+}
+
+// Account returns the account at addr, or nil if there is none.  It must not
+// be modified.
+func (s *MemoryState) Account(addr accnt.Address) *Account {
+	return s.accounts[string(addr)]
+}
+
+func (s *MemoryState) Exists(addr accnt.Address) bool {
+	return s.accounts[string(addr)] != nil
+}
+
+func (s *MemoryState) CreateAccount(addr accnt.Address) {
+	balance := new(big.Int)
+	if a := s.accounts[string(addr)]; a != nil {
+		balance = a.Balance
+	}
+	s.setAccount(addr, &Account{Balance: balance, Storage: make(map[string]*big.Int)})
+}
+
+func (s *MemoryState) DeleteAccount(addr accnt.Address) {
+	s.setAccount(addr, nil)
+}
+
+func (s *MemoryState) setAccount(addr accnt.Address, a *Account) {
+	prev := s.accounts[string(addr)]
+	s.journal = append(s.journal, func() {
+		if prev == nil {
+			delete(s.accounts, string(addr))
+		} else {
+			s.accounts[string(addr)] = prev
+		}
+	})
+	if a == nil {
+		delete(s.accounts, string(addr))
+	} else {
+		s.accounts[string(addr)] = a
+	}
+}
+
+// account returns the account at addr, creating it if missing.
+func (s *MemoryState) account(addr accnt.Address) *Account {
+	a := s.accounts[string(addr)]
+	if a == nil {
+		a = &Account{Balance: new(big.Int), Storage: make(map[string]*big.Int)}
+		s.setAccount(addr, a)
+	}
+	return a
+}
+
+func (s *MemoryState) GetBalance(addr accnt.Address) *big.Int {
+	if a := s.accounts[string(addr)]; a != nil {
+		return new(big.Int).Set(a.Balance)
+	}
+	return new(big.Int)
+}
+
+func (s *MemoryState) SetBalance(addr accnt.Address, balance *big.Int) {
+	a := s.account(addr)
+	prev := a.Balance
+	s.journal = append(s.journal, func() { a.Balance = prev })
+	a.Balance = new(big.Int).Set(balance)
+}
+
+func (s *MemoryState) GetNonce(addr accnt.Address) uint64 {
+	if a := s.accounts[string(addr)]; a != nil {
+		return a.Nonce
+	}
+	return 0
+}
+
+func (s *MemoryState) SetNonce(addr accnt.Address, nonce uint64) {
+	a := s.account(addr)
+	prev := a.Nonce
+	s.journal = append(s.journal, func() { a.Nonce = prev })
+	a.Nonce = nonce
+}
+
+func (s *MemoryState) GetCode(addr accnt.Address) []byte {
+	if a := s.accounts[string(addr)]; a != nil {
+		return a.Code
+	}
+	return nil
+}
+
+func (s *MemoryState) SetCode(addr accnt.Address, code []byte) {
+	a := s.account(addr)
+	prev := a.Code
+	s.journal = append(s.journal, func() { a.Code = prev })
+	a.Code = code
+}
+
+func (s *MemoryState) GetStorage(addr accnt.Address, key *big.Int) *big.Int {
+	if a := s.accounts[string(addr)]; a != nil {
+		if v := a.Storage[storageKey(key)]; v != nil {
+			return new(big.Int).Set(v)
+		}
+	}
+	return new(big.Int)
+}
+
+// SetStorage sets a storage slot.  Slots set to zero are deleted.
+func (s *MemoryState) SetStorage(addr accnt.Address, key, value *big.Int) {
+	a := s.account(addr)
+	k := storageKey(key)
+	prev := a.Storage[k]
+	s.journal = append(s.journal, func() {
+		if prev == nil {
+			delete(a.Storage, k)
+		} else {
+			a.Storage[k] = prev
+		}
+	})
+	if value.Sign() == 0 {
+		delete(a.Storage, k)
+	} else {
+		a.Storage[k] = new(big.Int).Set(value)
+	}
+}
+
+func storageKey(key *big.Int) string {
+	return string(key.FillBytes(make([]byte, 32)))
+}
+
+func (s *MemoryState) Snapshot() int {
+	return len(s.journal)
+}
+
+func (s *MemoryState) RevertToSnapshot(id int) {
+	for i := len(s.journal) - 1; i >= id; i-- {
+		s.journal[i]()
+	}
+	s.journal = s.journal[:id]
+}
+
+// Commit discards the snapshots of the state, so that its changes can no
+// longer be reverted.
+func (s *MemoryState) Commit() {
+	s.journal = nil
+}
+
+// Root returns the root of the state trie: the trie of the RLP encoded
+// accounts, keyed by the hash of their address, whose storage roots are
+// those of the tries of their RLP encoded slots, keyed by the hash of the
+// slot.
+func (s *MemoryState) Root() []byte {
+	t := trie.New()
+	for _, addr := range s.Addresses() {
+		a := s.Account(addr)
+
+		storage := trie.New()
+		for k, v := range a.Storage {
+			storage.Put(crypto.Keccak256([]byte(k)), util.EncodeRLPValue(v.Bytes()))
+		}
+
+		t.Put(crypto.Keccak256(addr), util.EncodeRLPValue([]interface{}{
+			util.IntToArr(a.Nonce),
+			a.Balance.Bytes(),
+			storage.Hash(),
+			crypto.Keccak256(a.Code),
+		}))
+	}
+	return t.Hash()
+}
@@ -0,0 +1,333 @@
+package evm
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"ethereum/accnt"
+	"ethereum/txn"
+	"ethereum/util"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// The state tests in test_data are in the filler format of
+// github.com/ethereum/tests, with code as hex rather than LLL or Yul, and
+// expectations of the accounts' storage, balances, nonces and code.  Like the
+// filled GeneralStateTests, they also have a post section of the state root
+// and the hash of the logs after each transaction, for each fork the
+// expectations cover.  These were filled by the state test runner of
+// go-ethereum v1.14.11 (tests.StateTest.RunNoVerify), with Constantinople
+// filled as Petersburg.  naivefuzz.json is go-ethereum's
+// cmd/evm/testdata/statetest.json, a filled test with no expectations, whose
+// state root is that of cmd/evm/testdata/evmrun/6.out.1.txt.
+
+type stateTest struct {
+	Env         stateEnv                `json:"env"`
+	Pre         map[string]stateAccount `json:"pre"`
+	Transaction stateTransaction        `json:"transaction"`
+	Expect      []stateExpect           `json:"expect"`
+	Post        map[string][]statePost  `json:"post"`
+}
+
+type stateEnv struct {
+	Coinbase   string `json:"currentCoinbase"`
+	Difficulty string `json:"currentDifficulty"`
+	Random     string `json:"currentRandom"`
+	GasLimit   string `json:"currentGasLimit"`
+	Number     string `json:"currentNumber"`
+	Timestamp  string `json:"currentTimestamp"`
+	BaseFee    string `json:"currentBaseFee"`
+}
+
+type stateAccount struct {
+	Balance        string            `json:"balance"`
+	Code           string            `json:"code"`
+	Nonce          string            `json:"nonce"`
+	Storage        map[string]string `json:"storage"`
+	ShouldNotExist string            `json:"shouldnotexist"`
+}
+
+type stateTransaction struct {
+	Data      []string `json:"data"`
+	GasLimit  []string `json:"gasLimit"`
+	GasPrice  string   `json:"gasPrice"`
+	Nonce     string   `json:"nonce"`
+	SecretKey string   `json:"secretKey"`
+	To        string   `json:"to"`
+	Value     []string `json:"value"`
+}
+
+type stateIndexes struct {
+	Data  int `json:"data"`
+	Gas   int `json:"gas"`
+	Value int `json:"value"`
+}
+
+type stateExpect struct {
+	Indexes stateIndexes            `json:"indexes"`
+	Network []string                `json:"network"`
+	Result  map[string]stateAccount `json:"result"`
+}
+
+type statePost struct {
+	Indexes stateIndexes `json:"indexes"`
+	Hash    string       `json:"hash"`
+	Logs    string       `json:"logs"`
+}
+
+// parseNumber parses a hex number with a 0x prefix, or a decimal one.
+func parseNumber(s string) *big.Int {
+	if s == "" {
+		return new(big.Int)
+	}
+	if strings.HasPrefix(s, "0x") {
+		return new(big.Int).SetBytes(decodeHex(s))
+	}
+	v, _ := new(big.Int).SetString(s, 10)
+	return v
+}
+
+func decodeHex(s string) []byte {
+	s = strings.TrimPrefix(s, "0x")
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	b, _ := hex.DecodeString(s)
+	return b
+}
+
+// networks returns the forks of a list such as ["Berlin", ">=London<Cancun"].
+func networks(list []string) ([]Fork, error) {
+	var forks []Fork
+	for _, n := range list {
+		from, to := Frontier, Latest
+		if !strings.HasPrefix(n, ">=") && !strings.HasPrefix(n, "<") {
+			f, err := ParseFork(n)
+			if err != nil {
+				return nil, err
+			}
+			from, to = f, f
+		}
+		if strings.HasPrefix(n, ">=") {
+			n = n[2:]
+			end := strings.Index(n, "<")
+			if end < 0 {
+				end = len(n)
+			}
+			f, err := ParseFork(n[:end])
+			if err != nil {
+				return nil, err
+			}
+			from, n = f, n[end:]
+		}
+		if strings.HasPrefix(n, "<") {
+			f, err := ParseFork(n[1:])
+			if err != nil {
+				return nil, err
+			}
+			to = f - 1
+		}
+		for f := from; f <= to; f++ {
+			forks = append(forks, f)
+		}
+	}
+	return forks, nil
+}
+
+func TestState(t *testing.T) {
+	files, err := filepath.Glob("test_data/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("No state tests")
+	}
+
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tests map[string]stateTest
+		if err := json.Unmarshal(b, &tests); err != nil {
+			t.Fatalf("%s: %s", file, err)
+		}
+
+		for name, test := range tests {
+			for _, expect := range test.Expect {
+				forks, err := networks(expect.Network)
+				if err != nil {
+					t.Fatalf("%s: %s", name, err)
+				}
+				for _, fork := range forks {
+					for d := range test.Transaction.Data {
+						for g := range test.Transaction.GasLimit {
+							for v := range test.Transaction.Value {
+								if !matches(expect.Indexes.Data, d) || !matches(expect.Indexes.Gas, g) ||
+									!matches(expect.Indexes.Value, v) {
+									continue
+								}
+								label := fmt.Sprintf("%s/%s/d%dg%dv%d", name, fork, d, g, v)
+								if err := runStateTest(test, fork, d, g, v, expect.Result); err != nil {
+									t.Errorf("%s: %s", label, err)
+								}
+							}
+						}
+					}
+				}
+			}
+
+			if len(test.Post) == 0 {
+				t.Errorf("%s: No post state", name)
+			}
+			for network, posts := range test.Post {
+				fork, err := ParseFork(network)
+				if err != nil {
+					t.Fatalf("%s: %s", name, err)
+				}
+				for _, post := range posts {
+					label := fmt.Sprintf("%s/%s/d%dg%dv%d", name, fork, post.Indexes.Data, post.Indexes.Gas,
+						post.Indexes.Value)
+					if err := runPostTest(test, fork, post); err != nil {
+						t.Errorf("%s: %s", label, err)
+					}
+				}
+			}
+		}
+	}
+}
+
+func matches(index, i int) bool {
+	return index == -1 || index == i
+}
+
+// logsHash returns the hash of the RLP encoded logs, as in the post section
+// of the state tests.
+func logsHash(logs []txn.Log) string {
+	list := make([]interface{}, len(logs))
+	for i, l := range logs {
+		topics := make([]interface{}, len(l.Topics))
+		for j, t := range l.Topics {
+			topics[j] = decodeHex(t)
+		}
+		list[i] = []interface{}{decodeHex(l.Address), topics, l.Data}
+	}
+	return "0x" + hex.EncodeToString(crypto.Keccak256(util.EncodeRLPValue(list)))
+}
+
+func runPostTest(test stateTest, fork Fork, post statePost) error {
+	state, res, err := applyStateTest(test, fork, post.Indexes.Data, post.Indexes.Gas, post.Indexes.Value)
+	if err != nil {
+		return err
+	}
+	if root := "0x" + hex.EncodeToString(state.Root()); root != post.Hash {
+		return fmt.Errorf("Expected state root: %s, received: %s", post.Hash, root)
+	}
+	if hash := logsHash(res.Logs); hash != post.Logs {
+		return fmt.Errorf("Expected logs hash: %s, received: %s", post.Logs, hash)
+	}
+	return nil
+}
+
+// applyStateTest applies the transaction of the test with the given data, gas
+// and value indexes to its pre state.
+func applyStateTest(test stateTest, fork Fork, d, g, v int) (*MemoryState, *Result, error) {
+	state := NewMemoryState()
+	for a, acc := range test.Pre {
+		addr := accnt.Address(decodeHex(a))
+		state.CreateAccount(addr)
+		state.SetBalance(addr, parseNumber(acc.Balance))
+		state.SetNonce(addr, parseNumber(acc.Nonce).Uint64())
+		state.SetCode(addr, decodeHex(acc.Code))
+		for k, v := range acc.Storage {
+			state.SetStorage(addr, parseNumber(k), parseNumber(v))
+		}
+	}
+	state.Commit()
+
+	env := test.Env
+	block := BlockContext{
+		Coinbase:   accnt.Address(decodeHex(env.Coinbase)),
+		Number:     parseNumber(env.Number).Uint64(),
+		Time:       parseNumber(env.Timestamp).Uint64(),
+		GasLimit:   parseNumber(env.GasLimit).Uint64(),
+		Difficulty: parseNumber(env.Difficulty),
+		Random:     parseNumber(env.Random),
+	}
+	if fork >= London {
+		block.BaseFee = parseNumber(env.BaseFee)
+	}
+
+	key, err := accnt.NewAccount(strings.TrimPrefix(test.Transaction.SecretKey, "0x"))
+	if err != nil {
+		return nil, nil, err
+	}
+	tx := test.Transaction
+	msg := Message{
+		From:     key.Address(),
+		Nonce:    parseNumber(tx.Nonce).Uint64(),
+		Value:    parseNumber(tx.Value[v]),
+		Data:     decodeHex(tx.Data[d]),
+		GasLimit: parseNumber(tx.GasLimit[g]).Uint64(),
+		GasPrice: parseNumber(tx.GasPrice),
+	}
+	if tx.To != "" {
+		msg.To = accnt.Address(decodeHex(tx.To))
+	}
+
+	evm := New(block, state, Config{Fork: fork, ChainID: big.NewInt(1), Precompiles: Precompiles(fork, KZG4844{})})
+	res, err := ApplyMessage(evm, msg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return state, res, nil
+}
+
+func runStateTest(test stateTest, fork Fork, d, g, v int, result map[string]stateAccount) error {
+	state, _, err := applyStateTest(test, fork, d, g, v)
+	if err != nil {
+		return err
+	}
+
+	for a, expected := range result {
+		addr := accnt.Address(decodeHex(a))
+		if expected.ShouldNotExist != "" {
+			if state.Exists(addr) {
+				return fmt.Errorf("%s: Expected no account", addr)
+			}
+			continue
+		}
+		if !state.Exists(addr) {
+			return fmt.Errorf("%s: Expected an account", addr)
+		}
+		if expected.Balance != "" && state.GetBalance(addr).Cmp(parseNumber(expected.Balance)) != 0 {
+			return fmt.Errorf("%s: Expected balance: %s, received: %s", addr, parseNumber(expected.Balance),
+				state.GetBalance(addr))
+		}
+		if expected.Nonce != "" && state.GetNonce(addr) != parseNumber(expected.Nonce).Uint64() {
+			return fmt.Errorf("%s: Expected nonce: %s, received: %d", addr, expected.Nonce, state.GetNonce(addr))
+		}
+		if expected.Code != "" && hex.EncodeToString(state.GetCode(addr)) != hex.EncodeToString(decodeHex(expected.Code)) {
+			return fmt.Errorf("%s: Expected code: %s, received: %x", addr, expected.Code, state.GetCode(addr))
+		}
+		if expected.Storage != nil {
+			storage := state.Account(addr).Storage
+			if len(storage) != len(expected.Storage) {
+				return fmt.Errorf("%s: Expected %d storage slots, received: %d", addr, len(expected.Storage),
+					len(storage))
+			}
+			for k, v := range expected.Storage {
+				if s := state.GetStorage(addr, parseNumber(k)); s.Cmp(parseNumber(v)) != 0 {
+					return fmt.Errorf("%s: Expected storage %s: %s, received: %#x", addr, k, v, s)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package evm
+
+import (
+	"errors"
+	"ethereum/accnt"
+	"ethereum/txn"
+	"math/big"
+)
+
+// Errors of transactions which can't be included in a block.
+var (
+	ErrNonceTooLow       = errors.New("nonce too low")
+	ErrNonceTooHigh      = errors.New("nonce too high")
+	ErrSenderNoEOA       = errors.New("sender not an eoa")
+	ErrFeeCapTooLow      = errors.New("max fee per gas less than block base fee")
+	ErrTipAboveFeeCap    = errors.New("max priority fee per gas higher than max fee per gas")
+	ErrInsufficientFunds = errors.New("insufficient funds for gas * price + value")
+	ErrIntrinsicGas      = errors.New("intrinsic gas too low")
+)
+
+// Message is a transaction to execute, without a signature.
+type Message struct {
+	From  accnt.Address
+	To    accnt.Address // nil for contract creation
+	Nonce uint64
+	Value *big.Int
+	Data  []byte
+
+	GasLimit uint64
+	// GasPrice is the price of legacy and access list transactions.
+	// Dynamic fee transactions have GasFeeCap and GasTipCap instead.
+	GasPrice  *big.Int
+	GasFeeCap *big.Int
+	GasTipCap *big.Int
+
+	AccessList []txn.AccessTuple
+	BlobHashes [][]byte
+
+	// SkipNonceCheck executes the message whatever the sender's nonce, as
+	// eth_call does.
+	SkipNonceCheck bool
+}
+
+// Result is the result of executing a Message.
+type Result struct {
+	UsedGas    uint64
+	ReturnData []byte // the revert data if Err is ErrExecutionReverted
+	// Err is the error the execution failed with, if any.  The transaction
+	// is still valid, and pays for its gas.
+	Err             error
+	Logs            []txn.Log
+	ContractAddress accnt.Address // of a contract creation
+}
+
+// Failed reports whether the execution failed.
+func (r *Result) Failed() bool {
+	return r.Err != nil
+}
+
+// ApplyMessage executes msg as a transaction in evm's block, buying its gas
+// from the sender and paying the fee to the coinbase.  An error is returned
+// if msg is an invalid transaction, in which case the state is unchanged.
+// Blob gas isn't charged.
+func ApplyMessage(evm *EVM, msg Message) (*Result, error) {
+	state := evm.State
+	fork := evm.Config.Fork
+	value := msg.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+
+	if !msg.SkipNonceCheck {
+		nonce := state.GetNonce(msg.From)
+		switch {
+		case msg.Nonce < nonce:
+			return nil, ErrNonceTooLow
+		case msg.Nonce > nonce:
+			return nil, ErrNonceTooHigh
+		case nonce+1 < nonce:
+			return nil, ErrNonceMax
+		}
+		// EIP-3607
+		if len(state.GetCode(msg.From)) != 0 {
+			return nil, ErrSenderNoEOA
+		}
+	}
+
+	feeCap, tipCap := msg.GasFeeCap, msg.GasTipCap
+	if feeCap == nil {
+		feeCap, tipCap = orZero(msg.GasPrice), orZero(msg.GasPrice)
+	}
+	if tipCap == nil {
+		tipCap = feeCap
+	}
+	if tipCap.Cmp(feeCap) > 0 {
+		return nil, ErrTipAboveFeeCap
+	}
+	gasPrice := feeCap
+	if fork >= London && evm.Block.BaseFee != nil {
+		if feeCap.Cmp(evm.Block.BaseFee) < 0 {
+			return nil, ErrFeeCapTooLow
+		}
+		gasPrice = new(big.Int).Add(evm.Block.BaseFee, tipCap)
+		if gasPrice.Cmp(feeCap) > 0 {
+			gasPrice = feeCap
+		}
+	}
+
+	gasLimit := new(big.Int).SetUint64(msg.GasLimit)
+	balance := state.GetBalance(msg.From)
+	if new(big.Int).Add(new(big.Int).Mul(gasLimit, feeCap), value).Cmp(balance) > 0 {
+		return nil, ErrInsufficientFunds
+	}
+
+	create := msg.To == nil
+	keys := 0
+	for _, t := range msg.AccessList {
+		keys += len(t.StorageKeys)
+	}
+	intrinsic := IntrinsicGas(msg.Data, create, len(msg.AccessList), keys, fork)
+	if intrinsic > msg.GasLimit {
+		return nil, ErrIntrinsicGas
+	}
+	if create && fork >= Shanghai && len(msg.Data) > maxInitCodeSize {
+		return nil, ErrMaxInitCodeSizeExceeded
+	}
+	accessList, err := parseAccessList(msg.AccessList)
+	if err != nil {
+		return nil, err
+	}
+
+	state.SetBalance(msg.From, balance.Sub(balance, new(big.Int).Mul(gasLimit, gasPrice)))
+	evm.Tx = TxContext{Origin: msg.From, GasPrice: gasPrice, BlobHashes: msg.BlobHashes}
+	evm.tx = newTxState()
+	evm.depth = 0
+
+	if fork >= Berlin {
+		evm.tx.addAddress(msg.From)
+		if !create {
+			evm.tx.addAddress(msg.To)
+		}
+		for _, p := range precompileAddresses(evm.Config.Precompiles) {
+			evm.tx.addAddress(p)
+		}
+		for _, t := range accessList {
+			evm.tx.addAddress(t.address)
+			for _, k := range t.keys {
+				evm.tx.addSlot(t.address, k)
+			}
+		}
+		// EIP-3651
+		if fork >= Shanghai {
+			evm.tx.addAddress(evm.Block.Coinbase)
+		}
+	}
+
+	res := &Result{}
+	gas := msg.GasLimit - intrinsic
+	if create {
+		res.ReturnData, res.ContractAddress, gas, res.Err = evm.Create(msg.From, msg.Data, gas, value)
+	} else {
+		state.SetNonce(msg.From, state.GetNonce(msg.From)+1)
+		res.ReturnData, gas, res.Err = evm.Call(msg.From, msg.To, msg.Data, gas, value)
+	}
+
+	// EIP-3529 lowered the maximum refund from half to a fifth of the gas
+	// used.
+	quotient := uint64(2)
+	if fork >= London {
+		quotient = 5
+	}
+	refund := evm.tx.refund
+	if max := (msg.GasLimit - gas) / quotient; refund > max {
+		refund = max
+	}
+	gas += refund
+	res.UsedGas = msg.GasLimit - gas
+
+	state.SetBalance(msg.From, new(big.Int).Add(state.GetBalance(msg.From),
+		new(big.Int).Mul(new(big.Int).SetUint64(gas), gasPrice)))
+
+	tip := gasPrice
+	if fork >= London && evm.Block.BaseFee != nil {
+		tip = new(big.Int).Sub(gasPrice, evm.Block.BaseFee)
+	}
+	coinbase := evm.Block.Coinbase
+	state.SetBalance(coinbase, new(big.Int).Add(state.GetBalance(coinbase),
+		new(big.Int).Mul(new(big.Int).SetUint64(res.UsedGas), tip)))
+	evm.tx.touch(coinbase)
+
+	for addr := range evm.tx.destructs {
+		state.DeleteAccount(accnt.Address(addr))
+	}
+	// EIP-161
+	if fork >= SpuriousDragon {
+		for addr := range evm.tx.touched {
+			if state.Exists(accnt.Address(addr)) && evm.empty(accnt.Address(addr)) {
+				state.DeleteAccount(accnt.Address(addr))
+			}
+		}
+	}
+
+	res.Logs = evm.tx.logs
+	return res, nil
+}
+
+type accessTuple struct {
+	address accnt.Address
+	keys    []*big.Int
+}
+
+func parseAccessList(list []txn.AccessTuple) ([]accessTuple, error) {
+	var tuples []accessTuple
+	for _, t := range list {
+		addr, err := accnt.NewAddress(t.Address)
+		if err != nil {
+			return nil, err
+		}
+		tuple := accessTuple{address: addr}
+		for _, k := range t.StorageKeys {
+			key, ok := new(big.Int).SetString(k, 0)
+			if !ok {
+				return nil, errors.New("invalid storage key " + k)
+			}
+			tuple.keys = append(tuple.keys, key)
+		}
+		tuples = append(tuples, tuple)
+	}
+	return tuples, nil
+}
+
+func precompileAddresses(precompiles map[string]Precompile) []accnt.Address {
+	var addrs []accnt.Address
+	for a := range precompiles {
+		if addr, err := accnt.NewAddress(a); err == nil {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
@@ -54,10 +54,11 @@ func main() {
 
 	a := accounts[1]
 
-	ctr, err := contract.Compile("escrow.sol")
+	cs, err := contract.Compile("escrow.sol")
 	if err != nil {
 		panic(err)
 	}
+	ctr := cs["escrow.sol:Escrow"]
 
 	nonce, err := cl.GetTransactionCount(a.Address())
 	if err != nil {
@@ -90,10 +91,11 @@ func main() {
 }
 
 func callContract() {
-	ctr, err := contract.Compile("escrow.sol")
+	cs, err := contract.Compile("escrow.sol")
 	if err != nil {
 		panic(err)
 	}
+	ctr := cs["escrow.sol:Escrow"].Contract
 	ctr.Address = "0x560a0c0ca6b0a67895024dae77442c5fd3dc473e"
 
 	var resp uint64
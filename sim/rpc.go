@@ -0,0 +1,773 @@
+package sim
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"ethereum/accnt"
+	"ethereum/client"
+	"ethereum/contract"
+	"ethereum/evm"
+	"ethereum/txn"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeServerError    = -32000
+	codeReverted       = 3 // the data is the revert data
+)
+
+type rpcRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+func invalidParams(err error) error {
+	return &rpcError{Code: codeInvalidParams, Message: "invalid params: " + err.Error()}
+}
+
+type method func(b *Backend, params []json.RawMessage) (interface{}, error)
+
+var methods = map[string]method{
+	"eth_chainId":               (*Backend).chainID,
+	"net_version":               (*Backend).netVersion,
+	"eth_blockNumber":           (*Backend).blockNumber,
+	"eth_getBlockByNumber":      (*Backend).getBlockByNumber,
+	"eth_getBlockByHash":        (*Backend).getBlockByHash,
+	"eth_getBalance":            (*Backend).getBalance,
+	"eth_getTransactionCount":   (*Backend).getTransactionCount,
+	"eth_getCode":               (*Backend).getCode,
+	"eth_getStorageAt":          (*Backend).getStorageAt,
+	"eth_gasPrice":              (*Backend).gasPrice,
+	"eth_call":                  (*Backend).call,
+	"eth_estimateGas":           (*Backend).estimateGas,
+	"eth_sendRawTransaction":    (*Backend).sendRawTransaction,
+	"eth_getTransactionByHash":  (*Backend).getTransactionByHash,
+	"eth_getTransactionReceipt": (*Backend).getTransactionReceipt,
+	"eth_getLogs":               (*Backend).getLogs,
+}
+
+// Dial serves the chain over HTTP on a loopback port, unless it already is,
+// and returns a client connected to it.  Subscriptions aren't supported.
+func (b *Backend) Dial() (client.Client, error) {
+	b.mu.Lock()
+	if b.server == nil {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			b.mu.Unlock()
+			return client.Client{}, err
+		}
+		b.server = &http.Server{Handler: b}
+		b.url = "http://" + l.Addr().String()
+		go b.server.Serve(l)
+	}
+	url := b.url
+	b.mu.Unlock()
+
+	return client.Dial(url)
+}
+
+// Close stops serving the chain.
+func (b *Backend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.server == nil {
+		return nil
+	}
+	err := b.server.Close()
+	b.server = nil
+	return err
+}
+
+// ServeHTTP serves JSON-RPC requests, single or batched, of the eth methods
+// client.Client uses.
+func (b *Backend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var out interface{}
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var reqs []rpcRequest
+		if err := json.Unmarshal(body, &reqs); err != nil {
+			out = errorResponse(nil, &rpcError{Code: codeParseError, Message: err.Error()})
+		} else if len(reqs) == 0 {
+			out = errorResponse(nil, &rpcError{Code: codeInvalidRequest, Message: "empty batch"})
+		} else {
+			resps := make([]rpcResponse, len(reqs))
+			for i, req := range reqs {
+				resps[i] = b.handle(req)
+			}
+			out = resps
+		}
+	} else {
+		var req rpcRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			out = errorResponse(nil, &rpcError{Code: codeParseError, Message: err.Error()})
+		} else {
+			out = b.handle(req)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+func (b *Backend) handle(req rpcRequest) rpcResponse {
+	m, ok := methods[req.Method]
+	if !ok {
+		return errorResponse(req.ID, &rpcError{
+			Code:    codeMethodNotFound,
+			Message: fmt.Sprintf("the method %s does not exist/is not available", req.Method),
+		})
+	}
+
+	b.mu.Lock()
+	result, err := m(b, req.Params)
+	b.mu.Unlock()
+	if err != nil {
+		var rpcErr *rpcError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &rpcError{Code: codeServerError, Message: err.Error()}
+		}
+		return errorResponse(req.ID, rpcErr)
+	}
+
+	enc, err := json.Marshal(result)
+	if err != nil {
+		return errorResponse(req.ID, &rpcError{Code: codeServerError, Message: err.Error()})
+	}
+	return rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: enc}
+}
+
+func errorResponse(id json.RawMessage, err *rpcError) rpcResponse {
+	return rpcResponse{JSONRPC: "2.0", ID: id, Error: err}
+}
+
+// parseParams decodes params into args, of which the first required must be
+// given.
+func parseParams(params []json.RawMessage, required int, args ...interface{}) error {
+	if len(params) < required {
+		return invalidParams(fmt.Errorf("missing value for required argument %d", len(params)))
+	}
+	if len(params) > len(args) {
+		return invalidParams(fmt.Errorf("too many arguments, want at most %d", len(args)))
+	}
+	for i, p := range params {
+		if err := json.Unmarshal(p, args[i]); err != nil {
+			return invalidParams(fmt.Errorf("argument %d: %s", i, err))
+		}
+	}
+	return nil
+}
+
+// blockAt returns the block of a number or tag, or nil if there is none.
+// The pending block is the latest one, as it isn't mined yet.
+func (b *Backend) blockAt(tag string) (*minedBlock, error) {
+	switch tag {
+	case "", "latest", "pending", "safe", "finalized":
+		return b.head(), nil
+	case "earliest":
+		return b.blocks[0], nil
+	}
+	n, err := hexutil.DecodeUint64(tag)
+	if err != nil {
+		return nil, invalidParams(fmt.Errorf("block %q: %s", tag, err))
+	}
+	if n >= uint64(len(b.blocks)) {
+		return nil, nil
+	}
+	return b.blocks[n], nil
+}
+
+// stateAt returns the state of a block, which is the pending state for
+// "pending", and the context to execute in it.  The state must not be
+// modified.
+func (b *Backend) stateAt(tag string) (*evm.MemoryState, evm.BlockContext, error) {
+	if tag == "pending" {
+		return b.pending, b.pendingContext(), nil
+	}
+	blk, err := b.blockAt(tag)
+	if err != nil {
+		return nil, evm.BlockContext{}, err
+	}
+	if blk == nil {
+		return nil, evm.BlockContext{}, errors.New("header not found")
+	}
+	return blk.state, b.blockContext(blk.Number, blk.Timestamp), nil
+}
+
+func parseAddress(a string) (accnt.Address, error) {
+	addr, err := accnt.NewAddress(a)
+	if err != nil || len(addr) != 20 {
+		return nil, invalidParams(fmt.Errorf("invalid address %q", a))
+	}
+	return addr, nil
+}
+
+func (b *Backend) chainID(params []json.RawMessage) (interface{}, error) {
+	return (*hexutil.Big)(b.config.ChainID), nil
+}
+
+func (b *Backend) netVersion(params []json.RawMessage) (interface{}, error) {
+	return b.config.ChainID.String(), nil
+}
+
+func (b *Backend) blockNumber(params []json.RawMessage) (interface{}, error) {
+	return hexutil.Uint64(b.head().Number), nil
+}
+
+func (b *Backend) getBlockByNumber(params []json.RawMessage) (interface{}, error) {
+	var tag string
+	var full bool
+	if err := parseParams(params, 2, &tag, &full); err != nil {
+		return nil, err
+	}
+	blk, err := b.blockAt(tag)
+	if err != nil || blk == nil {
+		return nil, err
+	}
+	return newRPCBlock(blk, full), nil
+}
+
+func (b *Backend) getBlockByHash(params []json.RawMessage) (interface{}, error) {
+	var hash string
+	var full bool
+	if err := parseParams(params, 2, &hash, &full); err != nil {
+		return nil, err
+	}
+	blk := b.byHash[strings.ToLower(hash)]
+	if blk == nil {
+		return nil, nil
+	}
+	return newRPCBlock(blk, full), nil
+}
+
+// accountParams parses the address and block of queries of an account.
+func (b *Backend) accountParams(params []json.RawMessage, args ...interface{}) (accnt.Address, *evm.MemoryState, error) {
+	var a, tag string
+	if err := parseParams(params, 1+len(args), append(append([]interface{}{&a}, args...), &tag)...); err != nil {
+		return nil, nil, err
+	}
+	addr, err := parseAddress(a)
+	if err != nil {
+		return nil, nil, err
+	}
+	state, _, err := b.stateAt(tag)
+	return addr, state, err
+}
+
+func (b *Backend) getBalance(params []json.RawMessage) (interface{}, error) {
+	addr, state, err := b.accountParams(params)
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(state.GetBalance(addr)), nil
+}
+
+func (b *Backend) getTransactionCount(params []json.RawMessage) (interface{}, error) {
+	addr, state, err := b.accountParams(params)
+	if err != nil {
+		return nil, err
+	}
+	return hexutil.Uint64(state.GetNonce(addr)), nil
+}
+
+func (b *Backend) getCode(params []json.RawMessage) (interface{}, error) {
+	addr, state, err := b.accountParams(params)
+	if err != nil {
+		return nil, err
+	}
+	return hexutil.Bytes(state.GetCode(addr)), nil
+}
+
+func (b *Backend) getStorageAt(params []json.RawMessage) (interface{}, error) {
+	var key hexutil.Big
+	addr, state, err := b.accountParams(params, &key)
+	if err != nil {
+		return nil, err
+	}
+	return hexutil.Bytes(state.GetStorage(addr, (*big.Int)(&key)).FillBytes(make([]byte, 32))), nil
+}
+
+func (b *Backend) gasPrice(params []json.RawMessage) (interface{}, error) {
+	return (*hexutil.Big)(b.baseFee), nil
+}
+
+// callArgs are the transaction of eth_call and eth_estimateGas.
+type callArgs struct {
+	From                 string         `json:"from"`
+	To                   string         `json:"to"`
+	Gas                  *hexutil.Big   `json:"gas"`
+	GasPrice             *hexutil.Big   `json:"gasPrice"`
+	MaxFeePerGas         *hexutil.Big   `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big   `json:"maxPriorityFeePerGas"`
+	Value                *hexutil.Big   `json:"value"`
+	Data                 *hexutil.Bytes `json:"data"`
+	Input                *hexutil.Bytes `json:"input"`
+}
+
+// message returns the message of args, with gas limit gas if none is given.
+// As eth_call does, the base fee is waived if no price is given.
+func (args callArgs) message(ctx *evm.BlockContext, gas uint64) (evm.Message, error) {
+	msg := evm.Message{
+		From:           make(accnt.Address, 20),
+		Value:          (*big.Int)(args.Value),
+		GasLimit:       gas,
+		GasPrice:       (*big.Int)(args.GasPrice),
+		GasFeeCap:      (*big.Int)(args.MaxFeePerGas),
+		GasTipCap:      (*big.Int)(args.MaxPriorityFeePerGas),
+		SkipNonceCheck: true,
+	}
+	var err error
+	if args.From != "" {
+		if msg.From, err = parseAddress(args.From); err != nil {
+			return evm.Message{}, err
+		}
+	}
+	if args.To != "" {
+		if msg.To, err = parseAddress(args.To); err != nil {
+			return evm.Message{}, err
+		}
+	}
+	if args.Gas != nil {
+		if !args.Gas.ToInt().IsUint64() {
+			return evm.Message{}, invalidParams(errors.New("gas too large"))
+		}
+		msg.GasLimit = args.Gas.ToInt().Uint64()
+	}
+	if args.Input != nil {
+		msg.Data = *args.Input
+	} else if args.Data != nil {
+		msg.Data = *args.Data
+	}
+	if msg.GasPrice == nil && msg.GasFeeCap == nil && msg.GasTipCap == nil {
+		ctx.BaseFee = new(big.Int)
+	}
+	return msg, nil
+}
+
+// apply executes msg against a copy of state.
+func (b *Backend) apply(state *evm.MemoryState, ctx evm.BlockContext, msg evm.Message) (*evm.Result, error) {
+	return evm.ApplyMessage(b.newEVM(ctx, state.Copy()), msg)
+}
+
+// resultError returns the error of a failed execution, with the revert data
+// as the error data if it reverted.
+func resultError(res *evm.Result) error {
+	if res.Err == evm.ErrExecutionReverted {
+		return &rpcError{
+			Code:    codeReverted,
+			Message: contract.DecodeRevert(res.ReturnData).Error(),
+			Data:    hexutil.Bytes(res.ReturnData).String(),
+		}
+	}
+	return res.Err
+}
+
+func (b *Backend) call(params []json.RawMessage) (interface{}, error) {
+	var args callArgs
+	var tag string
+	if err := parseParams(params, 1, &args, &tag); err != nil {
+		return nil, err
+	}
+	state, ctx, err := b.stateAt(tag)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := args.message(&ctx, b.gasLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := b.apply(state, ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	if res.Failed() {
+		return nil, resultError(res)
+	}
+	return hexutil.Bytes(res.ReturnData), nil
+}
+
+// estimateGas finds the lowest gas limit the transaction succeeds with, by
+// a binary search between the gas it uses and the block gas limit.
+func (b *Backend) estimateGas(params []json.RawMessage) (interface{}, error) {
+	var args callArgs
+	var tag string
+	if err := parseParams(params, 1, &args, &tag); err != nil {
+		return nil, err
+	}
+	state, ctx, err := b.stateAt(tag)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := args.message(&ctx, b.gasLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := b.apply(state, ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	if res.Failed() {
+		return nil, resultError(res)
+	}
+
+	lo, hi := res.UsedGas-1, msg.GasLimit
+	for lo+1 < hi {
+		mid := lo + (hi-lo)/2
+		msg.GasLimit = mid
+		res, err := b.apply(state, ctx, msg)
+		if err == nil && !res.Failed() {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return hexutil.Uint64(hi), nil
+}
+
+func (b *Backend) sendRawTransaction(params []json.RawMessage) (interface{}, error) {
+	var raw hexutil.Bytes
+	if err := parseParams(params, 1, &raw); err != nil {
+		return nil, err
+	}
+	t, err := txn.Decode(raw)
+	if err != nil {
+		return nil, invalidParams(err)
+	}
+	return b.sendTransaction(t)
+}
+
+func (b *Backend) getTransactionByHash(params []json.RawMessage) (interface{}, error) {
+	var hash string
+	if err := parseParams(params, 1, &hash); err != nil {
+		return nil, err
+	}
+	e := b.txs[strings.ToLower(hash)]
+	if e == nil {
+		return nil, nil
+	}
+	return newRPCTransaction(e), nil
+}
+
+func (b *Backend) getTransactionReceipt(params []json.RawMessage) (interface{}, error) {
+	var hash string
+	if err := parseParams(params, 1, &hash); err != nil {
+		return nil, err
+	}
+	e := b.txs[strings.ToLower(hash)]
+	if e == nil || !e.mined {
+		return nil, nil
+	}
+	return newRPCReceipt(e), nil
+}
+
+type filterArgs struct {
+	BlockHash string     `json:"blockHash"`
+	FromBlock string     `json:"fromBlock"`
+	ToBlock   string     `json:"toBlock"`
+	Address   []string   `json:"address"`
+	Topics    [][]string `json:"topics"`
+}
+
+func (b *Backend) getLogs(params []json.RawMessage) (interface{}, error) {
+	var args filterArgs
+	if err := parseParams(params, 1, &args); err != nil {
+		return nil, err
+	}
+
+	var blocks []*minedBlock
+	if args.BlockHash != "" {
+		if args.FromBlock != "" || args.ToBlock != "" {
+			return nil, invalidParams(errors.New("both blockHash and a block range given"))
+		}
+		blk := b.byHash[strings.ToLower(args.BlockHash)]
+		if blk == nil {
+			return nil, errors.New("unknown block")
+		}
+		blocks = append(blocks, blk)
+	} else {
+		from, err := b.blockAt(args.FromBlock)
+		if err != nil {
+			return nil, err
+		}
+		to, err := b.blockAt(args.ToBlock)
+		if err != nil {
+			return nil, err
+		}
+		if to == nil {
+			to = b.head()
+		}
+		if from != nil && from.Number <= to.Number {
+			blocks = b.blocks[from.Number : to.Number+1]
+		}
+	}
+
+	logs := []rpcLog{}
+	for _, blk := range blocks {
+		for _, t := range blk.Transactions {
+			for _, l := range b.txs[t.Hash].receipt.Logs {
+				if matchLog(l, args.Address, args.Topics) {
+					logs = append(logs, newRPCLog(l))
+				}
+			}
+		}
+	}
+	return logs, nil
+}
+
+// matchLog reports whether a log was emitted by one of addresses, or any
+// address if there are none, and matches the topics of a filter.
+func matchLog(l txn.Log, addresses []string, topics [][]string) bool {
+	if len(addresses) > 0 && !containsFold(addresses, l.Address) {
+		return false
+	}
+	if len(topics) > len(l.Topics) {
+		return false
+	}
+	for i, set := range topics {
+		if len(set) > 0 && !containsFold(set, l.Topics[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+type rpcBlock struct {
+	ParentHash            string         `json:"parentHash"`
+	Sha3Uncles            string         `json:"sha3Uncles"`
+	Miner                 string         `json:"miner"`
+	StateRoot             string         `json:"stateRoot"`
+	TransactionsRoot      string         `json:"transactionsRoot"`
+	ReceiptsRoot          string         `json:"receiptsRoot"`
+	LogsBloom             hexutil.Bytes  `json:"logsBloom"`
+	Difficulty            *hexutil.Big   `json:"difficulty"`
+	Number                hexutil.Uint64 `json:"number"`
+	GasLimit              hexutil.Uint64 `json:"gasLimit"`
+	GasUsed               hexutil.Uint64 `json:"gasUsed"`
+	Timestamp             hexutil.Uint64 `json:"timestamp"`
+	ExtraData             hexutil.Bytes  `json:"extraData"`
+	MixHash               string         `json:"mixHash"`
+	Nonce                 string         `json:"nonce"`
+	BaseFeePerGas         *hexutil.Big   `json:"baseFeePerGas"`
+	WithdrawalsRoot       string         `json:"withdrawalsRoot"`
+	BlobGasUsed           hexutil.Uint64 `json:"blobGasUsed"`
+	ExcessBlobGas         hexutil.Uint64 `json:"excessBlobGas"`
+	ParentBeaconBlockRoot string         `json:"parentBeaconBlockRoot"`
+	Hash                  string         `json:"hash"`
+	Size                  hexutil.Uint64 `json:"size"`
+	Transactions          []interface{}  `json:"transactions"`
+	Uncles                []string       `json:"uncles"`
+	Withdrawals           []interface{}  `json:"withdrawals"`
+}
+
+func newRPCBlock(blk *minedBlock, full bool) rpcBlock {
+	h := blk.Header
+	r := rpcBlock{
+		ParentHash:            h.ParentHash,
+		Sha3Uncles:            h.Sha3Uncles,
+		Miner:                 h.Miner,
+		StateRoot:             h.StateRoot,
+		TransactionsRoot:      h.TransactionsRoot,
+		ReceiptsRoot:          h.ReceiptsRoot,
+		LogsBloom:             h.LogsBloom,
+		Difficulty:            (*hexutil.Big)(h.Difficulty),
+		Number:                hexutil.Uint64(h.Number),
+		GasLimit:              hexutil.Uint64(h.GasLimit),
+		GasUsed:               hexutil.Uint64(h.GasUsed),
+		Timestamp:             hexutil.Uint64(h.Timestamp),
+		ExtraData:             h.ExtraData,
+		MixHash:               h.MixHash,
+		Nonce:                 fmt.Sprintf("0x%016x", h.Nonce),
+		BaseFeePerGas:         (*hexutil.Big)(h.BaseFeePerGas),
+		WithdrawalsRoot:       h.WithdrawalsRoot,
+		BlobGasUsed:           hexutil.Uint64(*h.BlobGasUsed),
+		ExcessBlobGas:         hexutil.Uint64(*h.ExcessBlobGas),
+		ParentBeaconBlockRoot: h.ParentBeaconBlockRoot,
+		Hash:                  blk.Hash,
+		Size:                  hexutil.Uint64(blk.Size),
+		Transactions:          []interface{}{},
+		Uncles:                blk.Uncles,
+		Withdrawals:           []interface{}{},
+	}
+	for i, t := range blk.Transactions {
+		if full {
+			r.Transactions = append(r.Transactions, newRPCTransaction(&entry{tx: t, mined: true}))
+		} else {
+			r.Transactions = append(r.Transactions, blk.TransactionHashes[i])
+		}
+	}
+	return r
+}
+
+// rpcTransaction is a transaction as nodes return it.  The block fields of
+// a pending transaction are null.
+type rpcTransaction struct {
+	BlockHash        *string         `json:"blockHash"`
+	BlockNumber      *hexutil.Uint64 `json:"blockNumber"`
+	From             string          `json:"from"`
+	Gas              *hexutil.Big    `json:"gas"`
+	GasPrice         *hexutil.Big    `json:"gasPrice"`
+	Hash             string          `json:"hash"`
+	Input            hexutil.Bytes   `json:"input"`
+	Nonce            hexutil.Uint64  `json:"nonce"`
+	To               *string         `json:"to"`
+	TransactionIndex *hexutil.Uint64 `json:"transactionIndex"`
+	Value            *hexutil.Big    `json:"value"`
+	Type             hexutil.Uint64  `json:"type"`
+	V                hexutil.Uint64  `json:"v"`
+	R                *hexutil.Big    `json:"r"`
+	S                *hexutil.Big    `json:"s"`
+}
+
+func newRPCTransaction(e *entry) rpcTransaction {
+	t := e.tx
+	r := rpcTransaction{
+		From:     t.From,
+		Gas:      (*hexutil.Big)(t.Gas),
+		GasPrice: (*hexutil.Big)(t.GasPrice),
+		Hash:     t.Hash,
+		Input:    t.Input,
+		Nonce:    hexutil.Uint64(t.Nonce),
+		To:       optional(t.To),
+		Value:    (*hexutil.Big)(t.Value),
+		Type:     hexutil.Uint64(t.Type),
+		V:        hexutil.Uint64(t.V),
+		R:        (*hexutil.Big)(t.R),
+		S:        (*hexutil.Big)(t.S),
+	}
+	if e.mined {
+		number, index := hexutil.Uint64(t.BlockNumber), hexutil.Uint64(t.TransactionIndex)
+		r.BlockHash, r.BlockNumber, r.TransactionIndex = &t.BlockHash, &number, &index
+	}
+	return r
+}
+
+type rpcReceipt struct {
+	BlockHash         string         `json:"blockHash"`
+	BlockNumber       hexutil.Uint64 `json:"blockNumber"`
+	ContractAddress   *string        `json:"contractAddress"`
+	CumulativeGasUsed *hexutil.Big   `json:"cumulativeGasUsed"`
+	EffectiveGasPrice *hexutil.Big   `json:"effectiveGasPrice"`
+	From              string         `json:"from"`
+	GasUsed           *hexutil.Big   `json:"gasUsed"`
+	Logs              []rpcLog       `json:"logs"`
+	LogsBloom         string         `json:"logsBloom"`
+	Status            hexutil.Uint64 `json:"status"`
+	To                *string        `json:"to"`
+	TransactionHash   string         `json:"transactionHash"`
+	TransactionIndex  hexutil.Uint64 `json:"transactionIndex"`
+	Type              hexutil.Uint64 `json:"type"`
+}
+
+func newRPCReceipt(e *entry) rpcReceipt {
+	r := e.receipt
+	out := rpcReceipt{
+		BlockHash:         r.BlockHash,
+		BlockNumber:       hexutil.Uint64(r.BlockNumber),
+		ContractAddress:   optional(r.ContractAddress),
+		CumulativeGasUsed: (*hexutil.Big)(r.CumulativeGasUsed),
+		// The price legacy transactions pay is their gas price.
+		EffectiveGasPrice: (*hexutil.Big)(e.tx.GasPrice),
+		From:              r.From,
+		GasUsed:           (*hexutil.Big)(r.GasUsed),
+		Logs:              make([]rpcLog, len(r.Logs)),
+		LogsBloom:         r.LogsBloom,
+		Status:            hexutil.Uint64(r.Status),
+		To:                optional(r.To),
+		TransactionHash:   r.TransactionHash,
+		TransactionIndex:  hexutil.Uint64(r.TransactionIndex),
+		Type:              hexutil.Uint64(r.Type),
+	}
+	for i, l := range r.Logs {
+		out.Logs[i] = newRPCLog(l)
+	}
+	return out
+}
+
+type rpcLog struct {
+	Address          string         `json:"address"`
+	Topics           []string       `json:"topics"`
+	Data             hexutil.Bytes  `json:"data"`
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	BlockHash        string         `json:"blockHash"`
+	TransactionHash  string         `json:"transactionHash"`
+	TransactionIndex hexutil.Uint64 `json:"transactionIndex"`
+	LogIndex         hexutil.Uint64 `json:"logIndex"`
+	Removed          bool           `json:"removed"`
+}
+
+func newRPCLog(l txn.Log) rpcLog {
+	topics := l.Topics
+	if topics == nil {
+		topics = []string{}
+	}
+	return rpcLog{
+		Address:          l.Address,
+		Topics:           topics,
+		Data:             l.Data,
+		BlockNumber:      hexutil.Uint64(l.BlockNumber),
+		BlockHash:        l.BlockHash,
+		TransactionHash:  l.TransactionHash,
+		TransactionIndex: hexutil.Uint64(l.TransactionIndex),
+		LogIndex:         hexutil.Uint64(l.LogIndex),
+		Removed:          l.Removed,
+	}
+}
+
+// optional returns nil for "", which is encoded as null.
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,421 @@
+// Package sim simulates a chain in process, so that flows which deploy and
+// call contracts can run in tests without a node.  Transactions are executed
+// by the evm package against an in-memory state, and blocks are mined on
+// demand.  The chain is served over JSON-RPC, for client.Client to use it
+// unchanged.
+package sim
+
+import (
+	"encoding/hex"
+	"errors"
+	"ethereum/accnt"
+	"ethereum/block"
+	"ethereum/evm"
+	"ethereum/trie"
+	"ethereum/txn"
+	"ethereum/util"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Defaults of Config.
+const (
+	DefaultChainID  = 1337
+	DefaultGasLimit = 30000000
+	DefaultBaseFee  = 1e9 // 1 Gwei
+)
+
+var (
+	// ErrAlreadyKnown is returned when a transaction is sent twice.
+	ErrAlreadyKnown = errors.New("already known")
+
+	// ErrGasLimitReached is returned when a transaction doesn't fit in the
+	// gas left in the pending block.
+	ErrGasLimitReached = errors.New("gas limit reached")
+)
+
+// Config is the configuration of a Backend.  All fields are optional.
+type Config struct {
+	ChainID  *big.Int
+	GasLimit uint64        // of every block
+	BaseFee  *big.Int      // of every block, as it doesn't adjust to usage
+	Coinbase accnt.Address // the recipient of priority fees, zero if nil
+	KZG      evm.KZG       // verifies point evaluation proofs, evm.KZG4844 if nil
+
+	// AutoMine mines a block with each transaction sent, so that it is
+	// mined by the time its hash is returned.  Otherwise transactions are
+	// pending until Commit.
+	AutoMine bool
+}
+
+// GenesisAccount is an account of the genesis state.
+type GenesisAccount struct {
+	Balance *big.Int
+	Nonce   uint64
+	Code    []byte
+}
+
+// Alloc is the genesis state, keyed by `0xHEX` address.
+type Alloc map[string]GenesisAccount
+
+// Backend is a simulated chain of a single node, following the rules of the
+// latest fork the evm package supports.  Only legacy transactions are
+// accepted, as those are what txn.Transaction is.  It is safe for concurrent
+// use.
+type Backend struct {
+	config   evm.Config
+	gasLimit uint64
+	baseFee  *big.Int
+	coinbase accnt.Address
+	autoMine bool
+
+	mu     sync.Mutex
+	blocks []*minedBlock
+	byHash map[string]*minedBlock
+	txs    map[string]*entry // by `0xHEX` hash
+
+	// The transactions of the pending block are executed as they are sent,
+	// against pending, which is the state of the block once it is mined.
+	pending     *evm.MemoryState
+	pendingTime uint64
+	pendingTxs  []*entry
+	pendingGas  uint64
+	pendingLogs uint64
+
+	server *http.Server
+	url    string
+}
+
+type minedBlock struct {
+	block.Block // with full transactions
+	state       *evm.MemoryState
+}
+
+// entry is a transaction sent to the backend, with its receipt.
+type entry struct {
+	tx      txn.BlockTransaction
+	receipt txn.TransactionReceipt
+	mined   bool
+}
+
+// New returns a Backend whose genesis block has the state given by alloc.
+func New(config Config, alloc Alloc) (*Backend, error) {
+	kzg := config.KZG
+	if kzg == nil {
+		kzg = evm.KZG4844{}
+	}
+	b := &Backend{
+		config: evm.Config{
+			Fork:        evm.Latest,
+			ChainID:     config.ChainID,
+			Precompiles: evm.Precompiles(evm.Latest, kzg),
+		},
+		gasLimit: config.GasLimit,
+		baseFee:  config.BaseFee,
+		coinbase: config.Coinbase,
+		autoMine: config.AutoMine,
+		byHash:   make(map[string]*minedBlock),
+		txs:      make(map[string]*entry),
+		pending:  evm.NewMemoryState(),
+	}
+	if b.config.ChainID == nil {
+		b.config.ChainID = big.NewInt(DefaultChainID)
+	}
+	if b.gasLimit == 0 {
+		b.gasLimit = DefaultGasLimit
+	}
+	if b.baseFee == nil {
+		b.baseFee = big.NewInt(DefaultBaseFee)
+	}
+	if b.coinbase == nil {
+		b.coinbase = make(accnt.Address, 20)
+	}
+
+	for a, acc := range alloc {
+		addr, err := accnt.NewAddress(a)
+		if err != nil {
+			return nil, fmt.Errorf("alloc: %s", err)
+		}
+		b.pending.CreateAccount(addr)
+		if acc.Balance != nil {
+			b.pending.SetBalance(addr, acc.Balance)
+		}
+		b.pending.SetNonce(addr, acc.Nonce)
+		b.pending.SetCode(addr, acc.Code)
+	}
+	b.pendingTime = uint64(time.Now().Unix())
+	b.commit()
+
+	return b, nil
+}
+
+// Commit mines the pending transactions into a block, and returns its hash.
+// A block is mined even if no transaction is pending.
+func (b *Backend) Commit() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.commit().Hash
+}
+
+// SendTransaction executes a signed transaction in the pending block, and
+// returns its `0xHEX` hash.  An error is returned if the transaction is
+// invalid, e.g. because its nonce isn't the sender's next one.
+func (b *Backend) SendTransaction(t txn.Transaction) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sendTransaction(t)
+}
+
+func (b *Backend) sendTransaction(t txn.Transaction) (string, error) {
+	if t.GasPrice == nil || t.GasLimit == nil {
+		return "", errors.New("transaction has no gas price or gas limit")
+	}
+	from, err := t.Sender()
+	if err != nil {
+		return "", err
+	}
+	hash := "0x" + t.Hash()
+	if b.txs[hash] != nil {
+		return "", ErrAlreadyKnown
+	}
+
+	msg := evm.Message{
+		From:     from,
+		Nonce:    t.Nonce,
+		Value:    t.Value,
+		Data:     t.Data,
+		GasLimit: t.GasLimit.Uint64(),
+		GasPrice: t.GasPrice,
+	}
+	if t.To != "" {
+		if msg.To, err = accnt.NewAddress(t.To); err != nil {
+			return "", err
+		}
+	}
+	if !t.GasLimit.IsUint64() || msg.GasLimit > b.gasLimit-b.pendingGas {
+		return "", ErrGasLimitReached
+	}
+
+	res, err := evm.ApplyMessage(b.newEVM(b.pendingContext(), b.pending), msg)
+	if err != nil {
+		return "", err
+	}
+	b.pending.Commit()
+
+	e := &entry{
+		tx: txn.BlockTransaction{
+			BlockNumber:      b.head().Number + 1,
+			From:             from.String(),
+			Gas:              new(big.Int).Set(t.GasLimit),
+			GasPrice:         new(big.Int).Set(t.GasPrice),
+			Hash:             hash,
+			Input:            t.Data,
+			Nonce:            t.Nonce,
+			To:               strings.ToLower(t.To),
+			TransactionIndex: uint64(len(b.pendingTxs)),
+			Value:            orZero(t.Value),
+			V:                t.V,
+			R:                t.R,
+			S:                t.S,
+		},
+	}
+	b.pendingGas += res.UsedGas
+
+	for i := range res.Logs {
+		l := &res.Logs[i]
+		l.BlockNumber = e.tx.BlockNumber
+		l.TransactionHash = hash
+		l.TransactionIndex = e.tx.TransactionIndex
+		l.LogIndex = b.pendingLogs
+		b.pendingLogs++
+	}
+	e.receipt = txn.TransactionReceipt{
+		BlockNumber:       e.tx.BlockNumber,
+		CumulativeGasUsed: new(big.Int).SetUint64(b.pendingGas),
+		From:              e.tx.From,
+		GasUsed:           new(big.Int).SetUint64(res.UsedGas),
+		Logs:              res.Logs,
+		LogsBloom:         "0x" + hex.EncodeToString(logsBloom(res.Logs)),
+		To:                e.tx.To,
+		TransactionHash:   hash,
+		TransactionIndex:  e.tx.TransactionIndex,
+		Type:              txn.LegacyTxType,
+	}
+	if !res.Failed() {
+		e.receipt.Status = 1
+	}
+	if msg.To == nil {
+		e.receipt.ContractAddress = evm.CreateAddress(from, t.Nonce).String()
+	}
+	if e.receipt.Logs == nil {
+		e.receipt.Logs = []txn.Log{}
+	}
+
+	b.txs[hash] = e
+	b.pendingTxs = append(b.pendingTxs, e)
+	if b.autoMine {
+		b.commit()
+	}
+	return hash, nil
+}
+
+func (b *Backend) head() *minedBlock {
+	return b.blocks[len(b.blocks)-1]
+}
+
+func (b *Backend) newEVM(ctx evm.BlockContext, state evm.StateDB) *evm.EVM {
+	return evm.New(ctx, state, b.config)
+}
+
+func (b *Backend) blockContext(number, time uint64) evm.BlockContext {
+	return evm.BlockContext{
+		Coinbase:    b.coinbase,
+		Number:      number,
+		Time:        time,
+		GasLimit:    b.gasLimit,
+		Difficulty:  new(big.Int),
+		Random:      new(big.Int),
+		BaseFee:     new(big.Int).Set(b.baseFee),
+		BlobBaseFee: big.NewInt(1),
+		GetHash: func(n uint64) []byte {
+			if n >= uint64(len(b.blocks)) {
+				return nil
+			}
+			h, _ := hex.DecodeString(b.blocks[n].Hash[2:])
+			return h
+		},
+	}
+}
+
+func (b *Backend) pendingContext() evm.BlockContext {
+	var number uint64
+	if len(b.blocks) > 0 {
+		number = b.head().Number + 1
+	}
+	return b.blockContext(number, b.pendingTime)
+}
+
+// commit mines the pending block, and opens the next one.
+func (b *Backend) commit() *minedBlock {
+	ctx := b.pendingContext()
+	txs := make([]txn.BlockTransaction, len(b.pendingTxs))
+	receipts := make([]txn.TransactionReceipt, len(b.pendingTxs))
+	var logs []txn.Log
+	for i, e := range b.pendingTxs {
+		txs[i], receipts[i] = e.tx, e.receipt
+		logs = append(logs, e.receipt.Logs...)
+	}
+	// Transactions are legacy, which always encode.
+	txRoot, _ := txn.DeriveTransactionsRoot(txs)
+
+	zero := "0x" + strings.Repeat("0", 64)
+	parent := zero
+	if len(b.blocks) > 0 {
+		parent = b.head().Hash
+	}
+	var blobGas uint64
+	h := block.Header{
+		ParentHash:            parent,
+		Sha3Uncles:            "0x" + hex.EncodeToString(crypto.Keccak256(util.EncodeRLPValue([]interface{}{}))),
+		Miner:                 b.coinbase.String(),
+		StateRoot:             "0x" + hex.EncodeToString(b.pending.Root()),
+		TransactionsRoot:      txRoot,
+		ReceiptsRoot:          txn.DeriveReceiptsRoot(receipts),
+		LogsBloom:             logsBloom(logs),
+		Difficulty:            new(big.Int),
+		Number:                ctx.Number,
+		GasLimit:              b.gasLimit,
+		GasUsed:               b.pendingGas,
+		Timestamp:             ctx.Time,
+		ExtraData:             []byte{},
+		MixHash:               zero,
+		BaseFeePerGas:         ctx.BaseFee,
+		WithdrawalsRoot:       "0x" + hex.EncodeToString(trie.EmptyRoot),
+		BlobGasUsed:           &blobGas,
+		ExcessBlobGas:         &blobGas,
+		ParentBeaconBlockRoot: zero,
+	}
+	blk := &minedBlock{
+		Block: block.Block{
+			Header:      h,
+			Hash:        h.Hash(),
+			Uncles:      []string{},
+			Withdrawals: []block.Withdrawal{},
+		},
+		state: b.pending,
+	}
+
+	for _, e := range b.pendingTxs {
+		e.mined = true
+		e.tx.BlockHash = blk.Hash
+		e.receipt.BlockHash = blk.Hash
+		for i := range e.receipt.Logs {
+			e.receipt.Logs[i].BlockHash = blk.Hash
+		}
+		blk.Transactions = append(blk.Transactions, e.tx)
+		blk.TransactionHashes = append(blk.TransactionHashes, e.tx.Hash)
+	}
+	blk.Size = blockSize(blk.Block)
+
+	b.blocks = append(b.blocks, blk)
+	b.byHash[blk.Hash] = blk
+
+	b.pending = b.pending.Copy()
+	b.pendingTxs, b.pendingGas, b.pendingLogs = nil, 0, 0
+	b.pendingTime = uint64(time.Now().Unix())
+	if b.pendingTime <= blk.Timestamp {
+		b.pendingTime = blk.Timestamp + 1
+	}
+	return blk
+}
+
+// logsBloom returns the 2048 bit bloom filter of the addresses and topics of
+// logs.  Each sets the three bits given by the first three pairs of bytes of
+// its hash, modulo 2048.
+func logsBloom(logs []txn.Log) []byte {
+	bloom := make([]byte, 256)
+	add := func(b []byte) {
+		h := crypto.Keccak256(b)
+		for i := 0; i < 6; i += 2 {
+			bit := (uint(h[i])<<8 | uint(h[i+1])) & 2047
+			bloom[255-bit/8] |= 1 << (bit % 8)
+		}
+	}
+	for _, l := range logs {
+		add(decodeHex(l.Address))
+		for _, t := range l.Topics {
+			add(decodeHex(t))
+		}
+	}
+	return bloom
+}
+
+// blockSize returns the size of the RLP encoding of b.
+func blockSize(b block.Block) uint64 {
+	txs := make([]interface{}, len(b.Transactions))
+	for i, t := range b.Transactions {
+		enc, _ := t.Encode()
+		txs[i] = util.RawRLP(enc)
+	}
+	return uint64(len(util.EncodeRLPValue([]interface{}{
+		util.RawRLP(b.Header.Encode()), txs, []interface{}{}, []interface{}{},
+	})))
+}
+
+func decodeHex(h string) []byte {
+	b, _ := hex.DecodeString(strings.TrimPrefix(h, "0x"))
+	return b
+}
+
+func orZero(i *big.Int) *big.Int {
+	if i == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(i)
+}
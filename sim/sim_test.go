@@ -0,0 +1,366 @@
+package sim
+
+import (
+	"encoding/hex"
+	"ethereum/accnt"
+	"ethereum/block"
+	"ethereum/client"
+	"ethereum/contract"
+	"ethereum/evm/asm"
+	"ethereum/txn"
+	"ethereum/util"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const storeAbi = `[{"inputs":[],"name":"get","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view",` +
+	`"type":"function"},{"inputs":[{"name":"v","type":"uint256"}],"name":"set","outputs":[],` +
+	`"stateMutability":"nonpayable","type":"function"},{"inputs":[],"name":"fail","outputs":[],` +
+	`"stateMutability":"view","type":"function"},{"anonymous":false,"inputs":[{"indexed":false,"name":"v",` +
+	`"type":"uint256"}],"name":"Set","type":"event"}]`
+
+func keccak(s string) string {
+	return hex.EncodeToString(crypto.Keccak256([]byte(s)))
+}
+
+// storeBin returns the creation code of a contract implementing storeAbi:
+// get returns the value stored by set, which emits Set, and fail reverts
+// with "nope".
+func storeBin(t *testing.T) []byte {
+	runtime, err := asm.Assemble(fmt.Sprintf(`
+			PUSH0
+			CALLDATALOAD
+			PUSH 0xe0
+			SHR
+			DUP1
+			PUSH4 0x%s
+			EQ
+			PUSH @get
+			JUMPI
+			DUP1
+			PUSH4 0x%s
+			EQ
+			PUSH @set
+			JUMPI
+			PUSH4 0x%s
+			EQ
+			PUSH @fail
+			JUMPI
+			PUSH0
+			DUP1
+			REVERT
+		get: JUMPDEST
+			PUSH0
+			SLOAD
+			PUSH0
+			MSTORE
+			PUSH 32
+			PUSH0
+			RETURN
+		set: JUMPDEST
+			PUSH 4
+			CALLDATALOAD
+			DUP1
+			PUSH0
+			SSTORE
+			PUSH0
+			MSTORE
+			PUSH32 0x%s
+			PUSH 32
+			PUSH0
+			LOG1
+			STOP
+		fail: JUMPDEST
+			PUSH32 0x08c379a0%s
+			PUSH0
+			MSTORE
+			PUSH 0x20
+			PUSH 4
+			MSTORE
+			PUSH 4
+			PUSH 0x24
+			MSTORE
+			PUSH32 0x6e6f7065%s
+			PUSH 0x44
+			MSTORE
+			PUSH 0x64
+			PUSH0
+			REVERT`,
+		keccak("get()")[:8], keccak("set(uint256)")[:8], keccak("fail()")[:8], keccak("Set(uint256)"),
+		strings.Repeat("0", 56), strings.Repeat("0", 56)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bin, err := asm.Assemble(fmt.Sprintf(`
+			PUSH @end-@runtime
+			DUP1
+			PUSH @runtime
+			PUSH0
+			CODECOPY
+			PUSH0
+			RETURN
+		runtime:
+			.bytes 0x%x
+		end:`, runtime))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bin
+}
+
+// newTestBackend returns a backend whose genesis funds the account of the
+// returned key, and a client connected to it.
+func newTestBackend(t *testing.T, autoMine bool) (*Backend, client.Client, accnt.Private) {
+	priv, err := accnt.NewAccount("cb4aab9577130f5c4622f355e5c6c3cad2661518ac968c34e4f14a9fde071bfd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := New(Config{AutoMine: autoMine}, Alloc{
+		priv.Address().String(): {Balance: util.EthToWei(100)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := b.Dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b, c, priv
+}
+
+// deployStore deploys the contract of storeAbi.
+func deployStore(t *testing.T, c client.Client, priv accnt.Private) contract.Contract {
+	a, err := contract.NewABI(storeAbi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, receipt, err := contract.DeployAndWait(c, txn.KeySigner{Private: priv}, contract.Contract{Abi: a, Bin: storeBin(t)},
+		contract.DeployOpts{PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.BlockNumber != 1 || receipt.Status != 1 {
+		t.Errorf("Expected: block 1, status 1, received: block %d, status %d", receipt.BlockNumber, receipt.Status)
+	}
+	return store
+}
+
+// transact sends a transaction from priv calling a function of cont.
+func transact(t *testing.T, c client.Client, priv accnt.Private, cont contract.Contract, funcName string,
+	args ...interface{}) string {
+	nonce, err := c.GetTransactionCount(priv.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := txn.Transaction{Nonce: nonce, GasPrice: big.NewInt(2e9), GasLimit: big.NewInt(100000)}
+	if err := cont.Transact(contract.CallOpts{}, funcName, &tx, args...); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Sign(priv); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := c.SendTransaction(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestDeployAndCall(t *testing.T) {
+	b, c, priv := newTestBackend(t, true)
+	defer b.Close()
+
+	store := deployStore(t, c, priv)
+	hash := transact(t, c, priv, store, "set", big.NewInt(42))
+
+	receipt, err := c.GetTransactionReceipt(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.Status != 1 || receipt.BlockNumber != 2 || len(receipt.Logs) != 1 {
+		t.Fatalf("Expected: status 1, block 2, 1 log, received: status %d, block %d, %d logs", receipt.Status,
+			receipt.BlockNumber, len(receipt.Logs))
+	}
+	if l := receipt.Logs[0]; l.Address != store.Address || l.Topics[0] != "0x"+keccak("Set(uint256)") ||
+		new(big.Int).SetBytes(l.Data).Int64() != 42 || l.BlockHash != receipt.BlockHash {
+		t.Errorf("Unexpected log: %+v", l)
+	}
+
+	var v *big.Int
+	if err := c.CallContract(store, "get", nil, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Int64() != 42 {
+		t.Errorf("Expected: %v, received: %v", 42, v)
+	}
+
+	// The value before set, at the block of the deployment.
+	if err := c.CallContractOpts(contract.CallOpts{BlockNumber: big.NewInt(1)}, store, "get", nil, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Sign() != 0 {
+		t.Errorf("Expected: %v, received: %v", 0, v)
+	}
+
+	logs, err := c.FilterLogs(client.FilterQuery{Addresses: []string{store.Address},
+		Topics: [][]string{{"0x" + keccak("Set(uint256)")}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 || logs[0].TransactionHash != hash {
+		t.Errorf("Expected: %s, received: %+v", hash, logs)
+	}
+
+	err = c.CallContract(store, "fail", nil, nil)
+	if re, ok := err.(*contract.RevertError); !ok || re.Reason != "nope" {
+		t.Errorf("Expected: %v, received: %v", "execution reverted: nope", err)
+	}
+}
+
+func TestCommit(t *testing.T) {
+	b, c, priv := newTestBackend(t, false)
+	defer b.Close()
+
+	to := "0x19e7e376e7c213b7e7e7e46cc70a5dd086daff2a"
+	var hashes []string
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		tx := txn.Transaction{Nonce: nonce, GasPrice: big.NewInt(2e9), GasLimit: big.NewInt(21000), To: to,
+			Value: big.NewInt(1000)}
+		if err := tx.Sign(priv); err != nil {
+			t.Fatal(err)
+		}
+		hash, err := c.SendTransaction(tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, hash)
+
+		if _, err := c.SendTransaction(tx); err == nil || err.Error() != ErrAlreadyKnown.Error() {
+			t.Errorf("Expected: %v, received: %v", ErrAlreadyKnown, err)
+		}
+	}
+
+	// Pending transactions have no receipt.
+	if _, err := c.GetTransactionReceipt(hashes[0]); err != client.ErrNotFound {
+		t.Errorf("Expected: %v, received: %v", client.ErrNotFound, err)
+	}
+
+	hash := b.Commit()
+	n, err := c.BlockNumber()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("Expected: %d, received: %d", 1, n)
+	}
+
+	blk, err := c.GetBlockByHash(hash, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := blk.VerifyHash(); err != nil {
+		t.Error(err)
+	}
+	genesis, err := c.GetHeader(big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := block.VerifyChain([]block.Header{genesis, blk.Header}); err != nil {
+		t.Error(err)
+	}
+	if len(blk.Transactions) != 2 || blk.Transactions[1].Hash != hashes[1] || blk.GasUsed != 42000 {
+		t.Fatalf("Expected: 2 transactions using 42000 gas, received: %d using %d", len(blk.Transactions),
+			blk.GasUsed)
+	}
+	if root, err := txn.DeriveTransactionsRoot(blk.Transactions); err != nil || root != blk.TransactionsRoot {
+		t.Errorf("Expected: %v, received: %v (%v)", blk.TransactionsRoot, root, err)
+	}
+
+	var receipts []txn.TransactionReceipt
+	for _, h := range hashes {
+		r, err := c.GetTransactionReceipt(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		receipts = append(receipts, r)
+	}
+	if root := txn.DeriveReceiptsRoot(receipts); root != blk.ReceiptsRoot {
+		t.Errorf("Expected: %v, received: %v", blk.ReceiptsRoot, root)
+	}
+	if receipts[1].CumulativeGasUsed.Int64() != 42000 || receipts[1].BlockHash != hash {
+		t.Errorf("Unexpected receipt: %+v", receipts[1])
+	}
+
+	balance, err := c.GetBalance(to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Int64() != 2000 {
+		t.Errorf("Expected: %d, received: %d", 2000, balance)
+	}
+
+	tx := txn.Transaction{Nonce: 0, GasPrice: big.NewInt(2e9), GasLimit: big.NewInt(21000), To: to}
+	if err := tx.Sign(priv); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SendTransaction(tx); err == nil || !strings.Contains(err.Error(), "nonce too low") {
+		t.Errorf("Expected: nonce too low, received: %v", err)
+	}
+}
+
+func TestMulticallBatch(t *testing.T) {
+	b, c, priv := newTestBackend(t, true)
+	defer b.Close()
+
+	store := deployStore(t, c, priv)
+	transact(t, c, priv, store, "set", big.NewInt(7))
+
+	// There is no Multicall3 contract, so calls are batched.
+	m, err := c.NewMulticall("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v *big.Int
+	calls := []*client.Call{
+		{Contract: store, Func: "get", Output: &v},
+		{Contract: store, Func: "fail", AllowFailure: true},
+	}
+	if err := m.Call(contract.CallOpts{}, calls...); err != nil {
+		t.Fatal(err)
+	}
+	if v.Int64() != 7 {
+		t.Errorf("Expected: %v, received: %v", 7, v)
+	}
+	if re, ok := calls[1].Err.(*contract.RevertError); !ok || re.Reason != "nope" {
+		t.Errorf("Expected: %v, received: %v", "execution reverted: nope", calls[1].Err)
+	}
+}
+
+func TestPointEvaluation(t *testing.T) {
+	b, c, _ := newTestBackend(t, true)
+	defer b.Close()
+
+	// The vector of go-ethereum's core/vm/testdata/precompiles/pointEvaluation.json.
+	input, err := hex.DecodeString("01e798154708fe7789429634053cbf9f99b619f9f084048927333fce637f549b564c0a11a0f704f4fc3e8" +
+		"acfe0f8245f0ad1347b378fbf96e206da11a5d3630624d25032e67a7e6a4910df5834b8fe70e6bcfeeac0352434196bdf4b2485d5a1" +
+		"8f59a8d2a1a625a17f3fea0fe5eb8c896db3764f3185481bc22f91b4aaffcca25f26936857bc3a7c2539ea8ec3a952b7873033e0383" +
+		"26e87ed3e1276fd140253fa08e9fc25fb2d9a98527fc22a2c9612fbeafdad446cbc7bcdbdcd780af2c16a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := c.CallData(contract.CallOpts{}, "0x000000000000000000000000000000000000000a", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "0000000000000000000000000000000000000000000000000000000000001000" +
+		"73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001"
+	if hex.EncodeToString(out) != expected {
+		t.Errorf("Expected: %s, received: %x", expected, out)
+	}
+}
@@ -0,0 +1,144 @@
+package trie
+
+import (
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Iterator visits the nodes of a trie depth first, in key order, loading
+// them from the store as it goes.  The value of a branch node is visited as
+// a leaf after the branch, before its children.
+//
+//	it := t.NodeIterator()
+//	for it.Next() {
+//		if it.Leaf() {
+//			fmt.Printf("%x: %x\n", it.LeafKey(), it.LeafValue())
+//		}
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator struct {
+	trie  *Trie
+	stack []iteratorItem
+	cur   iteratorItem
+	err   error
+}
+
+type iteratorItem struct {
+	n    node
+	path []byte // in nibbles
+	hash []byte // if n was referred to by hash
+	root bool
+
+	// value is set for the value of a branch node, in which case n is nil.
+	value []byte
+}
+
+// NodeIterator returns an iterator over the nodes of the trie.
+func (t *Trie) NodeIterator() *Iterator {
+	it := &Iterator{trie: t}
+	if t.root != nil {
+		it.stack = []iteratorItem{{n: t.root, path: []byte{}, root: true}}
+	}
+	return it
+}
+
+// Next moves to the next node, returning false when there are none left or
+// a node can't be loaded, which Err then returns until Skip is called.
+func (it *Iterator) Next() bool {
+	if it.err != nil || len(it.stack) == 0 {
+		return false
+	}
+	item := it.stack[len(it.stack)-1]
+	it.stack = it.stack[:len(it.stack)-1]
+
+	if h, ok := item.n.(hashNode); ok {
+		n, err := it.trie.resolve(h)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		item.n, item.hash = n, h
+	}
+	it.cur = item
+
+	switch nd := item.n.(type) {
+	case *extensionNode:
+		it.stack = append(it.stack, iteratorItem{n: nd.child, path: concat(item.path, nd.key)})
+	case *branchNode:
+		// Pushed in reverse, so that they are visited in order.
+		for i := 15; i >= 0; i-- {
+			if nd.children[i] != nil {
+				it.stack = append(it.stack, iteratorItem{n: nd.children[i], path: concat(item.path, []byte{byte(i)})})
+			}
+		}
+		if nd.value != nil {
+			it.stack = append(it.stack, iteratorItem{path: item.path, value: nd.value})
+		}
+	}
+	return true
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// Skip drops the node which couldn't be loaded, with its subtree, and clears
+// the error, so that Next goes on with the nodes after it.  This lets a trie
+// whose store is missing nodes be walked as far as it can be.
+//
+//	for {
+//		for it.Next() {
+//			...
+//		}
+//		if it.Err() == nil {
+//			break
+//		}
+//		log.Print(it.Err())
+//		it.Skip()
+//	}
+func (it *Iterator) Skip() {
+	it.err = nil
+}
+
+// Path returns the path of the current node from the root, in nibbles.
+func (it *Iterator) Path() []byte {
+	return it.cur.path
+}
+
+// Hash returns the hash of the current node, or nil if it is embedded in its
+// parent or is the value of a branch.  The root is always hashed.
+func (it *Iterator) Hash() []byte {
+	if it.cur.hash != nil || it.cur.n == nil {
+		return it.cur.hash
+	}
+	// Without a store, encoding can't fail.
+	enc, _ := encodeNode(it.cur.n, nil)
+	if len(enc) < 32 && !it.cur.root {
+		return nil
+	}
+	return crypto.Keccak256(enc)
+}
+
+// Leaf reports whether the current node is a leaf, or the value of a branch.
+func (it *Iterator) Leaf() bool {
+	_, leaf := it.cur.n.(*leafNode)
+	return leaf || it.cur.value != nil
+}
+
+// LeafKey returns the key of the current leaf.
+func (it *Iterator) LeafKey() []byte {
+	if l, ok := it.cur.n.(*leafNode); ok {
+		return nibblesToKey(concat(it.cur.path, l.key))
+	}
+	return nibblesToKey(it.cur.path)
+}
+
+// LeafValue returns the value of the current leaf.
+func (it *Iterator) LeafValue() []byte {
+	if l, ok := it.cur.n.(*leafNode); ok {
+		return l.value
+	}
+	return it.cur.value
+}
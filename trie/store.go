@@ -0,0 +1,160 @@
+package trie
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"ethereum/util"
+	"fmt"
+)
+
+// Store holds the nodes of tries, keyed by the keccak256 hash of their
+// encoding.  The leveldb database of a node is a Store.
+type Store interface {
+	Get(hash []byte) ([]byte, error)
+	Put(hash, node []byte) error
+}
+
+// MemoryStore is an in-memory Store.
+type MemoryStore map[string][]byte
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() MemoryStore {
+	return make(MemoryStore)
+}
+
+// ErrNotFound is returned by MemoryStore for missing nodes.
+var ErrNotFound = errors.New("not found")
+
+func (s MemoryStore) Get(hash []byte) ([]byte, error) {
+	n, ok := s[string(hash)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return n, nil
+}
+
+func (s MemoryStore) Put(hash, node []byte) error {
+	s[string(hash)] = append([]byte{}, node...)
+	return nil
+}
+
+// MissingNodeError is returned when a node of a trie can't be loaded from
+// its store.
+type MissingNodeError struct {
+	Hash []byte
+	Err  error // the error of the store, if any
+}
+
+func (e *MissingNodeError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("missing trie node %x: %s", e.Hash, e.Err)
+	}
+	return fmt.Sprintf("missing trie node %x", e.Hash)
+}
+
+func (e *MissingNodeError) Unwrap() error {
+	return e.Err
+}
+
+// decodeNode decodes the encoding of the node with the given hash.
+func decodeNode(hash, enc []byte) (node, error) {
+	v, err := util.DecodeRLP(bytes.NewReader(enc))
+	if err != nil {
+		return nil, fmt.Errorf("trie node %x: %s", hash, err)
+	}
+	n, err := decodeValue(v)
+	if err != nil {
+		return nil, fmt.Errorf("trie node %x: %s", hash, err)
+	}
+	return n, nil
+}
+
+// decodeValue decodes a node from its decoded RLP, which is a list of 2 items
+// for leaf and extension nodes, and of 17 items for branch nodes.
+func decodeValue(v interface{}) (node, error) {
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, errors.New("node is not a list")
+	}
+
+	switch len(items) {
+	case 2:
+		compact, ok := items[0].([]byte)
+		if !ok || len(compact) == 0 {
+			return nil, errors.New("invalid node key")
+		}
+		key, leaf, err := decodeHexPrefix(compact)
+		if err != nil {
+			return nil, err
+		}
+		if leaf {
+			value, ok := items[1].([]byte)
+			if !ok {
+				return nil, errors.New("invalid leaf value")
+			}
+			return &leafNode{key: key, value: value}, nil
+		}
+		child, err := decodeRef(items[1])
+		if err != nil {
+			return nil, err
+		}
+		if child == nil {
+			return nil, errors.New("extension node without child")
+		}
+		return &extensionNode{key: key, child: child}, nil
+	case 17:
+		b := &branchNode{}
+		for i := 0; i < 16; i++ {
+			child, err := decodeRef(items[i])
+			if err != nil {
+				return nil, err
+			}
+			b.children[i] = child
+		}
+		value, ok := items[16].([]byte)
+		if !ok {
+			return nil, errors.New("invalid branch value")
+		}
+		if len(value) > 0 {
+			b.value = value
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("node has %d items", len(items))
+	}
+}
+
+// decodeRef decodes a reference to a child node: empty, a hash, or the child
+// itself if it was embedded.
+func decodeRef(v interface{}) (node, error) {
+	switch r := v.(type) {
+	case []byte:
+		switch len(r) {
+		case 0:
+			return nil, nil
+		case 32:
+			return hashNode(r), nil
+		}
+		return nil, fmt.Errorf("invalid child reference %s", hex.EncodeToString(r))
+	case []interface{}:
+		return decodeValue(r)
+	default:
+		return nil, errors.New("invalid child reference")
+	}
+}
+
+// decodeHexPrefix is the inverse of hexPrefix.
+func decodeHexPrefix(compact []byte) ([]byte, bool, error) {
+	flag := compact[0] >> 4
+	if flag > 3 {
+		return nil, false, fmt.Errorf("invalid hex prefix flag %d", flag)
+	}
+
+	var nibbles []byte
+	if flag&1 == 1 {
+		nibbles = append(nibbles, compact[0]&0x0f)
+	}
+	nibbles = append(nibbles, keyToNibbles(compact[1:])...)
+	return nibbles, flag&2 == 2, nil
+}
@@ -0,0 +1,424 @@
+// Package trie implements the Merkle Patricia trie, which Ethereum uses for
+// the state, storage, transactions and receipts of blocks.
+package trie
+
+import (
+	"bytes"
+	"ethereum/util"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// EmptyRoot is the root hash of a trie with no entries, keccak256(rlp("")).
+var EmptyRoot = crypto.Keccak256(util.EncodeRLPValue([]byte{}))
+
+// Trie is a Merkle Patricia trie.  Its nodes are held in memory, and loaded
+// from a Store as they are needed if the trie was opened from one.  The zero
+// value is an empty trie.
+type Trie struct {
+	root  node
+	store Store
+}
+
+// New returns an empty trie.  As all its nodes are in memory, its operations
+// never fail.
+func New() *Trie {
+	return &Trie{}
+}
+
+// Open returns the trie with the given root hash, whose nodes are in store.
+// Nodes are only loaded when they are needed, so Open doesn't check that the
+// root is in the store.
+func Open(root []byte, store Store) *Trie {
+	t := &Trie{store: store}
+	if len(root) > 0 && !bytes.Equal(root, EmptyRoot) {
+		t.root = hashNode(append([]byte{}, root...))
+	}
+	return t
+}
+
+type node interface{}
+
+// Keys of leaf and extension nodes are stored as nibbles, one per byte.
+type (
+	leafNode struct {
+		key   []byte
+		value []byte
+	}
+	extensionNode struct {
+		key   []byte
+		child node
+	}
+	branchNode struct {
+		children [16]node
+		value    []byte
+	}
+	// hashNode is a node which hasn't been loaded from the store.
+	hashNode []byte
+)
+
+// resolve loads n from the store if it is a hashNode.
+func (t *Trie) resolve(n node) (node, error) {
+	h, ok := n.(hashNode)
+	if !ok {
+		return n, nil
+	}
+	if t.store == nil {
+		return nil, &MissingNodeError{Hash: h}
+	}
+	enc, err := t.store.Get(h)
+	if err != nil || len(enc) == 0 {
+		return nil, &MissingNodeError{Hash: h, Err: err}
+	}
+	return decodeNode(h, enc)
+}
+
+// Get returns the value stored under key, or nil if there is none.
+func (t *Trie) Get(key []byte) ([]byte, error) {
+	n, k := t.root, keyToNibbles(key)
+	for {
+		var err error
+		if n, err = t.resolve(n); err != nil {
+			return nil, err
+		}
+
+		switch nd := n.(type) {
+		case nil:
+			return nil, nil
+		case *leafNode:
+			if !bytes.Equal(nd.key, k) {
+				return nil, nil
+			}
+			return nd.value, nil
+		case *extensionNode:
+			if !bytes.HasPrefix(k, nd.key) {
+				return nil, nil
+			}
+			n, k = nd.child, k[len(nd.key):]
+		case *branchNode:
+			if len(k) == 0 {
+				return nd.value, nil
+			}
+			n, k = nd.children[k[0]], k[1:]
+		}
+	}
+}
+
+// Put stores value under key.  Storing an empty value deletes the key, as
+// the trie does not distinguish it from a missing key.
+func (t *Trie) Put(key, value []byte) error {
+	if len(value) == 0 {
+		return t.Delete(key)
+	}
+	root, err := t.insert(t.root, keyToNibbles(key), value)
+	if err != nil {
+		return err
+	}
+	t.root = root
+	return nil
+}
+
+func (t *Trie) insert(n node, key, value []byte) (node, error) {
+	n, err := t.resolve(n)
+	if err != nil {
+		return nil, err
+	}
+
+	switch nd := n.(type) {
+	case nil:
+		return &leafNode{key: key, value: value}, nil
+	case *leafNode:
+		if bytes.Equal(nd.key, key) {
+			return &leafNode{key: key, value: value}, nil
+		}
+
+		// Split the leaf into a branch, behind an extension holding the shared
+		// part of the keys.
+		p := prefixLen(nd.key, key)
+		b := &branchNode{}
+		b.insertLeaf(nd.key[p:], nd.value)
+		b.insertLeaf(key[p:], value)
+		return withExtension(key[:p], b), nil
+	case *extensionNode:
+		p := prefixLen(nd.key, key)
+		if p == len(nd.key) {
+			child, err := t.insert(nd.child, key[p:], value)
+			if err != nil {
+				return nil, err
+			}
+			return &extensionNode{key: nd.key, child: child}, nil
+		}
+
+		// The key diverges inside the extension, so split it around a branch.
+		b := &branchNode{}
+		b.children[nd.key[p]] = withExtension(nd.key[p+1:], nd.child)
+		b.insertLeaf(key[p:], value)
+		return withExtension(key[:p], b), nil
+	case *branchNode:
+		b := *nd
+		if len(key) == 0 {
+			b.value = value
+			return &b, nil
+		}
+		child, err := t.insert(b.children[key[0]], key[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		b.children[key[0]] = child
+		return &b, nil
+	default:
+		panic("unknown node type")
+	}
+}
+
+// insertLeaf adds a value to a branch which does not yet hold key.
+func (b *branchNode) insertLeaf(key, value []byte) {
+	if len(key) == 0 {
+		b.value = value
+		return
+	}
+	b.children[key[0]] = &leafNode{key: key[1:], value: value}
+}
+
+// withExtension places n behind an extension node for key, unless key is
+// empty.
+func withExtension(key []byte, n node) node {
+	if len(key) == 0 {
+		return n
+	}
+	return &extensionNode{key: key, child: n}
+}
+
+// Delete removes key from the trie.  Deleting a missing key does nothing.
+func (t *Trie) Delete(key []byte) error {
+	root, err := t.remove(t.root, keyToNibbles(key))
+	if err != nil {
+		return err
+	}
+	t.root = root
+	return nil
+}
+
+// remove returns n without key.  Nodes left with a single child are merged
+// with it, so that the trie has the same shape, and hash, as if key had
+// never been inserted.
+func (t *Trie) remove(n node, key []byte) (node, error) {
+	n, err := t.resolve(n)
+	if err != nil {
+		return nil, err
+	}
+
+	switch nd := n.(type) {
+	case nil:
+		return nil, nil
+	case *leafNode:
+		if bytes.Equal(nd.key, key) {
+			return nil, nil
+		}
+		return nd, nil
+	case *extensionNode:
+		if !bytes.HasPrefix(key, nd.key) {
+			return nd, nil
+		}
+		child, err := t.remove(nd.child, key[len(nd.key):])
+		if err != nil {
+			return nil, err
+		}
+		return t.prefix(nd.key, child)
+	case *branchNode:
+		b := *nd
+		if len(key) == 0 {
+			b.value = nil
+		} else {
+			child, err := t.remove(b.children[key[0]], key[1:])
+			if err != nil {
+				return nil, err
+			}
+			b.children[key[0]] = child
+		}
+
+		only, count := -1, 0
+		for i, c := range b.children {
+			if c != nil {
+				only, count = i, count+1
+			}
+		}
+		switch {
+		case count == 0 && b.value == nil:
+			return nil, nil
+		case count == 0:
+			return &leafNode{key: []byte{}, value: b.value}, nil
+		case count == 1 && b.value == nil:
+			return t.prefix([]byte{byte(only)}, b.children[only])
+		}
+		return &b, nil
+	default:
+		panic("unknown node type")
+	}
+}
+
+// prefix returns n under key: merged into it if it is a leaf or extension,
+// or behind an extension if it is a branch.
+func (t *Trie) prefix(key []byte, n node) (node, error) {
+	n, err := t.resolve(n)
+	if err != nil {
+		return nil, err
+	}
+
+	switch nd := n.(type) {
+	case nil:
+		return nil, nil
+	case *leafNode:
+		return &leafNode{key: concat(key, nd.key), value: nd.value}, nil
+	case *extensionNode:
+		return &extensionNode{key: concat(key, nd.key), child: nd.child}, nil
+	default:
+		return withExtension(key, n), nil
+	}
+}
+
+// Hash returns the keccak256 root hash of the trie.
+func (t *Trie) Hash() []byte {
+	switch r := t.root.(type) {
+	case nil:
+		return EmptyRoot
+	case hashNode:
+		return append([]byte{}, r...)
+	}
+	// Without a store, encoding can't fail.
+	enc, _ := encodeNode(t.root, nil)
+	return crypto.Keccak256(enc)
+}
+
+// Commit writes the nodes of the trie which are referred to by hash, and the
+// root node, to store, and returns the root hash.  Nodes which haven't been
+// loaded are assumed to be in store already.
+func (t *Trie) Commit(store Store) ([]byte, error) {
+	switch r := t.root.(type) {
+	case nil:
+		return EmptyRoot, nil
+	case hashNode:
+		return append([]byte{}, r...), nil
+	}
+
+	enc, err := encodeNode(t.root, store)
+	if err != nil {
+		return nil, err
+	}
+	h := crypto.Keccak256(enc)
+	if err := store.Put(h, enc); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// encodeNode returns the RLP encoding of a node.  If store isn't nil, the
+// descendants of the node which are referred to by hash are written to it.
+func encodeNode(n node, store Store) ([]byte, error) {
+	switch nd := n.(type) {
+	case *leafNode:
+		return util.EncodeRLPValue([]interface{}{hexPrefix(nd.key, true), nd.value}), nil
+	case *extensionNode:
+		ref, err := reference(nd.child, store)
+		if err != nil {
+			return nil, err
+		}
+		return util.EncodeRLPValue([]interface{}{hexPrefix(nd.key, false), ref}), nil
+	case *branchNode:
+		items := make([]interface{}, 17)
+		for i, c := range nd.children {
+			ref, err := reference(c, store)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = ref
+		}
+		items[16] = nd.value
+		if nd.value == nil {
+			items[16] = []byte{}
+		}
+		return util.EncodeRLPValue(items), nil
+	default:
+		panic("unknown node type")
+	}
+}
+
+// reference returns how a parent refers to a child node: nodes whose encoding
+// is shorter than 32 bytes are embedded, others are referred to by hash, and
+// written to store if it isn't nil.
+func reference(n node, store Store) (interface{}, error) {
+	switch nd := n.(type) {
+	case nil:
+		return []byte{}, nil
+	case hashNode:
+		return []byte(nd), nil
+	}
+
+	enc, err := encodeNode(n, store)
+	if err != nil {
+		return nil, err
+	}
+	if len(enc) < 32 {
+		return util.RawRLP(enc), nil
+	}
+	h := crypto.Keccak256(enc)
+	if store != nil {
+		if err := store.Put(h, enc); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// hexPrefix encodes nibbles into bytes, with a flag marking whether the
+// nibble count is odd and whether the node is a leaf.
+func hexPrefix(nibbles []byte, leaf bool) []byte {
+	var flag byte
+	if leaf {
+		flag = 2
+	}
+
+	var out []byte
+	if len(nibbles)%2 == 1 {
+		out = []byte{(flag+1)<<4 | nibbles[0]}
+		nibbles = nibbles[1:]
+	} else {
+		out = []byte{flag << 4}
+	}
+
+	for i := 0; i < len(nibbles); i += 2 {
+		out = append(out, nibbles[i]<<4|nibbles[i+1])
+	}
+	return out
+}
+
+func keyToNibbles(key []byte) []byte {
+	n := make([]byte, len(key)*2)
+	for i, b := range key {
+		n[i*2] = b >> 4
+		n[i*2+1] = b & 0x0f
+	}
+	return n
+}
+
+// nibblesToKey is the inverse of keyToNibbles.  A trailing odd nibble is
+// dropped.
+func nibblesToKey(nibbles []byte) []byte {
+	key := make([]byte, len(nibbles)/2)
+	for i := range key {
+		key[i] = nibbles[i*2]<<4 | nibbles[i*2+1]
+	}
+	return key
+}
+
+func prefixLen(a, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+func concat(a, b []byte) []byte {
+	return append(append(make([]byte, 0, len(a)+len(b)), a...), b...)
+}
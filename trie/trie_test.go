@@ -0,0 +1,342 @@
+package trie
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	// From https://github.com/ethereum/tests/blob/develop/TrieTests/trieanyorder.json
+	var tests = []struct {
+		name string
+		in   [][2]string
+		root string
+	}{
+		{
+			name: "empty",
+			root: "56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421",
+		},
+		{
+			name: "dogs",
+			in:   [][2]string{{"doe", "reindeer"}, {"dog", "puppy"}, {"dogglesworth", "cat"}},
+			root: "8aad789dff2f538bca5d8ea56e8abe10f4c7ba3a5dea95fea4cd6e7c3a1168d3",
+		},
+		{
+			name: "puppy",
+			in:   [][2]string{{"do", "verb"}, {"horse", "stallion"}, {"doge", "coin"}, {"dog", "puppy"}},
+			root: "5991bb8c6514148a29db676a14ac506cd2cd5775ace63c30a4fe457715e9ac84",
+		},
+		{
+			name: "foo",
+			in:   [][2]string{{"foo", "bar"}, {"food", "bass"}},
+			root: "17beaa1648bafa633cda809c90c04af50fc8aed3cb40d16efbddee6fdf63c4c3",
+		},
+		{
+			name: "smallValues",
+			in:   [][2]string{{"be", "e"}, {"dog", "puppy"}, {"bed", "d"}},
+			root: "3f67c7a47520f79faa29255d2d3c084a7a6df0453116ed7232ff10277a8be68b",
+		},
+		{
+			name: "testy",
+			in:   [][2]string{{"test", "test"}, {"te", "testy"}},
+			root: "8452568af70d8d140f58d941338542f645fcca50094b20f3c3d8c3df49337928",
+		},
+	}
+
+	for _, test := range tests {
+		// Insertion order must not matter.
+		for _, reverse := range []bool{false, true} {
+			tr := New()
+			for i := range test.in {
+				kv := test.in[i]
+				if reverse {
+					kv = test.in[len(test.in)-1-i]
+				}
+				if err := tr.Put([]byte(kv[0]), []byte(kv[1])); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if root := hex.EncodeToString(tr.Hash()); root != test.root {
+				t.Fatalf("%s: Expected: %s, received: %s", test.name, test.root, root)
+			}
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	tr := newTestTrie(t, "do", "verb", "dog", "puppy", "doge", "coin", "horse", "stallion", "dog", "hound")
+
+	var tests = []struct {
+		key, value string
+	}{
+		{"do", "verb"},
+		{"dog", "hound"},
+		{"doge", "coin"},
+		{"horse", "stallion"},
+		{"d", ""},
+		{"dogs", ""},
+		{"cat", ""},
+	}
+
+	for _, test := range tests {
+		v, err := tr.Get([]byte(test.key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(v, []byte(test.value)) {
+			t.Fatalf("%s: Expected: %q, received: %q", test.key, test.value, v)
+		}
+	}
+}
+
+// newTestTrie returns a trie of the given keys and values.
+func newTestTrie(t *testing.T, kv ...string) *Trie {
+	tr := New()
+	for i := 0; i < len(kv); i += 2 {
+		if err := tr.Put([]byte(kv[i]), []byte(kv[i+1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tr
+}
+
+// decodeTestString decodes a key or value of the trie tests, which is hex if
+// it has a 0x prefix.
+func decodeTestString(t *testing.T, s string) []byte {
+	if !strings.HasPrefix(s, "0x") {
+		return []byte(s)
+	}
+	b, err := hex.DecodeString(s[2:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestTrieTests(t *testing.T) {
+	// test_data/trietest.json is from
+	// https://github.com/ethereum/tests/blob/develop/TrieTests/trietest.json
+	// where entries are applied in order, and null values delete keys.
+	b, err := ioutil.ReadFile("test_data/trietest.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests map[string]struct {
+		In   [][2]*string
+		Root string
+	}
+	if err := json.Unmarshal(b, &tests); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, test := range tests {
+		tr := New()
+		for _, kv := range test.In {
+			key := decodeTestString(t, *kv[0])
+			var err error
+			if kv[1] == nil {
+				err = tr.Delete(key)
+			} else {
+				err = tr.Put(key, decodeTestString(t, *kv[1]))
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if root := "0x" + hex.EncodeToString(tr.Hash()); root != test.Root {
+			t.Errorf("%s: Expected: %s, received: %s", name, test.Root, root)
+		}
+	}
+}
+
+func TestDelete(t *testing.T) {
+	kv := []string{"do", "verb", "dog", "puppy", "doge", "coin", "horse", "stallion", "dogglesworth", "cat",
+		"A", strings.Repeat("a", 50)}
+	tr := newTestTrie(t, kv...)
+
+	// Deleting a key leaves the trie as if it had never been inserted.
+	for i := 0; i < len(kv); i += 2 {
+		if err := tr.Delete([]byte(kv[i])); err != nil {
+			t.Fatal(err)
+		}
+		if err := tr.Delete([]byte("missing")); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := newTestTrie(t, kv[i+2:]...).Hash()
+		if h := tr.Hash(); !bytes.Equal(h, expected) {
+			t.Fatalf("%s: Expected: %x, received: %x", kv[i], expected, h)
+		}
+	}
+
+	if h := tr.Hash(); !bytes.Equal(h, EmptyRoot) {
+		t.Errorf("Expected: %x, received: %x", EmptyRoot, h)
+	}
+}
+
+func TestCommit(t *testing.T) {
+	tr := newTestTrie(t, "do", "verb", "dog", "puppy", "doge", "coin", "horse", "stallion",
+		"A", strings.Repeat("a", 50))
+	store := NewMemoryStore()
+	root, err := tr.Commit(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(root, tr.Hash()) {
+		t.Fatalf("Expected: %x, received: %x", tr.Hash(), root)
+	}
+
+	opened := Open(root, store)
+	for _, key := range []string{"do", "dog", "doge", "horse", "A", "cat"} {
+		expected, _ := tr.Get([]byte(key))
+		v, err := opened.Get([]byte(key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(v, expected) {
+			t.Errorf("%s: Expected: %q, received: %q", key, expected, v)
+		}
+	}
+
+	// Changes to the opened trie load the nodes they need.
+	for _, tt := range []*Trie{tr, opened} {
+		if err := tt.Delete([]byte("doge")); err != nil {
+			t.Fatal(err)
+		}
+		if err := tt.Put([]byte("dodo"), []byte("bird")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if h := opened.Hash(); !bytes.Equal(h, tr.Hash()) {
+		t.Errorf("Expected: %x, received: %x", tr.Hash(), h)
+	}
+
+	_, err = Open(root, NewMemoryStore()).Get([]byte("dog"))
+	var missing *MissingNodeError
+	if !errors.As(err, &missing) || !bytes.Equal(missing.Hash, root) || missing.Err != ErrNotFound {
+		t.Errorf("Expected: missing node %x, received: %v", root, err)
+	}
+}
+
+func TestNodeIterator(t *testing.T) {
+	kv := map[string]string{"do": "verb", "dog": "puppy", "doge": "coin", "horse": "stallion",
+		"A": strings.Repeat("a", 50)}
+	tr := New()
+	var keys []string
+	for k, v := range kv {
+		if err := tr.Put([]byte(k), []byte(v)); err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	store := NewMemoryStore()
+	root, err := tr.Commit(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []*Trie{tr, Open(root, store)} {
+		var leaves []string
+		hashed := 0
+		it := tt.NodeIterator()
+		for it.Next() {
+			if len(it.Path()) == 0 && !bytes.Equal(it.Hash(), root) {
+				t.Errorf("Expected: %x, received: %x", root, it.Hash())
+			}
+			if it.Hash() != nil {
+				if _, ok := store[string(it.Hash())]; !ok {
+					t.Errorf("Node %x not committed", it.Hash())
+				}
+				hashed++
+			}
+			if it.Leaf() {
+				if v := string(it.LeafValue()); v != kv[string(it.LeafKey())] {
+					t.Errorf("%s: Expected: %s, received: %s", it.LeafKey(), kv[string(it.LeafKey())], v)
+				}
+				leaves = append(leaves, string(it.LeafKey()))
+			}
+		}
+		if err := it.Err(); err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Join(leaves, ",") != strings.Join(keys, ",") {
+			t.Errorf("Expected: %v, received: %v", keys, leaves)
+		}
+		if hashed != len(store) {
+			t.Errorf("Expected: %d, received: %d", len(store), hashed)
+		}
+	}
+
+	it := Open(root, NewMemoryStore()).NodeIterator()
+	if it.Next() || it.Err() == nil {
+		t.Errorf("Expected: missing node error, received: %v", it.Err())
+	}
+	it.Skip()
+	if it.Next() || it.Err() != nil {
+		t.Errorf("Expected: no nodes after the root, received: %v", it.Err())
+	}
+}
+
+func TestNodeIteratorSkip(t *testing.T) {
+	kv := map[string]string{"do": "verb", "dog": "puppy", "doge": "coin", "horse": "stallion",
+		"A": strings.Repeat("a", 50)}
+	tr := New()
+	for k, v := range kv {
+		if err := tr.Put([]byte(k), []byte(v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store := NewMemoryStore()
+	root, err := tr.Commit(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The leaf of "A" is too long to be embedded in its parent, so it is a
+	// node of its own in the store.
+	stored := len(store)
+	it := tr.NodeIterator()
+	for it.Next() {
+		if it.Leaf() && string(it.LeafKey()) == "A" {
+			delete(store, string(it.Hash()))
+		}
+	}
+	if len(store) != stored-1 {
+		t.Fatal("Expected the leaf of A to be a node of its own")
+	}
+
+	var leaves []string
+	var errs []error
+	it = Open(root, store).NodeIterator()
+	for {
+		for it.Next() {
+			if it.Leaf() {
+				leaves = append(leaves, string(it.LeafKey()))
+			}
+		}
+		if it.Err() == nil {
+			break
+		}
+		errs = append(errs, it.Err())
+		it.Skip()
+	}
+
+	if len(errs) != 1 {
+		t.Errorf("Expected: 1 missing node error, received: %v", errs)
+	}
+	expected := []string{"do", "dog", "doge", "horse"}
+	if strings.Join(leaves, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected: %v, received: %v", expected, leaves)
+	}
+}
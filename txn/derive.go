@@ -0,0 +1,41 @@
+package txn
+
+import (
+	"encoding/hex"
+	"ethereum/trie"
+	"ethereum/util"
+)
+
+// DeriveTransactionsRoot computes the root of the trie of the given
+// transactions, which must be in block order.  The result can be compared
+// against a block's transactionsRoot.
+func DeriveTransactionsRoot(txs []BlockTransaction) (string, error) {
+	t := trie.New()
+	for i, tx := range txs {
+		enc, err := tx.Encode()
+		if err != nil {
+			return "", err
+		}
+		t.Put(indexKey(i), enc)
+	}
+
+	return "0x" + hex.EncodeToString(t.Hash()), nil
+}
+
+// DeriveReceiptsRoot computes the root of the trie of the given receipts,
+// which must be in block order.  The result can be compared against a
+// block's receiptsRoot.
+func DeriveReceiptsRoot(receipts []TransactionReceipt) string {
+	t := trie.New()
+	for i, r := range receipts {
+		t.Put(indexKey(i), r.Encode())
+	}
+
+	return "0x" + hex.EncodeToString(t.Hash())
+}
+
+// indexKey returns the trie key of the item at index i in a block, which is
+// the RLP encoding of the index.
+func indexKey(i int) []byte {
+	return util.EncodeRLPValue(util.IntToArr(uint64(i)))
+}
@@ -0,0 +1,236 @@
+package txn
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"ethereum/util"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func legacyBlockTransaction() BlockTransaction {
+	return BlockTransaction{
+		Gas:      big.NewInt(21000),
+		GasPrice: big.NewInt(2e10),
+		Hash:     "0xd866f3672a3cef05f66dec56d30562bbffcc42aa11b54450e6973d52c89d1719",
+		Input:    []byte{},
+		Nonce:    1,
+		To:       "0x2c65492bb820552334ba59b4fbb626f35a95e566",
+		Value:    util.EthToWei(25),
+		V:        28,
+		R:        hexToBigInt("2083a43ac72ca892e22e805003926850a0da13d9aeb0ef1c4405de35a67d8447"),
+		S:        hexToBigInt("6502a37bd5cd629128dd889ee8916acd8d2193f1301c00d9064cbada640a9b58"),
+	}
+}
+
+func TestBlockTransactionEncode(t *testing.T) {
+	// The hash of a legacy transaction's encoding is its transaction hash.
+	bt := legacyBlockTransaction()
+	enc, err := bt.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := "0x" + hex.EncodeToString(crypto.Keccak256(enc)); h != bt.Hash {
+		t.Fatalf("Expected: %s, received: %s", bt.Hash, h)
+	}
+
+	var tests = []struct {
+		txType uint64
+		fields int
+	}{
+		{AccessListTxType, 11},
+		{DynamicFeeTxType, 12},
+		{BlobTxType, 14},
+	}
+
+	for _, test := range tests {
+		typed := legacyBlockTransaction()
+		typed.Type = test.txType
+		typed.ChainID = big.NewInt(1)
+		typed.V = 1
+		typed.MaxPriorityFeePerGas = big.NewInt(1e9)
+		typed.MaxFeePerGas = big.NewInt(3e10)
+		typed.MaxFeePerBlobGas = big.NewInt(1)
+		typed.AccessList = []AccessTuple{{
+			Address:     "0x2c65492bb820552334ba59b4fbb626f35a95e566",
+			StorageKeys: []string{"0x" + strings.Repeat("00", 32)},
+		}}
+		typed.BlobVersionedHashes = []string{"0x01" + strings.Repeat("ab", 31)}
+
+		enc, err := typed.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if enc[0] != byte(test.txType) {
+			t.Fatalf("Expected: type prefix %d, received: %d", test.txType, enc[0])
+		}
+
+		d, err := util.DecodeRLP(bytes.NewBuffer(enc[1:]))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if l := len(d.([]interface{})); l != test.fields {
+			t.Fatalf("Expected: %d fields for type %d, received: %d", test.fields, test.txType, l)
+		}
+	}
+
+	unknown := legacyBlockTransaction()
+	unknown.Type = 0x7f
+	if _, err := unknown.Encode(); err == nil {
+		t.Fatal("Expected error for unknown transaction type")
+	}
+}
+
+// decodeBlockTransaction decodes the consensus encoding of a legacy or
+// dynamic fee transaction.
+func decodeBlockTransaction(t *testing.T, raw []byte) BlockTransaction {
+	if raw[0] >= 0xc0 {
+		tx, err := Decode(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return BlockTransaction{Nonce: tx.Nonce, GasPrice: tx.GasPrice, Gas: tx.GasLimit, To: tx.To,
+			Value: tx.Value, Input: tx.Data, V: tx.V, R: tx.R, S: tx.S}
+	}
+	if raw[0] != DynamicFeeTxType {
+		t.Fatalf("Unexpected transaction type %d", raw[0])
+	}
+
+	d, err := util.DecodeRLP(bytes.NewBuffer(raw[1:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := d.([]interface{})
+	b := func(i int) []byte { return f[i].([]byte) }
+	n := func(i int) *big.Int { return new(big.Int).SetBytes(b(i)) }
+
+	bt := BlockTransaction{Type: DynamicFeeTxType, ChainID: n(0), Nonce: n(1).Uint64(),
+		MaxPriorityFeePerGas: n(2), MaxFeePerGas: n(3), Gas: n(4), Value: n(6), Input: b(7),
+		V: int(n(9).Int64()), R: n(10), S: n(11)}
+	if len(b(5)) > 0 {
+		bt.To = "0x" + hex.EncodeToString(b(5))
+	}
+	for _, tuple := range f[8].([]interface{}) {
+		tuple := tuple.([]interface{})
+		at := AccessTuple{Address: "0x" + hex.EncodeToString(tuple[0].([]byte))}
+		for _, k := range tuple[1].([]interface{}) {
+			at.StorageKeys = append(at.StorageKeys, "0x"+hex.EncodeToString(k.([]byte)))
+		}
+		bt.AccessList = append(bt.AccessList, at)
+	}
+	return bt
+}
+
+func TestDeriveTransactionsRoot(t *testing.T) {
+	root, err := DeriveTransactionsRoot(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421" {
+		t.Fatalf("Expected empty root, received: %s", root)
+	}
+
+	// test_data/block_18189758.json holds the transactions of mainnet block
+	// 18189758, from the execution payload in go-ethereum's
+	// beacon/types/testdata/block_capella.json, and the transactions root of
+	// its header.
+	b, err := ioutil.ReadFile("test_data/block_18189758.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var blk struct {
+		TransactionsRoot string
+		Transactions     []string
+	}
+	if err := json.Unmarshal(b, &blk); err != nil {
+		t.Fatal(err)
+	}
+
+	var txs []BlockTransaction
+	for _, raw := range blk.Transactions {
+		bt := decodeBlockTransaction(t, hexToBytes(raw))
+		if enc, err := bt.Encode(); err != nil || "0x"+hex.EncodeToString(enc) != raw {
+			t.Fatalf("Expected: %s, received: %x (%v)", raw, enc, err)
+		}
+		txs = append(txs, bt)
+	}
+
+	root, err = DeriveTransactionsRoot(txs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != blk.TransactionsRoot {
+		t.Fatalf("Expected: %s, received: %s", blk.TransactionsRoot, root)
+	}
+
+	// Order matters.
+	txs[0], txs[1] = txs[1], txs[0]
+	if swapped, _ := DeriveTransactionsRoot(txs); swapped == root {
+		t.Fatal("Expected reordering to change the root")
+	}
+}
+
+func TestDeriveReceiptsRoot(t *testing.T) {
+	// test_data/receipts.json holds receipts and their root from the
+	// transition tool tests of go-ethereum's cmd/evm/testdata, and receipts
+	// with logs whose root was derived by go-ethereum's types.DeriveSha.
+	b, err := ioutil.ReadFile("test_data/receipts.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests map[string]struct {
+		Receipts []struct {
+			Type              string
+			Root              string
+			Status            string
+			CumulativeGasUsed string
+			LogsBloom         string
+			Logs              []struct {
+				Address string
+				Topics  []string
+				Data    string
+			}
+		}
+		Root string
+	}
+	if err := json.Unmarshal(b, &tests); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, test := range tests {
+		var receipts []TransactionReceipt
+		for _, r := range test.Receipts {
+			receipt := TransactionReceipt{
+				CumulativeGasUsed: hexToBigInt(strings.TrimPrefix(r.CumulativeGasUsed, "0x")),
+				LogsBloom:         r.LogsBloom,
+				Status:            hexToBigInt(strings.TrimPrefix(r.Status, "0x")).Uint64(),
+				Logs:              []Log{},
+			}
+			if r.Type != "" {
+				receipt.Type = hexToBigInt(strings.TrimPrefix(r.Type, "0x")).Uint64()
+			}
+			if r.Root != "0x" {
+				receipt.Root = r.Root
+			}
+			for _, l := range r.Logs {
+				receipt.Logs = append(receipt.Logs, Log{Address: l.Address, Topics: l.Topics, Data: hexToBytes(l.Data)})
+			}
+			receipts = append(receipts, receipt)
+		}
+
+		if root := DeriveReceiptsRoot(receipts); root != test.Root {
+			t.Errorf("%s: Expected: %s, received: %s", name, test.Root, root)
+		}
+	}
+
+	if root := DeriveReceiptsRoot(nil); root != "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421" {
+		t.Fatalf("Expected empty root, received: %s", root)
+	}
+}
@@ -0,0 +1,18 @@
+package txn
+
+import "ethereum/accnt"
+
+// Signer signs transactions sent from its account.
+type Signer interface {
+	Address() accnt.Address
+	SignTransaction(t *Transaction) error
+}
+
+// KeySigner signs transactions with a private key.
+type KeySigner struct {
+	accnt.Private
+}
+
+func (k KeySigner) SignTransaction(t *Transaction) error {
+	return t.Sign(k.Private)
+}
@@ -163,8 +163,17 @@ func (t Transaction) Encode() []byte {
 	})
 }
 
+// Transaction types introduced by EIP-2718.
+const (
+	LegacyTxType     = 0x00
+	AccessListTxType = 0x01 // EIP-2930
+	DynamicFeeTxType = 0x02 // EIP-1559
+	BlobTxType       = 0x03 // EIP-4844
+)
+
 // BlockTransaction is a representation of a transaction saved on the
-// blockchain.
+// blockchain.  Fields which only exist for typed transactions are left at
+// their zero value for legacy transactions.
 type BlockTransaction struct {
 	BlockHash        string
 	BlockNumber      uint64
@@ -177,9 +186,88 @@ type BlockTransaction struct {
 	To               string
 	TransactionIndex uint64
 	Value            *big.Int
-	V                int
+	V                int // yParity for typed transactions
 	R                *big.Int
 	S                *big.Int
+
+	Type                 uint64
+	ChainID              *big.Int
+	AccessList           []AccessTuple
+	MaxPriorityFeePerGas *big.Int
+	MaxFeePerGas         *big.Int
+	MaxFeePerBlobGas     *big.Int
+	BlobVersionedHashes  []string
+}
+
+// AccessTuple is an entry of an EIP-2930 access list.
+type AccessTuple struct {
+	Address     string
+	StorageKeys []string
+}
+
+// Encode returns the consensus encoding of the transaction: the RLP list for
+// legacy transactions, and the type byte followed by the RLP payload for
+// typed transactions.
+func (bt BlockTransaction) Encode() ([]byte, error) {
+	common := func(fields ...interface{}) []interface{} {
+		return append(fields,
+			bigIntBytes(bt.Gas),
+			hexToBytes(bt.To),
+			bigIntBytes(bt.Value),
+			bt.Input,
+			accessList(bt.AccessList),
+		)
+	}
+	sig := []interface{}{
+		util.IntToArr(uint64(bt.V)),
+		bigIntBytes(bt.R),
+		bigIntBytes(bt.S),
+	}
+
+	var fields []interface{}
+	switch bt.Type {
+	case LegacyTxType:
+		return util.EncodeRLP([][]byte{
+			util.IntToArr(bt.Nonce),
+			bigIntBytes(bt.GasPrice),
+			bigIntBytes(bt.Gas),
+			hexToBytes(bt.To),
+			bigIntBytes(bt.Value),
+			bt.Input,
+			util.IntToArr(uint64(bt.V)),
+			bigIntBytes(bt.R),
+			bigIntBytes(bt.S),
+		}), nil
+	case AccessListTxType:
+		fields = common(bigIntBytes(bt.ChainID), util.IntToArr(bt.Nonce), bigIntBytes(bt.GasPrice))
+	case DynamicFeeTxType:
+		fields = common(bigIntBytes(bt.ChainID), util.IntToArr(bt.Nonce), bigIntBytes(bt.MaxPriorityFeePerGas),
+			bigIntBytes(bt.MaxFeePerGas))
+	case BlobTxType:
+		fields = common(bigIntBytes(bt.ChainID), util.IntToArr(bt.Nonce), bigIntBytes(bt.MaxPriorityFeePerGas),
+			bigIntBytes(bt.MaxFeePerGas))
+		hashes := make([]interface{}, len(bt.BlobVersionedHashes))
+		for i, h := range bt.BlobVersionedHashes {
+			hashes[i] = hexToBytes(h)
+		}
+		fields = append(fields, bigIntBytes(bt.MaxFeePerBlobGas), hashes)
+	default:
+		return nil, fmt.Errorf("unsupported transaction type %d", bt.Type)
+	}
+
+	return append([]byte{byte(bt.Type)}, util.EncodeRLPValue(append(fields, sig...))...), nil
+}
+
+func accessList(al []AccessTuple) []interface{} {
+	out := make([]interface{}, len(al))
+	for i, t := range al {
+		keys := make([]interface{}, len(t.StorageKeys))
+		for j, k := range t.StorageKeys {
+			keys[j] = hexToBytes(k)
+		}
+		out[i] = []interface{}{hexToBytes(t.Address), keys}
+	}
+	return out
 }
 
 // ContractAddress should be gotten from transaction receipt...this is just a
@@ -209,12 +297,65 @@ type TransactionReceipt struct {
 	CumulativeGasUsed *big.Int
 	From              string
 	GasUsed           *big.Int
-	Logs              []string
+	Logs              []Log
 	LogsBloom         string
-	Root              string
+	Root              string // pre-Byzantium receipts only
+	Status            uint64 // 1 for success, 0 for failure
 	To                string
 	TransactionHash   string
 	TransactionIndex  uint64
+	Type              uint64
+}
+
+// Succeeded reports whether the transaction of the receipt succeeded.
+// Receipts of blocks before Byzantium have no status, so their transactions
+// are assumed to have succeeded.
+func (r TransactionReceipt) Succeeded() bool {
+	return r.Root != "" || r.Status == 1
+}
+
+// Encode returns the consensus encoding of the receipt, which is prefixed by
+// the transaction type for typed transactions.
+func (r TransactionReceipt) Encode() []byte {
+	// Before Byzantium, receipts contained the intermediate state root rather
+	// than a status.
+	status := hexToBytes(r.Root)
+	if r.Root == "" {
+		status = util.IntToArr(r.Status)
+	}
+
+	logs := make([]interface{}, len(r.Logs))
+	for i, l := range r.Logs {
+		topics := make([]interface{}, len(l.Topics))
+		for j, t := range l.Topics {
+			topics[j] = hexToBytes(t)
+		}
+		logs[i] = []interface{}{hexToBytes(l.Address), topics, l.Data}
+	}
+
+	enc := util.EncodeRLPValue([]interface{}{
+		status,
+		bigIntBytes(r.CumulativeGasUsed),
+		hexToBytes(r.LogsBloom),
+		logs,
+	})
+	if r.Type == LegacyTxType {
+		return enc
+	}
+	return append([]byte{byte(r.Type)}, enc...)
+}
+
+// Log is an event emitted by a contract during a transaction.
+type Log struct {
+	Address          string
+	Topics           []string
+	Data             []byte
+	BlockNumber      uint64
+	BlockHash        string
+	TransactionHash  string
+	TransactionIndex uint64
+	LogIndex         uint64
+	Removed          bool // true if the log was reverted by a chain reorganisation
 }
 
 func bigIntBytes(in *big.Int) []byte {
@@ -223,3 +364,16 @@ func bigIntBytes(in *big.Int) []byte {
 	}
 	return in.Bytes()
 }
+
+// hexToBytes decodes a `0xHEX` formatted string, returning an empty slice for
+// empty or malformed input.
+func hexToBytes(h string) []byte {
+	if len(h) < 2 {
+		return []byte{}
+	}
+	b, err := hex.DecodeString(h[2:])
+	if err != nil {
+		return []byte{}
+	}
+	return b
+}
@@ -1,6 +1,7 @@
 package txn
 
 import (
+	"bytes"
 	"encoding/hex"
 	"ethereum/accnt"
 	"ethereum/util"
@@ -139,7 +140,7 @@ func TestSender(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		if s != test.sender {
+		if s.String() != test.sender {
 			t.Fatalf("Expected: %s, received: %s", test.sender, s)
 		}
 	}
@@ -171,7 +172,7 @@ func TestSign(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if sender != priv.Address() {
+	if !bytes.Equal(sender, priv.Address()) {
 		t.Fatalf("Expected: %s, received: %s", priv.Address(), sender)
 	}
 }
@@ -203,3 +204,21 @@ func TestHash(t *testing.T) {
 		}
 	}
 }
+
+func TestReceiptSucceeded(t *testing.T) {
+	var tests = []struct {
+		receipt  TransactionReceipt
+		expected bool
+	}{
+		{TransactionReceipt{Status: 1}, true},
+		{TransactionReceipt{Status: 0}, false},
+		// Receipts before Byzantium have a state root and no status.
+		{TransactionReceipt{Root: "0xd7f8974fb5ac78d9ac099b9ad5018bedc2ce0a72dad1827a1709da30580f0544"}, true},
+	}
+
+	for _, test := range tests {
+		if s := test.receipt.Succeeded(); s != test.expected {
+			t.Fatalf("Expected: %t, received: %t", test.expected, s)
+		}
+	}
+}
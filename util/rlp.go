@@ -2,6 +2,7 @@ package util
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 )
 
@@ -72,37 +73,67 @@ func DecodeRLP(in io.Reader) (interface{}, error) {
 	}
 }
 
+// EncodeRLP encodes a list of byte strings.
 func EncodeRLP(in [][]byte) []byte {
 	out := bytes.NewBuffer([]byte{})
-
 	for _, arr := range in {
-		h := len(arr)
-		switch {
-		case h == 1 && arr[0] < 0x80:
-			_, _ = out.Write([]byte{arr[0]})
-		case h == 1:
-			out.Write([]byte{0x81, arr[0]})
-		case h <= 55:
-			o := append([]byte{0x80 + byte(h)}, arr...)
-			_, _ = out.Write(o)
-		default:
-			// when string length is >55 bytes
-			// write 0xf7+length of length of string
-			// write length of string
-			// write string
-			l := IntToArr(uint64(h))
-			_ = out.WriteByte(byte(0xb7 + len(l)))
-			_, _ = out.Write(l)
-			_, _ = out.Write(arr)
+		_, _ = out.Write(encodeRLPString(arr))
+	}
+
+	return encodeRLPList(out.Bytes())
+}
+
+// RawRLP is already RLP encoded data, which EncodeRLPValue writes verbatim.
+type RawRLP []byte
+
+// EncodeRLPValue encodes a nested value, the inverse of DecodeRLP.  in must be
+// a []byte, which is encoded as a string, a RawRLP, or a [][]byte or
+// []interface{} list of such values.
+func EncodeRLPValue(in interface{}) []byte {
+	switch v := in.(type) {
+	case []byte:
+		return encodeRLPString(v)
+	case RawRLP:
+		return v
+	case [][]byte:
+		return EncodeRLP(v)
+	case []interface{}:
+		out := bytes.NewBuffer([]byte{})
+		for _, item := range v {
+			_, _ = out.Write(EncodeRLPValue(item))
 		}
+		return encodeRLPList(out.Bytes())
+	default:
+		panic(fmt.Sprintf("cannot rlp encode %T", in))
 	}
+}
+
+func encodeRLPString(arr []byte) []byte {
+	h := len(arr)
+	switch {
+	case h == 1 && arr[0] < 0x80:
+		return []byte{arr[0]}
+	case h <= 55:
+		return append([]byte{0x80 + byte(h)}, arr...)
+	default:
+		// when string length is >55 bytes
+		// write 0xb7+length of length of string
+		// write length of string
+		// write string
+		l := IntToArr(uint64(h))
+		o := append([]byte{byte(0xb7 + len(l))}, l...)
+		return append(o, arr...)
+	}
+}
 
-	ol := out.Len()
-	if out.Len() <= 55 {
-		return append([]byte{byte(0xc0 + ol)}, out.Bytes()...)
+// encodeRLPList prefixes the concatenated encoding of a list's items.
+func encodeRLPList(items []byte) []byte {
+	ol := len(items)
+	if ol <= 55 {
+		return append([]byte{byte(0xc0 + ol)}, items...)
 	}
 
 	ola := IntToArr(uint64(ol))
 	r := append([]byte{byte(0xf7 + len(ola))}, ola...)
-	return append(r, out.Bytes()...)
+	return append(r, items...)
 }
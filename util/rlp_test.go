@@ -74,3 +74,31 @@ func TestEncodeRLP(t *testing.T) {
 		}
 	}
 }
+
+func TestEncodeRLPValue(t *testing.T) {
+	var tests = []struct {
+		value   interface{}
+		encoded []byte
+	}{
+		{[]byte("dog"), []byte{0x83, byte('d'), byte('o'), byte('g')}},
+		{[]byte{}, []byte{0x80}},
+		{[]byte{0x0f}, []byte{0x0f}},
+		{[]byte{0x80}, []byte{0x81, 0x80}},
+		{[]interface{}{}, []byte{0xc0}},
+		{[][]byte{[]byte("cat"), []byte("dog")}, []byte{0xc8, 0x83, byte('c'), byte('a'), byte('t'), 0x83, byte('d'), byte('o'), byte('g')}},
+		// The set theoretical representation of three.
+		{
+			[]interface{}{[]interface{}{}, []interface{}{[]interface{}{}}, []interface{}{[]interface{}{}, []interface{}{[]interface{}{}}}},
+			[]byte{0xc7, 0xc0, 0xc1, 0xc0, 0xc3, 0xc0, 0xc1, 0xc0},
+		},
+		// Raw values are written verbatim.
+		{[]interface{}{RawRLP{0xc1, 0x01}, []byte{0x02}}, []byte{0xc3, 0xc1, 0x01, 0x02}},
+	}
+
+	for _, test := range tests {
+		encoded := EncodeRLPValue(test.value)
+		if !reflect.DeepEqual(encoded, test.encoded) {
+			t.Fatalf("Expected: %+v, received: %+v", test.encoded, encoded)
+		}
+	}
+}